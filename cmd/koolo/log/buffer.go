@@ -0,0 +1,221 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentEntriesSize is the number of entries kept in memory for the dashboard, for every logger
+const recentEntriesSize = 2000
+
+// Entry is a log record kept in memory so it can be served without reading the log files
+type Entry struct {
+	Time       time.Time         `json:"time"`
+	Level      string            `json:"level"`
+	Supervisor string            `json:"supervisor"`
+	Message    string            `json:"message"`
+	Attrs      map[string]string `json:"attrs,omitempty"`
+}
+
+// Filter selects the entries returned by Tail, zero values match everything
+type Filter struct {
+	// Supervisor is the supervisor name, Koolo itself logs with an empty supervisor
+	Supervisor *string
+	// MinLevel is a pointer like Supervisor, the zero level is info and would leave the debug entries out
+	MinLevel *slog.Level
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+func (f Filter) matches(e Entry, level slog.Level) bool {
+	if f.Supervisor != nil && *f.Supervisor != e.Supervisor {
+		return false
+	}
+	if f.MinLevel != nil && level < *f.MinLevel {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+type entryBuffer struct {
+	mu      sync.RWMutex
+	entries []Entry
+	levels  []slog.Level
+	next    int
+	full    bool
+}
+
+func newEntryBuffer(size int) *entryBuffer {
+	return &entryBuffer{entries: make([]Entry, size), levels: make([]slog.Level, size)}
+}
+
+func (b *entryBuffer) add(e Entry, level slog.Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = e
+	b.levels[b.next] = level
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// each calls fn with every entry, oldest first
+func (b *entryBuffer) each(fn func(e Entry, level slog.Level)) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	start, count := 0, b.next
+	if b.full {
+		start, count = b.next, len(b.entries)
+	}
+	for i := 0; i < count; i++ {
+		idx := (start + i) % len(b.entries)
+		fn(b.entries[idx], b.levels[idx])
+	}
+}
+
+var (
+	buffersMux sync.RWMutex
+	buffers    = make(map[string]*entryBuffer)
+)
+
+// buffer returns the in memory entries of the given logger, every supervisor has its own so a noisy one doesn't push
+// the entries of the others out
+func buffer(supervisor string) *entryBuffer {
+	buffersMux.Lock()
+	defer buffersMux.Unlock()
+
+	b, found := buffers[supervisor]
+	if !found {
+		b = newEntryBuffer(recentEntriesSize)
+		buffers[supervisor] = b
+	}
+
+	return b
+}
+
+// Tail returns the most recent entries matching the filter, oldest first
+func Tail(filter Filter) []Entry {
+	buffersMux.RLock()
+	selected := make([]*entryBuffer, 0, len(buffers))
+	for supervisor, b := range buffers {
+		if filter.Supervisor == nil || *filter.Supervisor == supervisor {
+			selected = append(selected, b)
+		}
+	}
+	buffersMux.RUnlock()
+
+	entries := make([]Entry, 0)
+	for _, b := range selected {
+		b.each(func(e Entry, level slog.Level) {
+			if filter.matches(e, level) {
+				entries = append(entries, e)
+			}
+		})
+	}
+
+	// Entries of a single buffer are already sorted, a stable sort keeps the order of entries logged at the same time
+	slices.SortStableFunc(entries, func(a, b Entry) int { return a.Time.Compare(b.Time) })
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+
+	return entries
+}
+
+// bufferHandler keeps the records in the in memory buffer of its logger
+type bufferHandler struct {
+	supervisor string
+	level      slog.Leveler
+	buffer     *entryBuffer
+	attrs      []slog.Attr
+	group      string
+}
+
+func newBufferHandler(supervisor string, level slog.Leveler) *bufferHandler {
+	return &bufferHandler{supervisor: supervisor, level: level, buffer: buffer(supervisor)}
+}
+
+func (h *bufferHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *bufferHandler) Handle(_ context.Context, r slog.Record) error {
+	e := Entry{Time: r.Time, Level: r.Level.String(), Supervisor: h.supervisor, Message: r.Message}
+	if len(h.attrs) > 0 || r.NumAttrs() > 0 {
+		e.Attrs = make(map[string]string, len(h.attrs)+r.NumAttrs())
+		for _, a := range h.attrs {
+			addAttr(e.Attrs, "", a)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			addAttr(e.Attrs, h.group, a)
+			return true
+		})
+	}
+	h.buffer.add(e, r.Level)
+
+	return nil
+}
+
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	h2.attrs = append(h2.attrs, h.attrs...)
+	for _, a := range attrs {
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		h2.attrs = append(h2.attrs, a)
+	}
+
+	return &h2
+}
+
+func (h *bufferHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+	h2.group = name
+
+	return &h2
+}
+
+func addAttr(attrs map[string]string, group string, a slog.Attr) {
+	key := a.Key
+	if group != "" {
+		key = group + "." + key
+	}
+
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, nested := range value.Group() {
+			addAttr(attrs, key, nested)
+		}
+		return
+	}
+	attrs[key] = fmt.Sprint(value.Any())
+}
+
+// ParseLevel parses the level names used by slog, e.g. "warn" or "ERROR"
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.ToUpper(name)))
+
+	return level, err
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+// resetBuffers empties the in memory entries of every logger for the duration of the test
+func resetBuffers(t *testing.T) {
+	buffersMux.Lock()
+	previous := buffers
+	buffers = make(map[string]*entryBuffer)
+	buffersMux.Unlock()
+
+	t.Cleanup(func() {
+		buffersMux.Lock()
+		buffers = previous
+		buffersMux.Unlock()
+	})
+}
+
+func messages(entries []Entry) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Message)
+	}
+
+	return names
+}
+
+func TestEntryBuffer(t *testing.T) {
+	b := newEntryBuffer(3)
+
+	var got []string
+	collect := func(e Entry, _ slog.Level) { got = append(got, e.Message) }
+
+	b.add(Entry{Message: "1"}, slog.LevelInfo)
+	b.add(Entry{Message: "2"}, slog.LevelInfo)
+	b.each(collect)
+	if expected := []string{"1", "2"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	for _, msg := range []string{"3", "4", "5"} {
+		b.add(Entry{Message: msg}, slog.LevelInfo)
+	}
+	got = nil
+	b.each(collect)
+	if expected := []string{"3", "4", "5"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected the oldest entries to be replaced, got %v", got)
+	}
+}
+
+func TestBufferPerSupervisor(t *testing.T) {
+	resetBuffers(t)
+
+	if buffer("sorc") != buffer("sorc") {
+		t.Fatalf("Expected the same buffer for the same supervisor")
+	}
+	if buffer("sorc") == buffer("sorc-2") || buffer("sorc") == buffer("") {
+		t.Fatalf("Expected every supervisor to have its own buffer")
+	}
+
+	quiet := slog.New(newBufferHandler("pala", slog.LevelInfo))
+	noisy := slog.New(newBufferHandler("sorc", slog.LevelInfo))
+	quiet.Info("still here")
+	for i := 0; i < recentEntriesSize+10; i++ {
+		noisy.Info("spam")
+	}
+
+	sorc, pala := "sorc", "pala"
+	if entries := Tail(Filter{Supervisor: &sorc}); len(entries) != recentEntriesSize {
+		t.Errorf("Expected %d entries, got %d", recentEntriesSize, len(entries))
+	}
+	if entries := Tail(Filter{Supervisor: &pala}); !slices.Equal(messages(entries), []string{"still here"}) {
+		t.Errorf("Expected the entries of a supervisor to survive the others logging, got %v", messages(entries))
+	}
+}
+
+func TestTail(t *testing.T) {
+	resetBuffers(t)
+
+	start := time.Date(2024, time.March, 9, 23, 15, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	add := func(supervisor, msg string, minutes int, level slog.Level) {
+		buffer(supervisor).add(Entry{Time: at(minutes), Level: level.String(), Supervisor: supervisor, Message: msg}, level)
+	}
+	add("", "koolo started", 0, slog.LevelInfo)
+	add("sorc", "sorc debug", 1, slog.LevelDebug)
+	add("pala", "pala chicken", 2, slog.LevelWarn)
+	add("sorc", "sorc error", 3, slog.LevelError)
+	add("pala", "pala info", 4, slog.LevelInfo)
+	add("sorc", "sorc info", 5, slog.LevelInfo)
+
+	koolo, sorc, unknown := "", "sorc", "unknown"
+	info, warn := slog.LevelInfo, slog.LevelWarn
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"all", Filter{}, []string{"koolo started", "sorc debug", "pala chicken", "sorc error", "pala info", "sorc info"}},
+		{"supervisor", Filter{Supervisor: &sorc}, []string{"sorc debug", "sorc error", "sorc info"}},
+		{"koolo", Filter{Supervisor: &koolo}, []string{"koolo started"}},
+		{"unknown supervisor", Filter{Supervisor: &unknown}, []string{}},
+		{"level", Filter{MinLevel: &warn}, []string{"pala chicken", "sorc error"}},
+		{"since", Filter{Since: at(3)}, []string{"sorc error", "pala info", "sorc info"}},
+		{"until", Filter{Until: at(1)}, []string{"koolo started", "sorc debug"}},
+		{"limit keeps the newest", Filter{Limit: 2}, []string{"pala info", "sorc info"}},
+		{"combined", Filter{Supervisor: &sorc, MinLevel: &info, Since: at(1), Limit: 1}, []string{"sorc info"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messages(Tail(tt.filter)); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBufferHandlerAttrs(t *testing.T) {
+	resetBuffers(t)
+
+	logger := slog.New(newBufferHandler("sorc", slog.LevelInfo)).With("run", "Pindleskin").WithGroup("item")
+	logger.Debug("hidden")
+	logger.Info("picked", "name", "Ber", slog.Group("stats", "sockets", 0))
+
+	entries := Tail(Filter{})
+	if len(entries) != 1 {
+		t.Fatalf("Expected only the enabled levels to be kept, got %v", messages(entries))
+	}
+	expected := map[string]string{"run": "Pindleskin", "item.name": "Ber", "item.stats.sockets": "0"}
+	if len(entries[0].Attrs) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, entries[0].Attrs)
+	}
+	for k, v := range expected {
+		if entries[0].Attrs[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, entries[0].Attrs[k])
+		}
+	}
+	if entries[0].Supervisor != "sorc" || entries[0].Level != "INFO" {
+		t.Errorf("Expected the supervisor and level to be set, got %+v", entries[0])
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]slog.Level{"debug": slog.LevelDebug, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		if level, err := ParseLevel(name); err != nil || level != expected {
+			t.Errorf("Expected %s to be %v, got %v, %v", name, expected, level, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("Expected unknown levels to fail")
+	}
+}
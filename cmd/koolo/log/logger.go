@@ -1,32 +1,106 @@
 package log
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+const (
+	defaultMaxSizeMB   = 20
+	defaultRotateHours = 24
+	defaultMaxFiles    = 10
+	defaultMaxAgeDays  = 14
 )
 
-var logFileHandler *os.File
+// Options are the logging settings with the defaults applied
+type Options struct {
+	JSON        bool
+	MaxSize     int64
+	RotateAfter time.Duration
+	MaxFiles    int
+	MaxAge      time.Duration
+}
+
+func optionsFromConfig(cfg config.LoggingCfg) Options {
+	opts := Options{
+		JSON:        cfg.JSON,
+		MaxSize:     int64(defaultMaxSizeMB) << 20,
+		RotateAfter: defaultRotateHours * time.Hour,
+		MaxFiles:    defaultMaxFiles,
+		MaxAge:      defaultMaxAgeDays * 24 * time.Hour,
+	}
+	if cfg.MaxSizeMB > 0 {
+		opts.MaxSize = int64(cfg.MaxSizeMB) << 20
+	}
+	if cfg.RotateHours > 0 {
+		opts.RotateAfter = time.Duration(cfg.RotateHours) * time.Hour
+	}
+	if cfg.MaxFiles > 0 {
+		opts.MaxFiles = cfg.MaxFiles
+	}
+	if cfg.MaxAgeDays > 0 {
+		opts.MaxAge = time.Duration(cfg.MaxAgeDays) * 24 * time.Hour
+	}
+
+	return opts
+}
+
+var (
+	filesMux sync.Mutex
+	// files are the log files by supervisor, Koolo itself uses the empty name
+	files = make(map[string]*rotatingFile)
+)
 
+// FlushLog syncs the log files of Koolo and every supervisor
 func FlushLog() {
-	if logFileHandler != nil {
-		logFileHandler.Sync()
+	filesMux.Lock()
+	defer filesMux.Unlock()
+
+	for _, f := range files {
+		f.Sync()
 	}
 }
 
+// FlushAndClose closes the log files of Koolo and every supervisor
 func FlushAndClose() error {
-	if logFileHandler != nil {
-		logFileHandler.Sync()
-		return logFileHandler.Close()
+	filesMux.Lock()
+	defer filesMux.Unlock()
+
+	var errs []error
+	for supervisor, f := range files {
+		errs = append(errs, f.Close())
+		delete(files, supervisor)
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func NewLogger(debug bool, logDir, supervisor string) (*slog.Logger, error) {
+// Close closes the log file of the given supervisor, its logger keeps working but only for stdout and the dashboard
+func Close(supervisor string) error {
+	filesMux.Lock()
+	defer filesMux.Unlock()
+
+	f, found := files[supervisor]
+	if !found {
+		return nil
+	}
+	delete(files, supervisor)
+
+	return f.Close()
+}
+
+// NewLogger creates the logger of Koolo (empty supervisor) or of a supervisor. Every logger writes to its own rotating
+// file, to stdout and to the in memory entries served to the dashboard. A previous logger of the same supervisor
+// stops writing to its file.
+func NewLogger(debug bool, logDir, supervisor string, logging config.LoggingCfg) (*slog.Logger, error) {
 	if logDir == "" {
 		logDir = "logs"
 	}
@@ -38,36 +112,93 @@ func NewLogger(debug bool, logDir, supervisor string) (*slog.Logger, error) {
 		}
 	}
 
-	fileName := "Koolo-log-" + time.Now().Format("2006-01-02-15-04-05") + ".txt"
+	opts := optionsFromConfig(logging)
+	prefix := "Koolo-log-"
 	if supervisor != "" {
-		fileName = fmt.Sprintf("Supervisor-log-%s-%s.txt", supervisor, time.Now().Format("2006-01-02-15-04-05"))
+		prefix = fmt.Sprintf("Supervisor-log-%s-", supervisor)
+	}
+	ext := ".txt"
+	if opts.JSON {
+		ext = ".json"
 	}
 
-	lfh, err := os.Create(logDir + "/" + fileName)
+	lfh, err := openRotatingFile(logDir, prefix, ext, opts)
 	if err != nil {
 		return nil, err
 	}
-	logFileHandler = lfh
+
+	filesMux.Lock()
+	if previous, found := files[supervisor]; found {
+		previous.Close()
+	}
+	files[supervisor] = lfh
+	filesMux.Unlock()
 
 	level := slog.LevelDebug
 	if !debug {
 		level = slog.LevelInfo
 	}
 
-	opts := &slog.HandlerOptions{
-		Level: level,
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			if a.Key != slog.TimeKey {
+	var fileHandler slog.Handler
+	if opts.JSON {
+		fileHandler = slog.NewJSONHandler(io.MultiWriter(lfh, os.Stdout), &slog.HandlerOptions{Level: level})
+	} else {
+		fileHandler = slog.NewTextHandler(io.MultiWriter(lfh, os.Stdout), &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key != slog.TimeKey {
+					return a
+				}
+
+				t := a.Value.Time()
+				a.Value = slog.StringValue(t.Format(time.TimeOnly))
+
 				return a
-			}
+			},
+		})
+	}
+
+	return slog.New(fanoutHandler{fileHandler, newBufferHandler(supervisor, level)}), nil
+}
+
+// fanoutHandler sends every record to all its handlers
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
 
-			t := a.Value.Time()
-			a.Value = slog.StringValue(t.Format(time.TimeOnly))
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, 0, len(h))
+	for _, handler := range h {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+
+	return handlers
+}
 
-			return a
-		},
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, 0, len(h))
+	for _, handler := range h {
+		handlers = append(handlers, handler.WithGroup(name))
 	}
-	handler := slog.NewTextHandler(io.MultiWriter(logFileHandler, os.Stdout), opts)
 
-	return slog.New(handler), nil
+	return handlers
 }
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fileTimeLayout = "2006-01-02-15-04-05"
+
+// rotatingFile is a log file replaced by a new one when it's too big or too old, only the newest files are kept
+type rotatingFile struct {
+	mu          sync.Mutex
+	dir         string
+	prefix      string
+	ext         string
+	maxSize     int64
+	rotateAfter time.Duration
+	maxFiles    int
+	maxAge      time.Duration
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+}
+
+func openRotatingFile(dir, prefix, ext string, opts Options) (*rotatingFile, error) {
+	f := &rotatingFile{
+		dir:         dir,
+		prefix:      prefix,
+		ext:         ext,
+		maxSize:     opts.MaxSize,
+		rotateAfter: opts.RotateAfter,
+		maxFiles:    opts.MaxFiles,
+		maxAge:      opts.MaxAge,
+	}
+	if err := f.rotate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Loggers of stopped supervisors may still be used by goroutines finishing their work, ignore them
+	if f.closed {
+		return len(p), nil
+	}
+
+	if f.size > 0 && (f.size+int64(len(p)) > f.maxSize || time.Since(f.openedAt) > f.rotateAfter) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	f.file.Sync()
+
+	return f.file.Close()
+}
+
+// rotate closes the current file and opens a new one, it must be called holding the lock
+func (f *rotatingFile) rotate() error {
+	if f.file != nil {
+		f.file.Sync()
+		f.file.Close()
+	}
+
+	now := time.Now()
+	path := filepath.Join(f.dir, f.prefix+now.Format(fileTimeLayout)+f.ext)
+	// Appending, a rotation in the same second must not truncate the previous file
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+
+	f.file = file
+	f.size = 0
+	if info, err := file.Stat(); err == nil {
+		f.size = info.Size()
+	}
+	f.openedAt = now
+	f.prune(path)
+
+	return nil
+}
+
+// prune removes the files over the retention limits, the current file is always kept
+func (f *rotatingFile) prune(current string) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+
+	type logFile struct {
+		path      string
+		createdAt time.Time
+	}
+	files := make([]logFile, 0)
+	for _, entry := range entries {
+		createdAt, ok := f.fileTime(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if path := filepath.Join(f.dir, entry.Name()); path != current {
+			files = append(files, logFile{path: path, createdAt: createdAt})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].createdAt.After(files[j].createdAt) })
+
+	for i, file := range files {
+		// The current file counts as one of the files kept
+		if i+1 >= f.maxFiles || time.Since(file.createdAt) > f.maxAge {
+			os.Remove(file.path)
+		}
+	}
+}
+
+// fileTime returns when a file written by this logger was created, a prefix shared with other loggers (e.g. the
+// supervisors "sorc" and "sorc-2") is not enough to match it
+func (f *rotatingFile) fileTime(name string) (time.Time, bool) {
+	rest, found := strings.CutPrefix(name, f.prefix)
+	if !found {
+		return time.Time{}, false
+	}
+	stamp, found := strings.CutSuffix(rest, f.ext)
+	if !found || len(stamp) != len(fileTimeLayout) {
+		return time.Time{}, false
+	}
+	createdAt, err := time.ParseInLocation(fileTimeLayout, stamp, time.Local)
+
+	return createdAt, err == nil
+}
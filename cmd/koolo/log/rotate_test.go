@@ -0,0 +1,185 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRotatingFile(t *testing.T, opts Options) *rotatingFile {
+	t.Helper()
+
+	f, err := openRotatingFile(t.TempDir(), "Supervisor-log-sorc-", ".txt", opts)
+	if err != nil {
+		t.Fatalf("Expected log file to be opened, got %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	return f
+}
+
+// logFiles returns the names of the files in the directory, oldest first
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Expected log directory to be readable, got %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// waitNextSecond waits until file names get a new timestamp, a rotation in the same second reopens the same file
+func waitNextSecond() {
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	f := testRotatingFile(t, Options{MaxSize: 100, RotateAfter: time.Hour, MaxFiles: 10, MaxAge: time.Hour})
+
+	line := []byte(strings.Repeat("a", 59) + "\n")
+	f.Write(line)
+	waitNextSecond()
+	f.Write(line)
+	if files := logFiles(t, f.dir); len(files) != 2 {
+		t.Fatalf("Expected a new file once the size limit is reached, got %v", files)
+	}
+
+	// A single record bigger than the limit is still written to an empty file
+	waitNextSecond()
+	big := []byte(strings.Repeat("b", 150) + "\n")
+	f.Write(big)
+	files := logFiles(t, f.dir)
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %v", files)
+	}
+	content, _ := os.ReadFile(filepath.Join(f.dir, files[2]))
+	if string(content) != string(big) {
+		t.Errorf("Expected the newest file to only contain the last record, got %d bytes", len(content))
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	f := testRotatingFile(t, Options{MaxSize: 1 << 20, RotateAfter: time.Hour, MaxFiles: 10, MaxAge: 24 * time.Hour})
+
+	f.Write([]byte("first\n"))
+	waitNextSecond()
+	f.Write([]byte("second\n"))
+	if files := logFiles(t, f.dir); len(files) != 1 {
+		t.Fatalf("Expected no rotation before the file is old enough, got %v", files)
+	}
+
+	f.openedAt = time.Now().Add(-2 * time.Hour)
+	f.Write([]byte("third\n"))
+	files := logFiles(t, f.dir)
+	if len(files) != 2 {
+		t.Fatalf("Expected a new file once the file is too old, got %v", files)
+	}
+	content, _ := os.ReadFile(filepath.Join(f.dir, files[1]))
+	if string(content) != "third\n" {
+		t.Errorf("Expected the new file to contain the last record, got %q", content)
+	}
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	f := testRotatingFile(t, Options{MaxSize: 100, RotateAfter: time.Hour, MaxFiles: 10, MaxAge: time.Hour})
+	f.Close()
+
+	if n, err := f.Write([]byte("late record\n")); err != nil || n != 12 {
+		t.Errorf("Expected writes after closing to be ignored, got %d, %v", n, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("Expected closing twice to be fine, got %v", err)
+	}
+}
+
+func TestRotatingFilePrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	create := func(prefix string, age time.Duration) string {
+		name := prefix + now.Add(-age).Format(fileTimeLayout) + ".txt"
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("Expected log file to be created, got %v", err)
+		}
+		return name
+	}
+
+	current := create("Supervisor-log-sorc-", 0)
+	sorc := []string{
+		create("Supervisor-log-sorc-", time.Hour),
+		create("Supervisor-log-sorc-", 2*time.Hour),
+		create("Supervisor-log-sorc-", 3*time.Hour),
+		create("Supervisor-log-sorc-", 4*time.Hour),
+	}
+	old := create("Supervisor-log-sorc-", 30*24*time.Hour)
+	// Files of a supervisor whose name starts like this one and unrelated files are never removed
+	others := []string{
+		create("Supervisor-log-sorc-2-", 5*time.Hour),
+		create("Supervisor-log-sorc-2-", 30*24*time.Hour),
+		create("Koolo-log-", 30*24*time.Hour),
+		"Supervisor-log-sorc-notes.txt",
+	}
+	os.WriteFile(filepath.Join(dir, others[3]), nil, 0644)
+	// Directories are skipped even when named like log files
+	folder := "Supervisor-log-sorc-" + now.Add(-48*time.Hour).Format(fileTimeLayout) + ".txt"
+	os.Mkdir(filepath.Join(dir, folder), 0755)
+
+	f := &rotatingFile{dir: dir, prefix: "Supervisor-log-sorc-", ext: ".txt", maxFiles: 3, maxAge: 24 * time.Hour}
+	f.prune(filepath.Join(dir, current))
+
+	expected := append([]string{current, sorc[0], sorc[1], folder}, others...)
+	slices.Sort(expected)
+	if files := logFiles(t, dir); !slices.Equal(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	// The age limit applies even when there are less files than the limit
+	f.maxFiles = 10
+	f.maxAge = 90 * time.Minute
+	os.WriteFile(filepath.Join(dir, sorc[1]), nil, 0644)
+	os.WriteFile(filepath.Join(dir, old), nil, 0644)
+	f.prune(filepath.Join(dir, current))
+	for _, name := range []string{sorc[1], old} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed for being too old", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, sorc[0])); err != nil {
+		t.Errorf("Expected %s to be kept, got %v", sorc[0], err)
+	}
+}
+
+func TestRotatingFileTime(t *testing.T) {
+	f := &rotatingFile{prefix: "Supervisor-log-sorc-", ext: ".json"}
+
+	tests := []struct {
+		name  string
+		match bool
+	}{
+		{"Supervisor-log-sorc-2024-03-09-23-15-00.json", true},
+		{"Supervisor-log-sorc-2024-03-09-23-15-00.txt", false},
+		{"Supervisor-log-sorc-2-2024-03-09-23-15-00.json", false},
+		{"Supervisor-log-sorcx2024-03-09-23-15-00.json", false},
+		{"Supervisor-log-sorc-2024-13-09-23-15-00.json", false},
+		{"Koolo-log-2024-03-09-23-15-00.json", false},
+	}
+
+	for _, tt := range tests {
+		createdAt, ok := f.fileTime(tt.name)
+		if ok != tt.match {
+			t.Errorf("Expected %s match to be %v", tt.name, tt.match)
+		}
+		if ok && !createdAt.Equal(time.Date(2024, time.March, 9, 23, 15, 0, 0, time.Local)) {
+			t.Errorf("Expected %s creation time to be parsed, got %v", tt.name, createdAt)
+		}
+	}
+}
@@ -55,12 +55,21 @@ func main() {
 		return
 	}
 
-	logger, err := sloggger.NewLogger(config.Koolo.Debug.Log, config.Koolo.LogSaveDirectory, "")
+	logger, err := sloggger.NewLogger(config.Koolo.Debug.Log, config.Koolo.LogSaveDirectory, "", config.Koolo.Logging)
 	if err != nil {
 		log.Fatalf("Error starting logger: %s", err.Error())
 	}
 	defer sloggger.FlushAndClose()
 
+	// Profiles of an older config version are saved once with the current one, a backup of the original is kept
+	migrations, err := config.MigrateConfigs()
+	for _, m := range migrations {
+		logger.Info("Character config migrated", slog.String("profile", m.Profile), slog.String("file", m.Path), slog.Int("fromVersion", m.FromVersion), slog.Int("toVersion", config.CurrentConfigVersion))
+	}
+	if err != nil {
+		logger.Error("Error saving migrated character configs, they are only migrated in memory", slog.Any("error", err))
+	}
+
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("fatal error detected, Koolo will close with the following error: %v\n Stacktrace: %s", r, debug.Stack())
@@ -94,6 +103,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error starting local server: %s", err.Error())
 	}
+	eventListener.Register(srv.Handle)
 
 	// Use wrapWithRecover for all goroutines to handle panics
 	g.Go(wrapWithRecover(logger, func() error {
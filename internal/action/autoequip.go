@@ -13,6 +13,7 @@ import (
 	"github.com/hectorgimenez/koolo/internal/action/step"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
 	"github.com/hectorgimenez/koolo/internal/ui"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
@@ -138,11 +139,20 @@ func AutoEquip() error {
 }
 
 func IsBetterThanEquipped(itm data.Item, forMerc bool, scoreFunc func(data.Item) map[item.LocationType]float64) bool {
+	better, _ := compareWithEquipped(itm, forMerc, scoreFunc)
+
+	return better
+}
+
+// compareWithEquipped returns whether the item is better than the equipped one, along with the scores compared. When
+// it's not better, the comparison is the one of the body location where it scores the highest.
+func compareWithEquipped(itm data.Item, forMerc bool, scoreFunc func(data.Item) map[item.LocationType]float64) (bool, itemlog.TierComparison) {
 	ctx := context.Get()
+	comparison := itemlog.TierComparison{Merc: forMerc}
 
 	bodyLocs := itm.Desc().GetType().BodyLocs
 	if len(bodyLocs) == 0 {
-		return false
+		return false, comparison
 	}
 
 	scores := scoreFunc(itm)
@@ -165,12 +175,19 @@ func IsBetterThanEquipped(itm data.Item, forMerc bool, scoreFunc func(data.Item)
 
 		equippedScore := scoreFunc(currentlyEquipped)
 
-		if itmScore > equippedScore[loc] {
-			return true
+		better := itmScore > equippedScore[loc]
+		if better || comparison.BodyLocation == "" || itmScore > comparison.ItemScore {
+			comparison.BodyLocation = string(loc)
+			comparison.ItemScore = itmScore
+			comparison.EquippedScore = equippedScore[loc]
+		}
+
+		if better {
+			return true, comparison
 		}
 	}
 
-	return false
+	return false, comparison
 }
 
 func equipCTAIfFound(allItems []data.Item) (bool, error) {
@@ -2,63 +2,131 @@ package action
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/npc"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/d2go/pkg/data/state"
 	"github.com/hectorgimenez/koolo/internal/action/step"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/game"
 	"github.com/hectorgimenez/koolo/internal/pather"
+	"github.com/hectorgimenez/koolo/internal/threat"
 )
 
 func ClearAreaAroundPlayer(radius int, filter data.MonsterFilter) error {
 	return ClearAreaAroundPosition(context.Get().Data.PlayerUnit.Position, radius, filter)
 }
 
-func IsPriorityMonster(m data.Monster) bool {
-	priorityMonsters := []npc.ID{
-		npc.FallenShaman,
-		npc.CarverShaman,
-		npc.DevilkinShaman,
-		npc.DarkShaman,
-		npc.WarpedShaman,
-		npc.MummyGenerator,
-		npc.BaalSubjectMummy,
-		npc.FetishShaman,
+// monsterThreatTags groups monsters scored together by the threat model
+var monsterThreatTags = map[npc.ID]string{
+	npc.FallenShaman:     "shaman",
+	npc.CarverShaman:     "shaman",
+	npc.DevilkinShaman:   "shaman",
+	npc.DarkShaman:       "shaman",
+	npc.WarpedShaman:     "shaman",
+	npc.FetishShaman:     "shaman",
+	npc.MummyGenerator:   "generator",
+	npc.BaalSubjectMummy: "generator",
+}
+
+var monsterThreatKinds = map[data.MonsterType]string{
+	data.MonsterTypeMinion:      "minion",
+	data.MonsterTypeChampion:    "champion",
+	data.MonsterTypeUnique:      "unique",
+	data.MonsterTypeSuperUnique: "superunique",
+}
+
+// monsterEnchantmentStats are the elemental damage stats the game adds to elite monsters with the matching
+// enchantment, the other enchantments (multishot, cursed, extra fast...) don't leave anything on the monster we can read
+var monsterEnchantmentStats = []struct {
+	enchantment string
+	stat        stat.ID
+}{
+	{"lightning_enchanted", stat.LightningMinDamage},
+	{"cold_enchanted", stat.ColdMinDamage},
+	{"fire_enchanted", stat.FireMinDamage},
+}
+
+// monsterEnchantments returns the enchantments of the monster known by the threat model, only elite monsters have them
+func monsterEnchantments(m data.Monster) []string {
+	if _, elite := monsterThreatKinds[m.Type]; !elite {
+		return nil
 	}
 
-	for _, priorityMonster := range priorityMonsters {
-		if m.Name == priorityMonster {
-			return true
+	var enchantments []string
+	for _, e := range monsterEnchantmentStats {
+		if m.Stats[e.stat] > 0 {
+			enchantments = append(enchantments, e.enchantment)
 		}
 	}
-	return false
+
+	return enchantments
 }
 
-func SortEnemiesByPriority(enemies *[]data.Monster) {
+// threatTarget returns the threat model view of the monster, others are the monsters around used to score packs
+func threatTarget(m data.Monster, others []data.Monster, model threat.Model) threat.Target {
 	ctx := context.Get()
-	sort.Slice(*enemies, func(i, j int) bool {
-		monsterI := (*enemies)[i]
-		monsterJ := (*enemies)[j]
 
-		isPriorityI := IsPriorityMonster(monsterI)
-		isPriorityJ := IsPriorityMonster(monsterJ)
-
-		distanceI := ctx.PathFinder.DistanceFromMe(monsterI.Position)
-		distanceJ := ctx.PathFinder.DistanceFromMe(monsterJ.Position)
-
-		if distanceI > 2 && distanceJ > 2 {
-			if isPriorityI && !isPriorityJ {
-				return true
-			} else if !isPriorityI && isPriorityJ {
-				return false
+	kind, found := monsterThreatKinds[m.Type]
+	if !found {
+		kind = "normal"
+	}
+	t := threat.Target{
+		ID:           int(m.Name),
+		Kind:         kind,
+		Enchantments: monsterEnchantments(m),
+		Distance:     ctx.PathFinder.DistanceFromMe(m.Position),
+	}
+	if tag, found := monsterThreatTags[m.Name]; found {
+		t.Tags = append(t.Tags, tag)
+	}
+	if m.IsMonsterRaiser() {
+		t.Tags = append(t.Tags, "raiser")
+	}
+	// Auras are seen through the states they set on the monster
+	for _, s := range model.States {
+		if m.States.HasState(state.State(s.State)) {
+			t.States = append(t.States, s.State)
+		}
+	}
+	for _, damageType := range ctx.CharacterCfg.Character.DamageTypes {
+		if m.IsImmune(damageType) {
+			t.Immunities = append(t.Immunities, string(damageType))
+		}
+	}
+	if model.DensityRadius > 0 {
+		for _, o := range others {
+			if o.UnitID != m.UnitID && pather.DistanceFromPoint(m.Position, o.Position) <= model.DensityRadius {
+				t.Nearby++
 			}
 		}
+	}
+
+	return t
+}
+
+// SortEnemiesByPriority sorts the enemies from the most to the least dangerous according to the threat model
+func SortEnemiesByPriority(enemies *[]data.Monster) {
+	ctx := context.Get()
+	model := threat.Current()
+
+	targets := make([]threat.Target, 0, len(*enemies))
+	for _, m := range *enemies {
+		targets = append(targets, threatTarget(m, *enemies, model))
+	}
 
-		return distanceI < distanceJ
-	})
+	damageTypes := make([]string, 0, len(ctx.CharacterCfg.Character.DamageTypes))
+	for _, damageType := range ctx.CharacterCfg.Character.DamageTypes {
+		damageTypes = append(damageTypes, string(damageType))
+	}
+
+	sorted := make([]data.Monster, 0, len(*enemies))
+	for _, idx := range model.Order(targets, damageTypes) {
+		sorted = append(sorted, (*enemies)[idx])
+	}
+	*enemies = sorted
 }
 
 func ClearAreaAroundPosition(pos data.Position, radius int, filters ...data.MonsterFilter) error {
@@ -0,0 +1,50 @@
+package action
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/koolo/internal/threat"
+)
+
+func TestMonsterEnchantments(t *testing.T) {
+	tests := []struct {
+		name     string
+		monster  data.Monster
+		expected []string
+	}{
+		{"plain champion", data.Monster{Type: data.MonsterTypeChampion, Stats: map[stat.ID]int{stat.Life: 100}}, nil},
+		{
+			"lightning enchanted unique",
+			data.Monster{Type: data.MonsterTypeUnique, Stats: map[stat.ID]int{stat.LightningMinDamage: 12}},
+			[]string{"lightning_enchanted"},
+		},
+		{
+			"cold and fire enchanted minion",
+			data.Monster{Type: data.MonsterTypeMinion, Stats: map[stat.ID]int{stat.FireMinDamage: 5, stat.ColdMinDamage: 5}},
+			[]string{"cold_enchanted", "fire_enchanted"},
+		},
+		{"normal monster dealing fire damage", data.Monster{Stats: map[stat.ID]int{stat.FireMinDamage: 5}}, nil},
+	}
+
+	for _, tt := range tests {
+		if enchantments := monsterEnchantments(tt.monster); !slices.Equal(enchantments, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, enchantments)
+		}
+	}
+}
+
+func TestEnchantedMonsterFirst(t *testing.T) {
+	plain := data.Monster{Type: data.MonsterTypeChampion, Stats: map[stat.ID]int{}}
+	enchanted := data.Monster{Type: data.MonsterTypeChampion, Stats: map[stat.ID]int{stat.LightningMinDamage: 12}}
+
+	targets := []threat.Target{
+		{Kind: "champion", Enchantments: monsterEnchantments(plain), Distance: 6},
+		{Kind: "champion", Enchantments: monsterEnchantments(enchanted), Distance: 9},
+	}
+	if order := threat.Current().Order(targets, nil); order[0] != 1 {
+		t.Errorf("Expected the lightning enchanted champion to be attacked first, got %v", order)
+	}
+}
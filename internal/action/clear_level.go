@@ -35,7 +35,22 @@ func ClearCurrentLevelEx(openChests bool, filter data.MonsterFilter, shouldInter
 
 	// We can make this configurable later, but 20 is a good starting radius.
 	const pickupRadius = 20
-	rooms := ctx.PathFinder.OptimizeRoomsTraverseOrder()
+	// Rooms with known elites, or chests when we are opening them, are visited first if it's not a big detour
+	rooms := ctx.PathFinder.OptimizeRoomsTraverseOrderWithPriority(func(r data.Room) bool {
+		for _, m := range ctx.Data.Monsters.Enemies() {
+			if m.IsElite() && r.IsInside(m.Position) {
+				return true
+			}
+		}
+		if openChests {
+			for _, o := range ctx.Data.Objects {
+				if o.IsChest() && o.Selectable && r.IsInside(o.Position) {
+					return true
+				}
+			}
+		}
+		return false
+	})
 	for _, r := range rooms {
 		if errDeath := checkPlayerDeath(ctx); errDeath != nil {
 			return errDeath
@@ -116,16 +131,12 @@ func clearRoom(room data.Room, filter data.MonsterFilter) error {
 
 		SortEnemiesByPriority(&monsters)
 
-		// Check if there are monsters that can summon new monsters, and kill them first
+		// Monsters are sorted by threat, monsters raising others already come first
 		targetMonster := data.Monster{}
 		for _, m := range monsters {
 			if !ctx.Char.ShouldIgnoreMonster(m) {
-				if m.IsMonsterRaiser() {
-					targetMonster = m
-					break
-				} else if targetMonster.UnitID == 0 {
-					targetMonster = m
-				}
+				targetMonster = m
+				break
 			}
 		}
 
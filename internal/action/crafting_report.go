@@ -0,0 +1,154 @@
+package action
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+type CraftingReport struct {
+	CubeRecipes []CraftingReportEntry `json:"cubeRecipes"`
+	Runewords   []CraftingReportEntry `json:"runewords"`
+}
+
+type CraftingReportEntry struct {
+	Name string `json:"name"`
+	// CanMake is true when all the ingredients are available right now
+	CanMake bool `json:"canMake"`
+	// Transmutes is the amount of times the cube recipe would be done, including items produced by other recipes
+	Transmutes int      `json:"transmutes,omitempty"`
+	Have       []string `json:"have"`
+	Missing    []string `json:"missing"`
+	Purchase   []string `json:"purchase,omitempty"`
+	Summary    string   `json:"summary"`
+}
+
+// BuildCraftingReport reports which enabled cube recipes and runewords can be made with the given items (usually a
+// stash snapshot) and what is missing for the rest. It doesn't need the game to be running.
+func BuildCraftingReport(cfg *config.CharacterCfg, items []data.Item) CraftingReport {
+	report := CraftingReport{
+		CubeRecipes: make([]CraftingReportEntry, 0),
+		Runewords:   make([]CraftingReportEntry, 0),
+	}
+
+	match := cubeIngredientMatcher(cfg)
+	recipes := make([]config.CubeRecipeDefinition, 0, len(cfg.CubeRecipes.EnabledRecipes))
+	for _, recipe := range config.CubeRecipeDefinitions {
+		if slices.Contains(cfg.CubeRecipes.EnabledRecipes, recipe.Name) {
+			recipes = append(recipes, recipe)
+		}
+	}
+
+	transmutes := make(map[string]int)
+	for _, step := range planCubeRecipes(recipes, items, cfg.CubeRecipes.KeepQuotas, match) {
+		transmutes[step.Recipe.Name]++
+	}
+
+	pool := make([]cubeItem, 0, len(items))
+	for _, itm := range items {
+		pool = append(pool, cubeItem{Item: itm})
+	}
+	for _, recipe := range recipes {
+		selected, shortfalls := fillCubeRecipe(recipe, pool, cfg.CubeRecipes.KeepQuotas, match)
+
+		entry := CraftingReportEntry{
+			Name:       recipe.Name,
+			CanMake:    len(shortfalls) == 0,
+			Transmutes: transmutes[recipe.Name],
+			Have:       make([]string, 0, len(selected)),
+			Missing:    make([]string, 0, len(shortfalls)),
+			Purchase:   recipe.Purchase,
+		}
+		for _, idx := range selected {
+			entry.Have = append(entry.Have, string(pool[idx].Item.Name))
+		}
+		for _, shortfall := range shortfalls {
+			entry.Missing = append(entry.Missing, describeCubeIngredient(shortfall.Ingredient, shortfall.Missing))
+		}
+		entry.Summary = craftingSummary(entry)
+		report.CubeRecipes = append(report.CubeRecipes, entry)
+	}
+
+	for _, recipe := range Runewords {
+		if !slices.Contains(cfg.Game.Leveling.EnabledRunewordRecipes, string(recipe.Name)) {
+			continue
+		}
+
+		have, missing := runewordRunesShortfall(items, recipe)
+		if _, hasBase := hasBaseForRunewordRecipe(items, recipe); hasBase {
+			have = append(have, "base")
+		} else {
+			missing = append(missing, fmt.Sprintf("base (%d sockets %s)", len(recipe.Runes), strings.Join(recipe.BaseItemTypes, "/")))
+		}
+
+		entry := CraftingReportEntry{
+			Name:    string(recipe.Name),
+			CanMake: len(missing) == 0,
+			Have:    have,
+			Missing: missing,
+		}
+		entry.Summary = craftingSummary(entry)
+		report.Runewords = append(report.Runewords, entry)
+	}
+
+	return report
+}
+
+// runewordRunesShortfall splits the runes of the recipe between the available ones and the missing ones
+func runewordRunesShortfall(items []data.Item, recipe Runeword) ([]string, []string) {
+	found, missingRunes := matchRunewordRunes(items, recipe)
+
+	have := make([]string, 0, len(found))
+	for _, itm := range found {
+		have = append(have, strings.TrimSuffix(string(itm.Name), "Rune"))
+	}
+	missing := make([]string, 0, len(missingRunes))
+	for _, runeName := range missingRunes {
+		missing = append(missing, strings.TrimSuffix(runeName, "Rune"))
+	}
+
+	return have, missing
+}
+
+func describeCubeIngredient(ingredient config.CubeIngredient, amount int) string {
+	description := ingredient.Name
+	switch {
+	case len(ingredient.Names) > 0:
+		description = strings.Join(ingredient.Names, "/")
+	case description == "":
+		description = "base (" + strings.Join(ingredient.Types, "/") + ")"
+		if ingredient.Socketable {
+			description = "socketable " + description
+		}
+	}
+	if ingredient.Quality != "" {
+		description = ingredient.Quality + " " + description
+	}
+	if amount > 1 {
+		description = fmt.Sprintf("%dx %s", amount, description)
+	}
+
+	return description
+}
+
+// craftingSummary returns a human readable summary like "Enigma: need Jah, have Ith + Ber + base"
+func craftingSummary(entry CraftingReportEntry) string {
+	have := "nothing"
+	if len(entry.Have) > 0 {
+		have = strings.Join(entry.Have, " + ")
+	}
+
+	if len(entry.Missing) > 0 {
+		return fmt.Sprintf("%s: need %s, have %s", entry.Name, strings.Join(entry.Missing, " + "), have)
+	}
+
+	summary := fmt.Sprintf("%s: ready with %s", entry.Name, have)
+	if len(entry.Purchase) > 0 {
+		summary += ", buying one of " + strings.Join(entry.Purchase, "/")
+	}
+
+	return summary
+}
@@ -0,0 +1,200 @@
+package action
+
+import (
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+// maxCubePlanSteps protects the planner against recipe definitions producing their own ingredients
+const maxCubePlanSteps = 500
+
+// cubeItem is an item that can be used by the planner, virtual items are the outputs of previously planned transmutes
+type cubeItem struct {
+	Item    data.Item
+	Virtual bool
+}
+
+// cubeItemMatcher returns true if the (real) item can be used as the given recipe ingredient
+type cubeItemMatcher func(recipe config.CubeRecipeDefinition, ingredient config.CubeIngredient, itm data.Item) bool
+
+type CubePlanStep struct {
+	Recipe config.CubeRecipeDefinition
+	// Items are the ingredients planned for this transmute, items produced by previous steps only have name and quality
+	Items []data.Item
+}
+
+// planCubeRecipes computes the transmutes that can be done with the given items. Recipes producing items used by
+// other recipes are planned first, so chained upgrades (Upgrade Ral feeding Upgrade Ort) are done in a single pass.
+// Items are never consumed below their keep quota, outputs of planned transmutes are counted towards the quota.
+func planCubeRecipes(recipes []config.CubeRecipeDefinition, items []data.Item, keepQuotas map[string]int, match cubeItemMatcher) []CubePlanStep {
+	pool := make([]cubeItem, 0, len(items))
+	for _, itm := range items {
+		pool = append(pool, cubeItem{Item: itm})
+	}
+
+	steps := make([]CubePlanStep, 0)
+	for _, recipe := range orderCubeRecipes(recipes) {
+		for len(steps) < maxCubePlanSteps {
+			selected, found := matchCubeRecipe(recipe, pool, keepQuotas, match)
+			if !found {
+				break
+			}
+
+			step := CubePlanStep{Recipe: recipe, Items: make([]data.Item, 0, len(selected))}
+			for _, idx := range selected {
+				step.Items = append(step.Items, pool[idx].Item)
+			}
+			steps = append(steps, step)
+
+			pool = removeCubeItems(pool, selected)
+			for _, output := range recipe.Outputs {
+				if output.Name == "" {
+					continue
+				}
+				quality, _ := config.ParseCubeQuality(output.Quality)
+				pool = append(pool, cubeItem{Item: data.Item{Name: item.Name(output.Name), Quality: quality}, Virtual: true})
+			}
+		}
+	}
+
+	return steps
+}
+
+// cubeIngredientShortfall is an ingredient that can't be filled with the available items
+type cubeIngredientShortfall struct {
+	Ingredient config.CubeIngredient
+	Missing    int
+}
+
+// matchCubeRecipe returns the index of the pool items to use for the recipe, ingredients are filled in order
+func matchCubeRecipe(recipe config.CubeRecipeDefinition, pool []cubeItem, keepQuotas map[string]int, match cubeItemMatcher) ([]int, bool) {
+	selected, shortfalls := fillCubeRecipe(recipe, pool, keepQuotas, match)
+	if len(shortfalls) > 0 {
+		return nil, false
+	}
+
+	return selected, true
+}
+
+// fillCubeRecipe assigns pool items to every ingredient, ingredients that can't be completely filled are returned
+// as shortfalls and the rest of ingredients are still filled
+func fillCubeRecipe(recipe config.CubeRecipeDefinition, pool []cubeItem, keepQuotas map[string]int, match cubeItemMatcher) ([]int, []cubeIngredientShortfall) {
+	available := make(map[item.Name]int)
+	for _, ci := range pool {
+		available[ci.Item.Name]++
+	}
+
+	taken := make([]bool, len(pool))
+	selected := make([]int, 0)
+	shortfalls := make([]cubeIngredientShortfall, 0)
+	for _, ingredient := range recipe.Ingredients {
+		needed := ingredient.Amount()
+		for idx, ci := range pool {
+			if needed == 0 {
+				break
+			}
+			if taken[idx] || available[ci.Item.Name] <= keepQuotas[string(ci.Item.Name)] {
+				continue
+			}
+
+			if ci.Virtual {
+				if !virtualItemMatches(ingredient, ci.Item) {
+					continue
+				}
+			} else if !match(recipe, ingredient, ci.Item) {
+				continue
+			}
+
+			taken[idx] = true
+			available[ci.Item.Name]--
+			selected = append(selected, idx)
+			needed--
+		}
+
+		if needed > 0 {
+			shortfalls = append(shortfalls, cubeIngredientShortfall{Ingredient: ingredient, Missing: needed})
+		}
+	}
+
+	return selected, shortfalls
+}
+
+// virtualItemMatches checks items that are not crafted yet, we only know their name and quality so ingredients
+// depending on other properties (type, sockets, pickit rules...) are never matched
+func virtualItemMatches(ingredient config.CubeIngredient, itm data.Item) bool {
+	if ingredient.Name == "" && len(ingredient.Names) == 0 {
+		return false
+	}
+	if len(ingredient.Types) > 0 || ingredient.Socketable || ingredient.UnmatchedByPickit || ingredient.MinLevelReq > 0 || ingredient.MaxLevelReq > 0 {
+		return false
+	}
+	if quality, found := config.ParseCubeQuality(ingredient.Quality); found && itm.Quality != quality {
+		return false
+	}
+
+	return ingredient.MatchesName(string(itm.Name))
+}
+
+// orderCubeRecipes sorts the recipes so producers go before the recipes consuming their outputs, keeping the
+// original order otherwise. Recipes that are part of a dependency cycle keep their original order.
+func orderCubeRecipes(recipes []config.CubeRecipeDefinition) []config.CubeRecipeDefinition {
+	inDegree := make([]int, len(recipes))
+	consumers := make([][]int, len(recipes))
+	for p, producer := range recipes {
+		for c, consumer := range recipes {
+			if p == c || !producesIngredientOf(producer, consumer) {
+				continue
+			}
+			consumers[p] = append(consumers[p], c)
+			inDegree[c]++
+		}
+	}
+
+	ordered := make([]config.CubeRecipeDefinition, 0, len(recipes))
+	done := make([]bool, len(recipes))
+	for len(ordered) < len(recipes) {
+		next := -1
+		for i := range recipes {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		// Dependency cycle, take the first pending recipe
+		if next == -1 {
+			next = slices.Index(done, false)
+		}
+
+		done[next] = true
+		ordered = append(ordered, recipes[next])
+		for _, c := range consumers[next] {
+			inDegree[c]--
+		}
+	}
+
+	return ordered
+}
+
+func producesIngredientOf(producer, consumer config.CubeRecipeDefinition) bool {
+	for _, output := range producer.Outputs {
+		if output.Name != "" && consumer.Uses(output.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeCubeItems(pool []cubeItem, indexes []int) []cubeItem {
+	remaining := make([]cubeItem, 0, len(pool))
+	for idx, ci := range pool {
+		if !slices.Contains(indexes, idx) {
+			remaining = append(remaining, ci)
+		}
+	}
+
+	return remaining
+}
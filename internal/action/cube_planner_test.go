@@ -0,0 +1,86 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+func upgradeRecipe(name, from, to string) config.CubeRecipeDefinition {
+	return config.CubeRecipeDefinition{
+		Name:        name,
+		Ingredients: []config.CubeIngredient{{Name: from, Count: 3}},
+		Outputs:     []config.CubeOutput{{Name: to}},
+	}
+}
+
+func matchByName(_ config.CubeRecipeDefinition, ingredient config.CubeIngredient, itm data.Item) bool {
+	return ingredient.MatchesName(string(itm.Name))
+}
+
+func runes(name string, count int) []data.Item {
+	items := make([]data.Item, 0, count)
+	for i := 0; i < count; i++ {
+		items = append(items, data.Item{Name: item.Name(name)})
+	}
+	return items
+}
+
+func planRecipeNames(steps []CubePlanStep) []string {
+	names := make([]string, 0, len(steps))
+	for _, step := range steps {
+		names = append(names, step.Recipe.Name)
+	}
+	return names
+}
+
+func TestPlanCubeRecipes(t *testing.T) {
+	// Defined in the opposite order they have to be done
+	recipes := []config.CubeRecipeDefinition{
+		upgradeRecipe("Upgrade Eld", "EldRune", "TirRune"),
+		upgradeRecipe("Upgrade El", "ElRune", "EldRune"),
+	}
+
+	tests := []struct {
+		name     string
+		items    []data.Item
+		quotas   map[string]int
+		expected []string
+	}{
+		{"not enough items", runes("ElRune", 2), nil, []string{}},
+		{"single transmute", runes("ElRune", 4), nil, []string{"Upgrade El"}},
+		{"chained", runes("ElRune", 9), nil, []string{"Upgrade El", "Upgrade El", "Upgrade El", "Upgrade Eld"}},
+		{"chained with stash items", append(runes("ElRune", 3), runes("EldRune", 2)...), nil, []string{"Upgrade El", "Upgrade Eld"}},
+		{"keep quota", runes("ElRune", 5), map[string]int{"ElRune": 3}, []string{}},
+		{"keep quota on outputs", runes("ElRune", 9), map[string]int{"EldRune": 1}, []string{"Upgrade El", "Upgrade El", "Upgrade El"}},
+	}
+
+	for _, tt := range tests {
+		steps := planCubeRecipes(recipes, tt.items, tt.quotas, matchByName)
+		names := planRecipeNames(steps)
+		if len(names) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, names)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, names)
+				break
+			}
+		}
+	}
+}
+
+func TestPlanCubeRecipesDependencyCycle(t *testing.T) {
+	recipes := []config.CubeRecipeDefinition{
+		upgradeRecipe("A to B", "A", "B"),
+		upgradeRecipe("B to A", "B", "A"),
+	}
+
+	steps := planCubeRecipes(recipes, runes("A", 3), nil, matchByName)
+	if len(steps) == 0 || len(steps) > maxCubePlanSteps {
+		t.Errorf("Expected the plan to be bounded, got %d steps", len(steps))
+	}
+}
@@ -2,409 +2,16 @@ package action
 
 import (
 	"slices"
-	"strings"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
 	"github.com/hectorgimenez/d2go/pkg/nip"
+	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
-type CubeRecipe struct {
-	Name             string
-	Items            []string
-	PurchaseRequired bool
-	PurchaseItems    []string
-}
-
-var (
-	Recipes = []CubeRecipe{
-
-		// Perfects
-		{
-			Name:  "Perfect Amethyst",
-			Items: []string{"FlawlessAmethyst", "FlawlessAmethyst", "FlawlessAmethyst"},
-		},
-		{
-			Name:  "Perfect Diamond",
-			Items: []string{"FlawlessDiamond", "FlawlessDiamond", "FlawlessDiamond"},
-		},
-		{
-			Name:  "Perfect Emerald",
-			Items: []string{"FlawlessEmerald", "FlawlessEmerald", "FlawlessEmerald"},
-		},
-		{
-			Name:  "Perfect Ruby",
-			Items: []string{"FlawlessRuby", "FlawlessRuby", "FlawlessRuby"},
-		},
-		{
-			Name:  "Perfect Sapphire",
-			Items: []string{"FlawlessSapphire", "FlawlessSapphire", "FlawlessSapphire"},
-		},
-		{
-			Name:  "Perfect Topaz",
-			Items: []string{"FlawlessTopaz", "FlawlessTopaz", "FlawlessTopaz"},
-		},
-		{
-			Name:  "Perfect Skull",
-			Items: []string{"FlawlessSkull", "FlawlessSkull", "FlawlessSkull"},
-		},
-
-		// Token
-		{
-			Name:  "Token of Absolution",
-			Items: []string{"TwistedEssenceOfSuffering", "ChargedEssenceOfHatred", "BurningEssenceOfTerror", "FesteringEssenceOfDestruction"},
-		},
-
-		// Runes
-		{
-			Name:  "Upgrade El",
-			Items: []string{"ElRune", "ElRune", "ElRune"},
-		},
-		{
-			Name:  "Upgrade Eld",
-			Items: []string{"EldRune", "EldRune", "EldRune"},
-		},
-		{
-			Name:  "Upgrade Tir",
-			Items: []string{"TirRune", "TirRune", "TirRune"},
-		},
-		{
-			Name:  "Upgrade Nef",
-			Items: []string{"NefRune", "NefRune", "NefRune"},
-		},
-		{
-			Name:  "Upgrade Eth",
-			Items: []string{"EthRune", "EthRune", "EthRune"},
-		},
-		{
-			Name:  "Upgrade Ith",
-			Items: []string{"IthRune", "IthRune", "IthRune"},
-		},
-		{
-			Name:  "Upgrade Tal",
-			Items: []string{"TalRune", "TalRune", "TalRune"},
-		},
-		{
-			Name:  "Upgrade Ral",
-			Items: []string{"RalRune", "RalRune", "RalRune"},
-		},
-		{
-			Name:  "Upgrade Ort",
-			Items: []string{"OrtRune", "OrtRune", "OrtRune"},
-		},
-		{
-			Name:  "Upgrade Thul",
-			Items: []string{"ThulRune", "ThulRune", "ThulRune", "ChippedTopaz"},
-		},
-		{
-			Name:  "Upgrade Amn",
-			Items: []string{"AmnRune", "AmnRune", "AmnRune", "ChippedAmethyst"},
-		},
-		{
-			Name:  "Upgrade Sol",
-			Items: []string{"SolRune", "SolRune", "SolRune", "ChippedSapphire"},
-		},
-		{
-			Name:  "Upgrade Shael",
-			Items: []string{"ShaelRune", "ShaelRune", "ShaelRune", "ChippedRuby"},
-		},
-		{
-			Name:  "Upgrade Dol",
-			Items: []string{"DolRune", "DolRune", "DolRune", "ChippedEmerald"},
-		},
-		{
-			Name:  "Upgrade Hel",
-			Items: []string{"HelRune", "HelRune", "HelRune", "ChippedDiamond"},
-		},
-		{
-			Name:  "Upgrade Io",
-			Items: []string{"IoRune", "IoRune", "IoRune", "FlawedTopaz"},
-		},
-		{
-			Name:  "Upgrade Lum",
-			Items: []string{"LumRune", "LumRune", "LumRune", "FlawedAmethyst"},
-		},
-		{
-			Name:  "Upgrade Ko",
-			Items: []string{"KoRune", "KoRune", "KoRune", "FlawedSapphire"},
-		},
-		{
-			Name:  "Upgrade Fal",
-			Items: []string{"FalRune", "FalRune", "FalRune", "FlawedRuby"},
-		},
-		{
-			Name:  "Upgrade Lem",
-			Items: []string{"LemRune", "LemRune", "LemRune", "FlawedEmerald"},
-		},
-		{
-			Name:  "Upgrade Pul",
-			Items: []string{"PulRune", "PulRune", "FlawedDiamond"},
-		},
-		{
-			Name:  "Upgrade Um",
-			Items: []string{"UmRune", "UmRune", "Topaz"},
-		},
-		{
-			Name:  "Upgrade Mal",
-			Items: []string{"MalRune", "MalRune", "Amethyst"},
-		},
-		{
-			Name:  "Upgrade Ist",
-			Items: []string{"IstRune", "IstRune", "Sapphire"},
-		},
-		{
-			Name:  "Upgrade Gul",
-			Items: []string{"GulRune", "GulRune", "Ruby"},
-		},
-		{
-			Name:  "Upgrade Vex",
-			Items: []string{"VexRune", "VexRune", "Emerald"},
-		},
-		{
-			Name:  "Upgrade Ohm",
-			Items: []string{"OhmRune", "OhmRune", "Diamond"},
-		},
-		{
-			Name:  "Upgrade Lo",
-			Items: []string{"LoRune", "LoRune", "FlawlessTopaz"},
-		},
-		{
-			Name:  "Upgrade Sur",
-			Items: []string{"SurRune", "SurRune", "FlawlessAmethyst"},
-		},
-		{
-			Name:  "Upgrade Ber",
-			Items: []string{"BerRune", "BerRune", "FlawlessSapphire"},
-		},
-		{
-			Name:  "Upgrade Jah",
-			Items: []string{"JahRune", "JahRune", "FlawlessRuby"},
-		},
-		{
-			Name:  "Upgrade Cham",
-			Items: []string{"ChamRune", "ChamRune", "FlawlessEmerald"},
-		},
-
-		// add sockets
-		{
-			Name:  "Add Sockets to Weapon",
-			Items: []string{"RalRune", "AmnRune", "PerfectAmethyst", "NormalWeapon"},
-		},
-		{
-			Name:  "Add Sockets to Armor",
-			Items: []string{"TalRune", "ThulRune", "PerfectTopaz", "NormalArmor"},
-		},
-		{
-			Name:  "Add Sockets to Helm",
-			Items: []string{"RalRune", "ThulRune", "PerfectSapphire", "NormalHelm"},
-		},
-		{
-			Name:  "Add Sockets to Shield",
-			Items: []string{"TalRune", "AmnRune", "PerfectRuby", "NormalShield"},
-		},
-
-		// Crafting
-		{
-			Name:  "Reroll GrandCharms",
-			Items: []string{"GrandCharm", "Perfect", "Perfect", "Perfect"}, // Special handling in hasItemsForRecipe
-		},
-
-		// Caster Amulet
-		{
-			Name:             "Caster Amulet",
-			Items:            []string{"RalRune", "PerfectAmethyst", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Amulet"},
-		},
-
-		// Caster Ring
-		{
-			Name:             "Caster Ring",
-			Items:            []string{"AmnRune", "PerfectAmethyst", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Ring"},
-		},
-
-		// Caster Belt
-		{
-			Name:             "Caster Belt",
-			Items:            []string{"IthRune", "PerfectAmethyst", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"LightBelt", "SharkskinBelt", "VampirefangBelt"},
-		},
-
-		// Caster Boots
-		{
-			Name:             "Caster Boots",
-			Items:            []string{"ThulRune", "PerfectAmethyst", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Boots", "DemonhideBoots", "WyrmhideBoots"},
-		},
-
-		// Blood Amulet
-		{
-			Name:             "Blood Amulet",
-			Items:            []string{"AmnRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Amulet"},
-		},
-
-		// Blood Ring
-		{
-			Name:             "Blood Ring",
-			Items:            []string{"SolRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Ring"},
-		},
-
-		// Blood Gloves
-		{
-			Name:             "Blood Gloves",
-			Items:            []string{"NefRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"HeavyGloves", "SharkskinGloves", "VampireboneGloves"},
-		},
-
-		// Blood Boots
-		{
-			Name:             "Blood Boots",
-			Items:            []string{"EthRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"LightPlatedBoots", "BattleBoots", "MirroredBoots"},
-		},
-
-		// Blood Belt
-		{
-			Name:             "Blood Belt",
-			Items:            []string{"TalRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Belt", "MeshBelt", "MithrilCoil"},
-		},
-
-		// Blood Helm
-		{
-			Name:             "Blood Helm",
-			Items:            []string{"RalRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Helm", "Casque", "Armet"},
-		},
-
-		// Blood Armor
-		{
-			Name:             "Blood Armor",
-			Items:            []string{"ThulRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"PlateMail", "TemplarPlate", "HellforgePlate"},
-		},
-
-		// Blood Weapon
-		{
-			Name:             "Blood Weapon",
-			Items:            []string{"OrtRune", "PerfectRuby", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Axe"},
-		},
-
-		// Safety Shield
-		{
-			Name:             "Safety Shield",
-			Items:            []string{"NefRune", "PerfectEmerald", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"KiteShield", "DragonShield", "Monarch"},
-		},
-
-		// Safety Armor
-		{
-			Name:             "Safety Armor",
-			Items:            []string{"EthRune", "PerfectEmerald", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"BreastPlate", "Curiass", "GreatHauberk"},
-		},
-
-		// Safety Boots
-		{
-			Name:             "Safety Boots",
-			Items:            []string{"OrtRune", "PerfectEmerald", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Greaves", "WarBoots", "MyrmidonBoots"},
-		},
-
-		// Safety Gloves
-		{
-			Name:             "Safety Gloves",
-			Items:            []string{"RalRune", "PerfectEmerald", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Gauntlets", "WarGauntlets", "OgreGauntlets"},
-		},
-
-		// Safety Belt
-		{
-			Name:             "Safety Belt",
-			Items:            []string{"TalRune", "PerfectEmerald", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Sash", "DemonhideSash", "SpiderwebSash"},
-		},
-
-		// Safety Helm
-		{
-			Name:             "Safety Helm",
-			Items:            []string{"IthRune", "PerfectEmerald", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"Crown", "GrandCrown", "Corona"},
-		},
-
-		// Hitpower Gloves
-		{
-			Name:             "Hitpower Gloves",
-			Items:            []string{"OrtRune", "PerfectSapphire", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"ChainGloves", "HeavyBracers", "Vambraces"},
-		},
-
-		// Hitpower Boots
-		{
-			Name:             "Hitpower Boots",
-			Items:            []string{"RalRune", "PerfectSapphire", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"ChainBoots", "MeshBoots", "Boneweave"},
-		},
-
-		// Hitpower Belt
-		{
-			Name:             "Hitpower Belt",
-			Items:            []string{"TalRune", "PerfectSapphire", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"HeavyBelt", "BattleBelt", "TrollBelt"},
-		},
-
-		// Hitpower Helm
-		{
-			Name:             "Hitpower Helm",
-			Items:            []string{"NefRune", "PerfectSapphire", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"FullHelm", "Basinet", "GiantConch"},
-		},
-
-		// Hitpower Armor
-		{
-			Name:             "Hitpower Armor",
-			Items:            []string{"EthRune", "PerfectSapphire", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"FieldPlate", "Sharktooth", "KrakenShell"},
-		},
-
-		// Hitpower Shield
-		{
-			Name:             "Hitpower Shield",
-			Items:            []string{"IthRune", "PerfectSapphire", "Jewel"},
-			PurchaseRequired: true,
-			PurchaseItems:    []string{"GothicShield", "AncientShield", "Ward"},
-		},
-	}
-)
-
 func CubeRecipes() error {
 	ctx := context.Get()
 	ctx.SetLastAction("CubeRecipes")
@@ -415,248 +22,208 @@ func CubeRecipes() error {
 		return nil
 	}
 
+	recipes := make([]config.CubeRecipeDefinition, 0, len(ctx.CharacterCfg.CubeRecipes.EnabledRecipes))
+	for _, recipe := range config.CubeRecipeDefinitions {
+		if slices.Contains(ctx.CharacterCfg.CubeRecipes.EnabledRecipes, recipe.Name) {
+			recipes = append(recipes, recipe)
+		}
+	}
+
+	match := cubeIngredientMatcher(ctx.CharacterCfg)
 	itemsInStash := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash)
-	for _, recipe := range Recipes {
-		// Check if the current recipe is Enabled
-		if !slices.Contains(ctx.CharacterCfg.CubeRecipes.EnabledRecipes, recipe.Name) {
-			// is this really needed ? making huge logs
-			//		ctx.Logger.Debug("Cube recipe is not enabled, skipping", "recipe", recipe.Name)
+	plan := planCubeRecipes(recipes, itemsInStash, ctx.CharacterCfg.CubeRecipes.KeepQuotas, match)
+	if len(plan) == 0 {
+		return nil
+	}
+	ctx.Logger.Debug("Cube recipes planned", "transmutes", len(plan))
+
+	for stepIdx, step := range plan {
+		recipe := step.Recipe
+		ctx.RefreshGameData()
+		stashItems := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash)
+		items, hasItems := hasItemsForRecipe(stashItems, recipe, ctx.CharacterCfg.CubeRecipes.KeepQuotas, match)
+		if !hasItems {
+			ctx.Logger.Debug("Items for planned cube recipe not found, skipping", "recipe", recipe.Name)
 			continue
 		}
 
-		ctx.Logger.Debug("Cube recipe is enabled, processing", "recipe", recipe.Name)
-
-		continueProcessing := true
-		for continueProcessing {
-			if items, hasItems := hasItemsForRecipe(ctx, recipe); hasItems {
-
-				// TODO: Check if we have the items in our storage and if not, purchase them, else take the item from the storage
-				if recipe.PurchaseRequired {
-					err := GambleSingleItem(recipe.PurchaseItems, item.QualityMagic)
-					if err != nil {
-						ctx.Logger.Error("Error gambling item, skipping recipe", "error", err, "recipe", recipe.Name)
-						break
-					}
-
-					purchasedItem := getPurchasedItem(ctx, recipe.PurchaseItems)
-					if purchasedItem.Name == "" {
-						ctx.Logger.Debug("Could not find purchased item. Skipping recipe", "recipe", recipe.Name)
-						break
-					}
+		// TODO: Check if we have the items in our storage and if not, purchase them, else take the item from the storage
+		if len(recipe.Purchase) > 0 {
+			err := GambleSingleItem(recipe.Purchase, item.QualityMagic)
+			if err != nil {
+				ctx.Logger.Error("Error gambling item, skipping recipe", "error", err, "recipe", recipe.Name)
+				continue
+			}
 
-					// Add the purchased item the list of items to cube
-					items = append(items, purchasedItem)
-				}
+			purchasedItem := getPurchasedItem(ctx, recipe.Purchase)
+			if purchasedItem.Name == "" {
+				ctx.Logger.Debug("Could not find purchased item. Skipping recipe", "recipe", recipe.Name)
+				continue
+			}
 
-				// Add items to the cube and perform the transmutation
-				err := CubeAddItems(items...)
-				if err != nil {
-					return err
-				}
-				if err = CubeTransmute(); err != nil {
-					return err
-				}
+			// Add the purchased item the list of items to cube
+			items = append(items, purchasedItem)
+		}
 
-				// Get a list of items that are in our inventory
-				itemsInInv := ctx.Data.Inventory.ByLocation(item.LocationInventory)
+		// Add items to the cube and perform the transmutation
+		err := CubeAddItems(items...)
+		if err != nil {
+			return err
+		}
+		if err = CubeTransmute(); err != nil {
+			return err
+		}
 
-				stashingRequired := false
-				stashingGrandCharm := false
+		// Get a list of items that are in our inventory
+		itemsInInv := ctx.Data.Inventory.ByLocation(item.LocationInventory)
 
-				// Check if the items that are not in the protected invetory slots should be stashed
-				for _, it := range itemsInInv {
-					// If item is not in the protected slots, check if it should be stashed
-					if ctx.CharacterCfg.Inventory.InventoryLock[it.Position.Y][it.Position.X] == 1 {
-						if it.Name == "Key" || it.IsPotion() || it.Name == item.TomeOfTownPortal || it.Name == item.TomeOfIdentify {
-							continue
-						}
+		stashingRequired := false
+		forceStashing := false
 
-						shouldStash, _, reason, _ := shouldStashIt(it, false)
-
-						if shouldStash {
-							ctx.Logger.Debug("Stashing item after cube recipe.", "item", it.Name, "recipe", recipe.Name, "reason", reason)
-							stashingRequired = true
-						} else if it.Name == "GrandCharm" {
-							ctx.Logger.Debug("Checking if we need to stash a GrandCharm that doesn't match any NIP rules.", "recipe", recipe.Name)
-							// Check if we have a GrandCharm in stash that doesn't match any NIP rules
-							hasUnmatchedGrandCharm := false
-							for _, stashItem := range itemsInStash {
-								if stashItem.Name == "GrandCharm" {
-									if _, result := ctx.CharacterCfg.Runtime.Rules.EvaluateAll(stashItem); result != nip.RuleResultFullMatch {
-										hasUnmatchedGrandCharm = true
-										break
-									}
-								}
-							}
-							if !hasUnmatchedGrandCharm {
-
-								ctx.Logger.Debug("GrandCharm doesn't match any NIP rules and we don't have any in stash to be used for this recipe. Stashing it.", "recipe", recipe.Name)
-								stashingRequired = true
-								stashingGrandCharm = true
+		// Check if the items that are not in the protected invetory slots should be stashed
+		for _, it := range itemsInInv {
+			// If item is not in the protected slots, check if it should be stashed
+			if ctx.CharacterCfg.Inventory.InventoryLock[it.Position.Y][it.Position.X] == 1 {
+				if it.Name == "Key" || it.IsPotion() || it.Name == item.TomeOfTownPortal || it.Name == item.TomeOfIdentify {
+					continue
+				}
 
-							} else {
-								DropInventoryItem(it)
-								utils.Sleep(500)
+				shouldStash, _, reason, _ := shouldStashIt(it, false)
+
+				if shouldStash {
+					ctx.Logger.Debug("Stashing item after cube recipe.", "item", it.Name, "recipe", recipe.Name, "reason", reason)
+					stashingRequired = true
+				} else if isUsedByPlannedRecipe(plan[stepIdx+1:], it) {
+					ctx.Logger.Debug("Stashing item used by the next planned cube recipes.", "item", it.Name, "recipe", recipe.Name)
+					stashingRequired = true
+					forceStashing = true
+				} else if it.Name == "GrandCharm" {
+					ctx.Logger.Debug("Checking if we need to stash a GrandCharm that doesn't match any NIP rules.", "recipe", recipe.Name)
+					// Check if we have a GrandCharm in stash that doesn't match any NIP rules
+					hasUnmatchedGrandCharm := false
+					for _, stashItem := range itemsInStash {
+						if stashItem.Name == "GrandCharm" {
+							if _, result := ctx.CharacterCfg.Runtime.Rules.EvaluateAll(stashItem); result != nip.RuleResultFullMatch {
+								hasUnmatchedGrandCharm = true
+								break
 							}
-						} else {
-							DropInventoryItem(it)
-							utils.Sleep(500)
 						}
 					}
-				}
+					if !hasUnmatchedGrandCharm {
 
-				// Add items to the stash if needed
-				if stashingRequired && !stashingGrandCharm {
-					_ = Stash(false)
-				} else if stashingGrandCharm {
-					// Force stashing of the invetory
-					_ = Stash(true)
-				}
+						ctx.Logger.Debug("GrandCharm doesn't match any NIP rules and we don't have any in stash to be used for this recipe. Stashing it.", "recipe", recipe.Name)
+						stashingRequired = true
+						forceStashing = true
 
-				// Remove or decrement the used items from itemsInStash
-				itemsInStash = removeUsedItems(itemsInStash, items)
-			} else {
-				continueProcessing = false
+					} else {
+						DropInventoryItem(it)
+						utils.Sleep(500)
+					}
+				} else {
+					DropInventoryItem(it)
+					utils.Sleep(500)
+				}
 			}
 		}
+
+		// Add items to the stash if needed
+		if stashingRequired && !forceStashing {
+			_ = Stash(false)
+		} else if forceStashing {
+			// Force stashing of the invetory
+			_ = Stash(true)
+		}
+
+		// Remove or decrement the used items from itemsInStash
+		itemsInStash = removeUsedItems(itemsInStash, items)
 	}
+	saveStashSnapshot()
 
 	return nil
 }
 
-func hasItemsForRecipe(ctx *context.Status, recipe CubeRecipe) ([]data.Item, bool) {
-
-	ctx.RefreshGameData()
-	items := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash)
-
-	if strings.Contains(recipe.Name, "Add Sockets to") {
-		return hasItemsForSocketRecipe(ctx, recipe, items)
+// isUsedByPlannedRecipe returns true when an item produced by a transmute is an ingredient of the next steps
+func isUsedByPlannedRecipe(steps []CubePlanStep, itm data.Item) bool {
+	for _, step := range steps {
+		for _, planned := range step.Items {
+			if planned.UnitID == 0 && planned.Name == itm.Name {
+				return true
+			}
+		}
 	}
 
-	// Special handling for "Reroll GrandCharms" recipe
-	if recipe.Name == "Reroll GrandCharms" {
-		return hasItemsForGrandCharmReroll(ctx, items)
-	}
+	return false
+}
 
-	recipeItems := make(map[string]int)
-	for _, item := range recipe.Items {
-		recipeItems[item]++
+// hasItemsForRecipe returns the items to put in the cube for the recipe, items are never consumed below their keep quota
+func hasItemsForRecipe(items []data.Item, recipe config.CubeRecipeDefinition, keepQuotas map[string]int, match cubeItemMatcher) ([]data.Item, bool) {
+	pool := make([]cubeItem, 0, len(items))
+	for _, itm := range items {
+		pool = append(pool, cubeItem{Item: itm})
 	}
 
-	itemsForRecipe := []data.Item{}
-
-	// Iterate over the items in our stash to see if we have the items for the recipie.
-	for _, item := range items {
-		if count, ok := recipeItems[string(item.Name)]; ok {
-
-			// Let's make sure we don't use an item we don't want to. Add more if needed (depending on the recipes we have)
-			if item.Name == "Jewel" {
-				if _, result := ctx.CharacterCfg.Runtime.Rules.EvaluateAll(item); result == nip.RuleResultFullMatch {
-					continue
-				}
-			}
-
-			itemsForRecipe = append(itemsForRecipe, item)
+	selected, found := matchCubeRecipe(recipe, pool, keepQuotas, match)
+	if !found {
+		return nil, false
+	}
 
-			// Check if we now have exactly the needed count before decrementing
-			count -= 1
-			if count == 0 {
-				delete(recipeItems, string(item.Name))
-				if len(recipeItems) == 0 {
-					return itemsForRecipe, true
-				}
-			} else {
-				recipeItems[string(item.Name)] = count
-			}
-		}
+	itemsForRecipe := make([]data.Item, 0, len(selected))
+	for _, idx := range selected {
+		itemsForRecipe = append(itemsForRecipe, pool[idx].Item)
 	}
 
-	// We don't have all the items for the recipie.
-	return nil, false
+	return itemsForRecipe, true
 }
 
-func hasItemsForSocketRecipe(ctx *context.Status, recipe CubeRecipe, items []data.Item) ([]data.Item, bool) {
-	ctx.Logger.Debug("Processing socket recipe", "recipe", recipe.Name, "totalItems", len(items))
-
-	recipeItems := make(map[string]int)
-	for _, itemName := range recipe.Items {
-		recipeItems[itemName]++
-	}
+// cubeIngredientMatcher checks the item against the ingredient definition and the character settings
+func cubeIngredientMatcher(cfg *config.CharacterCfg) cubeItemMatcher {
+	return func(recipe config.CubeRecipeDefinition, ingredient config.CubeIngredient, itm data.Item) bool {
+		if !ingredient.MatchesName(string(itm.Name)) {
+			return false
+		}
 
-	itemsForRecipe := []data.Item{}
-
-	var targetItemTypes []string
-	switch recipe.Name {
-	case "Add Sockets to Weapon":
-		targetItemTypes = []string{
-			item.TypeWeapon, item.TypeAxe, item.TypeSword, item.TypeSpear,
-			item.TypePolearm, item.TypeMace, item.TypeBow,
-			item.TypeWand, item.TypeStaff, item.TypeScepter, item.TypeClub, item.TypeHammer, item.TypeKnife,
-			item.TypeCrossbow, item.TypeHandtoHand, item.TypeHandtoHand2, item.TypeOrb,
-			item.TypeAmazonBow, item.TypeAmazonSpear,
+		if quality, found := config.ParseCubeQuality(ingredient.Quality); found && itm.Quality != quality {
+			return false
 		}
-	case "Add Sockets to Armor":
-		targetItemTypes = []string{item.TypeArmor}
-	case "Add Sockets to Helm":
-		targetItemTypes = []string{
-			item.TypeHelm,
-			item.TypePrimalHelm, item.TypePelt, item.TypeCirclet,
+
+		if len(ingredient.Types) > 0 && !isItemOfAnyType(itm, ingredient.ItemTypes()) {
+			return false
 		}
-	case "Add Sockets to Shield":
-		targetItemTypes = []string{
-			item.TypeShield, item.TypeAuricShields, item.TypeVoodooHeads,
+
+		if ingredient.Socketable && !isSocketableItem(itm) {
+			return false
 		}
-	default:
-		return nil, false
-	}
 
-	for _, itm := range items {
-		itemName := string(itm.Name)
-
-		if count, ok := recipeItems[itemName]; ok {
-			itemsForRecipe = append(itemsForRecipe, itm)
-			count--
-			if count == 0 {
-				delete(recipeItems, itemName)
-			} else {
-				recipeItems[itemName] = count
+		if ingredient.MinLevelReq > 0 || ingredient.MaxLevelReq > 0 {
+			levelReq := 0
+			if lvlReqStat, found := itm.FindStat(stat.LevelRequire, 0); found {
+				levelReq = lvlReqStat.Value
 			}
-		} else {
-
-			specialType := ""
-			switch recipe.Name {
-			case "Add Sockets to Weapon":
-				specialType = "NormalWeapon"
-			case "Add Sockets to Armor":
-				specialType = "NormalArmor"
-			case "Add Sockets to Helm":
-				specialType = "NormalHelm"
-			case "Add Sockets to Shield":
-				specialType = "NormalShield"
+			if levelReq < ingredient.MinLevelReq || (ingredient.MaxLevelReq > 0 && levelReq > ingredient.MaxLevelReq) {
+				return false
 			}
+		}
 
-			if count, ok := recipeItems[specialType]; ok && isSocketableItemMultiType(itm, targetItemTypes) {
-				ctx.Logger.Debug("Found socketable item for recipe", "recipe", recipe.Name, "item", itm.Name, "quality", itm.Quality.ToString(), "ethereal", itm.Ethereal)
-				itemsForRecipe = append(itemsForRecipe, itm)
-				count--
-				if count == 0 {
-					delete(recipeItems, specialType)
-				} else {
-					recipeItems[specialType] = count
-				}
+		// Skip perfect amethysts and rubies if configured
+		if recipe.Name == "Reroll GrandCharms" {
+			if (cfg.CubeRecipes.SkipPerfectAmethysts && itm.Name == "PerfectAmethyst") ||
+				(cfg.CubeRecipes.SkipPerfectRubies && itm.Name == "PerfectRuby") {
+				return false
 			}
 		}
 
-		if len(recipeItems) == 0 {
-			ctx.Logger.Debug("Socket recipe ready to execute", "recipe", recipe.Name, "itemCount", len(itemsForRecipe))
-			return itemsForRecipe, true
+		// Let's make sure we don't use an item we don't want to
+		if ingredient.UnmatchedByPickit {
+			if _, result := cfg.Runtime.Rules.EvaluateAll(itm); result == nip.RuleResultFullMatch {
+				return false
+			}
 		}
-	}
 
-	return nil, false
+		return true
+	}
 }
 
-func isSocketableItemMultiType(itm data.Item, targetTypes []string) bool {
+func isSocketableItem(itm data.Item) bool {
 
 	excludedItems := []string{
 		"Runic Talons",
@@ -676,18 +243,14 @@ func isSocketableItemMultiType(itm data.Item, targetTypes []string) bool {
 		}
 	}
 
-	if itm.Quality != item.QualityNormal {
-		return false
-	}
-
 	if itm.HasSockets || len(itm.Sockets) > 0 {
 		return false
 	}
 
-	if itm.Desc().MaxSockets == 0 {
-		return false
-	}
+	return itm.Desc().MaxSockets > 0
+}
 
+func isItemOfAnyType(itm data.Item, targetTypes []string) bool {
 	for _, targetType := range targetTypes {
 		if itm.Type().IsType(targetType) {
 			return true
@@ -697,42 +260,6 @@ func isSocketableItemMultiType(itm data.Item, targetTypes []string) bool {
 	return false
 }
 
-func hasItemsForGrandCharmReroll(ctx *context.Status, items []data.Item) ([]data.Item, bool) {
-	var grandCharm data.Item
-	perfectGems := make([]data.Item, 0, 3)
-
-	for _, itm := range items {
-		if itm.Name == "GrandCharm" {
-			if _, result := ctx.CharacterCfg.Runtime.Rules.EvaluateAll(itm); result != nip.RuleResultFullMatch && itm.Quality == item.QualityMagic {
-				grandCharm = itm
-			}
-		} else if isPerfectGem(itm) && len(perfectGems) < 3 {
-			// Skip perfect amethysts and rubies if configured
-			if (ctx.CharacterCfg.CubeRecipes.SkipPerfectAmethysts && itm.Name == "PerfectAmethyst") ||
-				(ctx.CharacterCfg.CubeRecipes.SkipPerfectRubies && itm.Name == "PerfectRuby") {
-				continue
-			}
-			perfectGems = append(perfectGems, itm)
-		}
-
-		if grandCharm.Name != "" && len(perfectGems) == 3 {
-			return append([]data.Item{grandCharm}, perfectGems...), true
-		}
-	}
-
-	return nil, false
-}
-
-func isPerfectGem(item data.Item) bool {
-	perfectGems := []string{"PerfectAmethyst", "PerfectDiamond", "PerfectEmerald", "PerfectRuby", "PerfectSapphire", "PerfectTopaz", "PerfectSkull"}
-	for _, gemName := range perfectGems {
-		if string(item.Name) == gemName {
-			return true
-		}
-	}
-	return false
-}
-
 func removeUsedItems(stash []data.Item, usedItems []data.Item) []data.Item {
 	remainingItems := make([]data.Item, 0)
 	usedItemMap := make(map[string]int)
@@ -3,10 +3,11 @@ package action
 import (
 	"fmt"
 	"math"
-	"sort"
 	"time"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/pather"
+	"github.com/hectorgimenez/koolo/internal/pather/danger"
+	"github.com/hectorgimenez/koolo/internal/threat"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/stat"
@@ -136,6 +137,14 @@ func ShouldSwitchTarget(targetID data.UnitID, targetMonster data.Monster, lastLi
 	return false
 }
 
+const (
+	// safePositionDestinationWeight is how much standing on a dangerous tile counts compared to walking over it
+	safePositionDestinationWeight = 10
+	safePositionMoveCost          = 0.5
+)
+
+// FindSafePosition returns the position in attack range of the target monster with the least danger, according to
+// the danger every monster around spreads. Walking characters also avoid crossing dangerous tiles to get there.
 func FindSafePosition(targetMonster data.Monster, dangerDistance int, safeDistance int, minAttackDistance int, maxAttackDistance int) (data.Position, bool) {
 	ctx := context.Get()
 	playerPos := ctx.Data.PlayerUnit.Position
@@ -143,131 +152,46 @@ func FindSafePosition(targetMonster data.Monster, dangerDistance int, safeDistan
 	// Define a stricter minimum safe distance from monsters
 	minSafeMonsterDistance := int(math.Floor((float64(safeDistance) + float64(dangerDistance)) / 2))
 
-	// Generate candidate positions in a circle around the player
-	candidatePositions := []data.Position{}
-
-	// First try positions in the opposite direction from the dangerous monster
-	vectorX := playerPos.X - targetMonster.Position.X
-	vectorY := playerPos.Y - targetMonster.Position.Y
-
-	// Normalize the vector
-	length := math.Sqrt(float64(vectorX*vectorX + vectorY*vectorY))
-	if length > 0 {
-		normalizedX := int(float64(vectorX) / length * float64(safeDistance))
-		normalizedY := int(float64(vectorY) / length * float64(safeDistance))
-
-		// Add positions in the opposite direction with some variation
-		for offsetX := -3; offsetX <= 3; offsetX++ {
-			for offsetY := -3; offsetY <= 3; offsetY++ {
-				candidatePos := data.Position{
-					X: playerPos.X + normalizedX + offsetX,
-					Y: playerPos.Y + normalizedY + offsetY,
-				}
-
-				if ctx.Data.AreaData.IsWalkable(candidatePos) {
-					candidatePositions = append(candidatePositions, candidatePos)
-				}
-			}
-		}
-	}
-
-	// Generate positions in a circle with smaller angle increments for more candidates
-	// Try positions in different directions around the player
-	for angle := 0; angle < 360; angle += 5 {
-		radians := float64(angle) * math.Pi / 180
-
-		// Try multiple distances from the player
-		for distance := minSafeMonsterDistance; distance <= safeDistance+5; distance += 2 {
-			dx := int(math.Cos(radians) * float64(distance))
-			dy := int(math.Sin(radians) * float64(distance))
-
-			basePos := data.Position{
-				X: playerPos.X + dx,
-				Y: playerPos.Y + dy,
-			}
-
-			// Check a small area around the base position
-			for offsetX := -1; offsetX <= 1; offsetX++ {
-				for offsetY := -1; offsetY <= 1; offsetY++ {
-					candidatePos := data.Position{
-						X: basePos.X + offsetX,
-						Y: basePos.Y + offsetY,
-					}
-
-					if ctx.Data.AreaData.IsWalkable(candidatePos) {
-						candidatePositions = append(candidatePositions, candidatePos)
-					}
-				}
-			}
-		}
-	}
-
-	// No walkable positions found
-	if len(candidatePositions) == 0 {
+	field := dangerField(playerPos, safeDistance+10)
+	pos, cost, found := field.SafePosition(danger.Search{
+		From:              playerPos,
+		Target:            targetMonster.Position,
+		MinRange:          minAttackDistance,
+		MaxRange:          maxAttackDistance,
+		LineOfSight:       true,
+		MaxDistance:       safeDistance + 5,
+		MinSourceDistance: minSafeMonsterDistance,
+		Teleport:          ctx.Data.CanTeleport(),
+		DestinationWeight: safePositionDestinationWeight,
+		MoveCost:          safePositionMoveCost,
+	})
+	if !found {
 		return data.Position{}, false
 	}
 
-	// Evaluate all candidate positions
-	type scoredPosition struct {
-		pos   data.Position
-		score float64
-	}
-
-	scoredPositions := []scoredPosition{}
-
-	for _, pos := range candidatePositions {
-		// Check if this position has line of sight to target
-		if !ctx.PathFinder.LineOfSight(pos, targetMonster.Position) {
-			continue
-		}
-
-		// Calculate minimum distance to any monster
-		minMonsterDist := GetDistanceFromClosestEnemy(pos, ctx.Data.Monsters)
-
-		// Strictly skip positions that are too close to monsters
-		if minMonsterDist < float64(minSafeMonsterDistance) {
-			continue
-		}
+	ctx.Logger.Info(fmt.Sprintf("Found safe position with cost %.2f at distance %.2f from nearest monster",
+		cost, GetDistanceFromClosestEnemy(pos, ctx.Data.Monsters)))
 
-		// Calculate distance to target monster
-		targetDistance := pather.DistanceFromPoint(pos, targetMonster.Position)
-
-		distanceFromPlayer := pather.DistanceFromPoint(pos, playerPos)
-
-		// Calculate attack range score (highest when in optimal attack range)
-		attackRangeScore := 0.0
-		if targetDistance >= minAttackDistance && targetDistance <= maxAttackDistance {
-			attackRangeScore = 10.0
-		} else {
-			// Penalize positions outside attack range
-			attackRangeScore = -math.Abs(float64(targetDistance) - float64(minAttackDistance+maxAttackDistance)/2.0)
-		}
+	return pos, true
+}
 
-		// Final score calculation - heavily weight monster distance for safety
-		score := minMonsterDist*3.0 + attackRangeScore*2.0 - float64(distanceFromPlayer)*0.5
+// dangerField spreads the danger of every alive enemy around the center, weighted by the threat model
+func dangerField(center data.Position, radius int) *danger.Field {
+	ctx := context.Get()
+	model := threat.Current()
 
-		// Extra bonus for positions that are very safe (far from monsters)
-		if minMonsterDist > float64(dangerDistance) {
-			score += 5.0
+	enemies := make([]data.Monster, 0)
+	for _, m := range ctx.Data.Monsters.Enemies() {
+		if m.Stats[stat.Life] > 0 {
+			enemies = append(enemies, m)
 		}
-
-		scoredPositions = append(scoredPositions, scoredPosition{
-			pos:   pos,
-			score: score,
-		})
 	}
 
-	// Sort positions by score (highest first)
-	sort.Slice(scoredPositions, func(i, j int) bool {
-		return scoredPositions[i].score > scoredPositions[j].score
-	})
-
-	// Return the best position if we found any
-	if len(scoredPositions) > 0 {
-		ctx.Logger.Info(fmt.Sprintf("Found safe position with score %.2f at distance %.2f from nearest monster",
-			scoredPositions[0].score, GetDistanceFromClosestEnemy(scoredPositions[0].pos, ctx.Data.Monsters)))
-		return scoredPositions[0].pos, true
+	sources := make([]danger.Source, 0, len(enemies))
+	for _, m := range enemies {
+		t := threatTarget(m, enemies, model)
+		sources = append(sources, danger.Source{Position: m.Position, Weight: model.Danger(t), Radius: model.Radius(t)})
 	}
 
-	return data.Position{}, false
+	return danger.NewField(ctx.Data.AreaData.Grid, center, radius, sources)
 }
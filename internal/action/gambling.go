@@ -12,6 +12,7 @@ import (
 	"github.com/hectorgimenez/koolo/internal/action/step"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
 	"github.com/hectorgimenez/koolo/internal/town"
 	"github.com/hectorgimenez/koolo/internal/ui"
 	"github.com/hectorgimenez/koolo/internal/utils"
@@ -110,6 +111,7 @@ func GambleSingleItem(items []string, desiredQuality item.Quality) error {
 					ctx.Logger.Info("Found item matching desired quality, will be kept", slog.Any("item", itemBought))
 					return step.CloseAllMenus()
 				} else {
+					recordItemDecision(itemBought, itemlog.DecisionSell, itemlog.ReasonNoRuleMatch, nip.Rule{}, "gambled item not matching the desired quality")
 					town.SellItem(itemBought)
 					itemBought = data.Item{}
 				}
@@ -182,6 +184,7 @@ func gambleItems() error {
 			} else {
 				// Filter not pass, selling the item
 				ctx.Logger.Debug("Item doesn't match NIP rules, selling", slog.Any("item", itemBought))
+				recordItemDecision(itemBought, itemlog.DecisionSell, itemlog.ReasonNoRuleMatch, nip.Rule{}, "gambled item")
 				town.SellItem(itemBought)
 			}
 
@@ -0,0 +1,18 @@
+package action
+
+import (
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/nip"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
+)
+
+// recordItemDecision keeps the decision taken on the item, so it can be checked later from the dashboard
+func recordItemDecision(i data.Item, decision itemlog.Decision, reason itemlog.Reason, rule nip.Rule, detail string) {
+	itemlog.Add(itemlog.NewRecord(context.Get().Name, i, decision, reason, rule).WithDetail(detail))
+}
+
+// recordTierDecision keeps a decision taken comparing the item with the equipped one
+func recordTierDecision(i data.Item, decision itemlog.Decision, reason itemlog.Reason, rule nip.Rule, comparison itemlog.TierComparison) {
+	itemlog.Add(itemlog.NewRecord(context.Get().Name, i, decision, reason, rule).WithTier(comparison))
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/hectorgimenez/koolo/internal/action/step"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
@@ -84,6 +85,9 @@ func ItemPickup(maxDistance int) error {
 		ctx.SetPickingItems(false)
 	}()
 
+	// Items we already went back to town for, we don't go back again if they still don't fit
+	townTripsForSpace := make(map[data.UnitID]bool)
+
 	for {
 		ctx.PauseIfNotPriority()
 		itemsToPickup := GetItemsToPickup(maxDistance)
@@ -92,12 +96,13 @@ func ItemPickup(maxDistance int) error {
 			return nil
 		}
 
-		var itemToPickup data.Item
-		for _, i := range itemsToPickup {
-			if itemFitsInventory(i) {
-				itemToPickup = i
-				break
+		itemToPickup, townTrip := planNextPickup(itemsToPickup, townTripsForSpace)
+		if townTrip && HasTPsAvailable() {
+			ctx.Logger.Info("A valuable item doesn't fit in the inventory, returning to town to make room")
+			if err := InRunReturnTownRoutine(); err != nil {
+				ctx.Logger.Warn("Failed returning to town from ItemPickup", "error", err)
 			}
+			continue
 		}
 
 		if itemToPickup.UnitID == 0 {
@@ -285,6 +290,7 @@ func ItemPickup(maxDistance int) error {
 		// If all attempts failed (totalAttemptCounter reached limit and lastError is not nil)
 		if totalAttemptCounter >= totalMaxAttempts && lastError != nil {
 			ctx.CurrentGame.BlacklistedItems = append(ctx.CurrentGame.BlacklistedItems, itemToPickup)
+			recordItemDecision(itemToPickup, itemlog.DecisionBlacklist, itemlog.ReasonPickupFailed, nip.Rule{}, lastError.Error())
 
 			// Screenshot with show items on
 			ctx.HID.KeyDown(ctx.Data.KeyBindings.ShowItems)
@@ -381,7 +387,12 @@ func shouldBePickedUp(i data.Item) bool {
 	ctx.SetLastAction("shouldBePickedUp")
 
 	// Always pickup Runewords and Wirt's Leg
-	if i.IsRuneword || i.Name == "WirtsLeg" {
+	if i.IsRuneword {
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonRuneword, nip.Rule{}, "")
+		return true
+	}
+	if i.Name == "WirtsLeg" {
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonQuestItem, nip.Rule{}, "")
 		return true
 	}
 
@@ -390,20 +401,24 @@ func shouldBePickedUp(i data.Item) bool {
 	if specialRuns {
 		switch i.Name {
 		case "Scroll of Inifuss", "ScrollOfInifuss", "HoradricMalus", "LamEsensTome", "HoradricCube", "AmuletoftheViper", "StaffofKings", "HoradricStaff", "AJadeFigurine", "KhalimsEye", "KhalimsBrain", "KhalimsHeart", "KhalimsFlail", "TheGidbinn", "HellforgeHammer":
+			recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonQuestItem, nip.Rule{}, "quest item needed by the leveling or quests runs")
 			return true
 		}
 	}
 	if i.ID == 552 { // Book of Skill doesnt work by name, so we find it by ID
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonQuestItem, nip.Rule{}, "Book of Skill")
 		return true
 	}
 
 	if i.ID == 524 { // Scroll of Inifuss
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonQuestItem, nip.Rule{}, "Scroll of Inifuss")
 		return true
 	}
 	// Skip picking up gold if we can not carry more
 	gold, _ := ctx.Data.PlayerUnit.FindStat(stat.Gold, 0)
 	if gold.Value >= ctx.Data.PlayerUnit.MaxGold() && i.Name == "Gold" {
 		ctx.Logger.Debug("Skipping gold pickup, inventory full")
+		recordItemDecision(i, itemlog.DecisionSkip, itemlog.ReasonGoldFull, nip.Rule{}, "")
 		return false
 	}
 
@@ -412,11 +427,13 @@ func shouldBePickedUp(i data.Item) bool {
 	// Leaving it for now, but can probably be removed due to the auto MinGoldPickupThreshold in leveling config
 	_, isLevelingChar := ctx.Char.(context.LevelingCharacter)
 	if isLevelingChar && IsLowGold() && i.Name != "Gold" {
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonLowGold, nip.Rule{}, "leveling character low on gold, it will be sold")
 		return true
 	}
 
 	if isLevelingChar && i.Name == "StaminaPotion" {
 		if ctx.HealthManager.ShouldPickStaminaPot() {
+			recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonStaminaPotion, nip.Rule{}, "")
 			return true
 		}
 	}
@@ -424,6 +441,7 @@ func shouldBePickedUp(i data.Item) bool {
 	// Pickup all magic or superior items if total gold is low, filter will not pass and items will be sold to vendor
 	minGoldPickupThreshold := ctx.CharacterCfg.Game.MinGoldPickupThreshold
 	if ctx.Data.PlayerUnit.TotalPlayerGold() < minGoldPickupThreshold && i.Quality >= item.QualityMagic {
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonLowGold, nip.Rule{}, fmt.Sprintf("total gold below %d, it will be sold", minGoldPickupThreshold))
 		return true
 	}
 
@@ -433,16 +451,25 @@ func shouldBePickedUp(i data.Item) bool {
 		if i.Quality <= item.QualitySuperior {
 			//If item doesn't need ID, check tier right away and keep it if better than equipped
 			if playerRule.Tier() > 0.0 {
-				if IsBetterThanEquipped(i, false, PlayerScore) {
+				if better, comparison := compareWithEquipped(i, false, PlayerScore); better {
+					comparison.Tier = playerRule.Tier()
+					recordTierDecision(i, itemlog.DecisionPickup, itemlog.ReasonTierUpgrade, playerRule, comparison)
 					return true
 				}
 			} else {
-				if IsBetterThanEquipped(i, true, MercScore) {
+				if better, comparison := compareWithEquipped(i, true, MercScore); better {
+					comparison.Tier = mercRule.MercTier()
+					recordTierDecision(i, itemlog.DecisionPickup, itemlog.ReasonMercTierUpgrade, mercRule, comparison)
 					return true
 				}
 			}
 		} else {
 			//need ID
+			tierRule := playerRule
+			if playerRule.Tier() <= 0.0 {
+				tierRule = mercRule
+			}
+			recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonRulePartial, tierRule, "tier rule, compared with the equipped item once identified")
 			return true
 		}
 	}
@@ -450,9 +477,11 @@ func shouldBePickedUp(i data.Item) bool {
 	// Evaluate item based on NIP rules ignoring tier rules
 	matchedRule, result := ctx.Data.CharacterCfg.Runtime.Rules.EvaluateAllIgnoreTiers(i)
 	if result == nip.RuleResultNoMatch {
+		recordItemDecision(i, itemlog.DecisionSkip, itemlog.ReasonNoRuleMatch, nip.Rule{}, "")
 		return false
 	}
 	if result == nip.RuleResultPartial {
+		recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonRulePartial, matchedRule, "needs to be identified")
 		return true
 	}
 
@@ -461,10 +490,12 @@ func shouldBePickedUp(i data.Item) bool {
 		if !IsBlacklisted(i) {
 			ctx.CurrentGame.BlacklistedItems = append(ctx.CurrentGame.BlacklistedItems, i)
 			ctx.Logger.Debug(fmt.Sprintf("Blacklisted item %s (UnitID: %d) because it exceeds quantity limits defined in pickit.", i.Name, i.UnitID))
+			recordItemDecision(i, itemlog.DecisionBlacklist, itemlog.ReasonMaxQuantity, matchedRule, fmt.Sprintf("already %d or more in stash", matchedRule.MaxQuantity()))
 		}
 		return false // Do not pick up the item if it exceeds quantity
 	}
 
+	recordItemDecision(i, itemlog.DecisionPickup, itemlog.ReasonRuleMatch, matchedRule, "")
 	return true
 }
 
@@ -0,0 +1,247 @@
+package action
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/nip"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
+)
+
+const (
+	// pickupMustHaveValue is the value of the items always picked up first (runewords, quest items)
+	pickupMustHaveValue = 100000
+	pickupRuneValue     = 20
+	pickupRuleBonus     = 20
+)
+
+var defaultPickupQualityValues = map[item.Quality]int{
+	item.QualityLowQuality: 1,
+	item.QualityNormal:     5,
+	item.QualitySuperior:   5,
+	item.QualityMagic:      10,
+	item.QualityRare:       30,
+	item.QualityCrafted:    30,
+	item.QualitySet:        40,
+	item.QualityUnique:     50,
+}
+
+// pickupCandidate is an item on the ground as seen by the planner, items without size don't use inventory space
+type pickupCandidate struct {
+	Width    int
+	Height   int
+	Value    int
+	Distance int
+}
+
+// pickupPlan is the result of the planner, indexes refer to the candidates
+type pickupPlan struct {
+	// Order are the candidates to pick up, the most valuable first
+	Order []int
+	// Skipped are the candidates not fitting in the inventory along with the planned ones
+	Skipped []int
+	// TownTrip is set when a skipped candidate is worth going back to town for
+	TownTrip bool
+}
+
+// planPickup chooses which candidates to pick up and in which order, simulating where they are placed in the
+// inventory. Two orders are simulated, by value and by value per cell, and the one keeping the most value wins: a
+// single big item can be worth less than the small ones it would leave no room for.
+func planPickup(inventory [][]bool, candidates []pickupCandidate, townTripValue int) pickupPlan {
+	byValue := make([]int, len(candidates))
+	for i := range candidates {
+		byValue[i] = i
+	}
+	slices.SortStableFunc(byValue, func(a, b int) int {
+		if candidates[a].Value != candidates[b].Value {
+			return candidates[b].Value - candidates[a].Value
+		}
+		if candidates[a].cells() != candidates[b].cells() {
+			return candidates[a].cells() - candidates[b].cells()
+		}
+		return candidates[a].Distance - candidates[b].Distance
+	})
+
+	byDensity := slices.Clone(byValue)
+	slices.SortStableFunc(byDensity, func(a, b int) int {
+		// Compare value/cells without floats: a.Value*b.cells vs b.Value*a.cells
+		densityA := candidates[a].Value * max(candidates[b].cells(), 1)
+		densityB := candidates[b].Value * max(candidates[a].cells(), 1)
+		return densityB - densityA
+	})
+
+	plan, value := packPickup(inventory, candidates, byValue)
+	if densityPlan, densityValue := packPickup(inventory, candidates, byDensity); densityValue > value {
+		plan = densityPlan
+	}
+
+	// The chosen items are picked up the most valuable first, in case we can't pick up all of them
+	slices.SortStableFunc(plan.Order, func(a, b int) int {
+		return slices.Index(byValue, a) - slices.Index(byValue, b)
+	})
+
+	for _, idx := range plan.Skipped {
+		if townTripValue > 0 && candidates[idx].Value >= townTripValue {
+			plan.TownTrip = true
+		}
+	}
+
+	return plan
+}
+
+// packPickup places the candidates in the given order, returning the plan and the value of the placed candidates
+func packPickup(inventory [][]bool, candidates []pickupCandidate, order []int) (pickupPlan, int) {
+	grid := make([][]bool, len(inventory))
+	for y := range inventory {
+		grid[y] = slices.Clone(inventory[y])
+	}
+
+	plan := pickupPlan{Order: make([]int, 0, len(order)), Skipped: make([]int, 0)}
+	value := 0
+	for _, idx := range order {
+		c := candidates[idx]
+		if c.cells() == 0 {
+			plan.Order = append(plan.Order, idx)
+			value += c.Value
+			continue
+		}
+
+		x, y, found := findSpaceInGrid(grid, c.Width, c.Height)
+		if !found {
+			plan.Skipped = append(plan.Skipped, idx)
+			continue
+		}
+		for dy := 0; dy < c.Height; dy++ {
+			for dx := 0; dx < c.Width; dx++ {
+				grid[y+dy][x+dx] = true
+			}
+		}
+		plan.Order = append(plan.Order, idx)
+		value += c.Value
+	}
+
+	return plan, value
+}
+
+// findSpaceInGrid returns the top-left cell of the first free space for an item of the given size, the game fills
+// the inventory column by column
+func findSpaceInGrid(grid [][]bool, width, height int) (int, int, bool) {
+	if len(grid) == 0 {
+		return 0, 0, false
+	}
+
+	for x := 0; x <= len(grid[0])-width; x++ {
+		for y := 0; y <= len(grid)-height; y++ {
+			free := true
+			for dy := 0; dy < height && free; dy++ {
+				for dx := 0; dx < width; dx++ {
+					if grid[y+dy][x+dx] {
+						free = false
+						break
+					}
+				}
+			}
+			if free {
+				return x, y, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+func (c pickupCandidate) cells() int {
+	return c.Width * c.Height
+}
+
+// pickupValue scores an item for the planner using the value table of the character, the NIP rules and tiers
+func pickupValue(i data.Item, values config.PickupValueCfg) int {
+	ctx := context.Get()
+
+	if i.IsRuneword || i.IsFromQuest() || i.Name == "WirtsLeg" {
+		return pickupMustHaveValue
+	}
+	if value, found := values.Items[string(i.Name)]; found {
+		return value
+	}
+
+	value, found := defaultPickupQualityValues[i.Quality]
+	for name, v := range values.Qualities {
+		if quality, ok := config.ParseCubeQuality(name); ok && quality == i.Quality {
+			value, found = v, true
+		}
+	}
+	if !found {
+		value = defaultPickupQualityValues[item.QualityNormal]
+	}
+	if strings.HasSuffix(string(i.Name), "Rune") {
+		value = max(value, pickupRuneValue)
+	}
+
+	playerRule, mercRule := ctx.CharacterCfg.Runtime.Rules.EvaluateTiers(i, ctx.CharacterCfg.Runtime.TierRules)
+	value += int(max(playerRule.Tier(), mercRule.MercTier()))
+
+	if _, result := ctx.CharacterCfg.Runtime.Rules.EvaluateAllIgnoreTiers(i); result == nip.RuleResultFullMatch {
+		value += pickupRuleBonus
+	}
+
+	return value
+}
+
+// pickupCandidateFor returns the planner view of the item, gold doesn't use inventory space
+func pickupCandidateFor(i data.Item, values config.PickupValueCfg) pickupCandidate {
+	c := pickupCandidate{
+		Value:    pickupValue(i, values),
+		Distance: context.Get().PathFinder.DistanceFromMe(i.Position),
+	}
+	if i.Name != "Gold" {
+		c.Width = i.Desc().InventoryWidth
+		c.Height = i.Desc().InventoryHeight
+	}
+
+	return c
+}
+
+// planNextPickup returns the next item to pick up according to the planner, and whether we should go back to town
+// first because a valuable item doesn't fit. Town trips are requested once per item, townTrips keeps track of them.
+func planNextPickup(items []data.Item, townTrips map[data.UnitID]bool) (data.Item, bool) {
+	ctx := context.Get()
+	values := ctx.CharacterCfg.Inventory.PickupValues
+
+	candidates := make([]pickupCandidate, 0, len(items))
+	for _, i := range items {
+		candidates = append(candidates, pickupCandidateFor(i, values))
+	}
+
+	invMatrix := ctx.Data.Inventory.Matrix()
+	inventory := make([][]bool, len(invMatrix))
+	for y := range invMatrix {
+		inventory[y] = make([]bool, len(invMatrix[y]))
+		for x := range invMatrix[y] {
+			inventory[y][x] = invMatrix[y][x]
+		}
+	}
+
+	plan := planPickup(inventory, candidates, values.TownTripValue)
+
+	townTrip := false
+	for _, idx := range plan.Skipped {
+		i := items[idx]
+		recordItemDecision(i, itemlog.DecisionSkip, itemlog.ReasonNoSpace, nip.Rule{}, fmt.Sprintf("value %d", candidates[idx].Value))
+		if values.TownTripValue > 0 && candidates[idx].Value >= values.TownTripValue && !townTrips[i.UnitID] {
+			townTrips[i.UnitID] = true
+			townTrip = true
+		}
+	}
+
+	if len(plan.Order) == 0 {
+		return data.Item{}, townTrip
+	}
+
+	return items[plan.Order[0]], townTrip
+}
@@ -0,0 +1,133 @@
+package action
+
+import (
+	"slices"
+	"testing"
+)
+
+// pickupGrid builds an inventory from rows of cells, # are occupied cells
+func pickupGrid(rows ...string) [][]bool {
+	grid := make([][]bool, len(rows))
+	for y, row := range rows {
+		grid[y] = make([]bool, len(row))
+		for x, cell := range row {
+			grid[y][x] = cell == '#'
+		}
+	}
+	return grid
+}
+
+func TestPlanPickup(t *testing.T) {
+	tests := []struct {
+		name          string
+		inventory     [][]bool
+		candidates    []pickupCandidate
+		townTripValue int
+		order         []int
+		skipped       []int
+		townTrip      bool
+	}{
+		{
+			name:       "everything fits, most valuable first",
+			inventory:  pickupGrid("....", "...."),
+			candidates: []pickupCandidate{{1, 1, 10, 5}, {1, 1, 30, 5}, {1, 2, 20, 5}},
+			order:      []int{1, 2, 0},
+			skipped:    []int{},
+		},
+		{
+			name:       "same value, closest first",
+			inventory:  pickupGrid("....", "...."),
+			candidates: []pickupCandidate{{1, 1, 10, 9}, {1, 1, 10, 2}},
+			order:      []int{1, 0},
+			skipped:    []int{},
+		},
+		{
+			name:       "gold doesn't need space",
+			inventory:  pickupGrid("####", "####"),
+			candidates: []pickupCandidate{{1, 1, 50, 5}, {0, 0, 5, 5}},
+			order:      []int{1},
+			skipped:    []int{0},
+		},
+		{
+			name:       "small items worth more than a big one",
+			inventory:  pickupGrid("##..", "##..", "##..", "##.."),
+			candidates: []pickupCandidate{{2, 4, 40, 5}, {2, 2, 25, 5}, {2, 2, 25, 5}},
+			order:      []int{1, 2},
+			skipped:    []int{0},
+		},
+		{
+			name:       "big item worth more than the small ones",
+			inventory:  pickupGrid("##..", "##..", "##..", "##.."),
+			candidates: []pickupCandidate{{2, 4, 100, 5}, {2, 2, 25, 5}, {2, 2, 25, 5}},
+			order:      []int{0},
+			skipped:    []int{1, 2},
+		},
+		{
+			name:          "town trip for a valuable item without space",
+			inventory:     pickupGrid("#.", "#."),
+			candidates:    []pickupCandidate{{1, 2, 10, 5}, {2, 2, 60, 5}},
+			townTripValue: 50,
+			order:         []int{0},
+			skipped:       []int{1},
+			townTrip:      true,
+		},
+		{
+			name:          "no town trip below the value",
+			inventory:     pickupGrid("#.", "#."),
+			candidates:    []pickupCandidate{{1, 2, 10, 5}, {2, 2, 40, 5}},
+			townTripValue: 50,
+			order:         []int{0},
+			skipped:       []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		plan := planPickup(tt.inventory, tt.candidates, tt.townTripValue)
+		if !slices.Equal(plan.Order, tt.order) {
+			t.Errorf("%s: expected order %v, got %v", tt.name, tt.order, plan.Order)
+		}
+		if !slices.Equal(plan.Skipped, tt.skipped) {
+			t.Errorf("%s: expected skipped %v, got %v", tt.name, tt.skipped, plan.Skipped)
+		}
+		if plan.TownTrip != tt.townTrip {
+			t.Errorf("%s: expected town trip %v, got %v", tt.name, tt.townTrip, plan.TownTrip)
+		}
+	}
+}
+
+func TestPackPickup(t *testing.T) {
+	inventory := pickupGrid("...", "...")
+	candidates := []pickupCandidate{{1, 2, 10, 0}, {1, 2, 20, 0}, {1, 2, 30, 0}, {1, 2, 40, 0}}
+
+	plan, value := packPickup(inventory, candidates, []int{3, 2, 1, 0})
+	if !slices.Equal(plan.Order, []int{3, 2, 1}) || !slices.Equal(plan.Skipped, []int{0}) || value != 90 {
+		t.Errorf("Expected the first three candidates in order to fit, got %+v with value %d", plan, value)
+	}
+	if slices.ContainsFunc(inventory, func(row []bool) bool { return slices.Contains(row, true) }) {
+		t.Errorf("Expected the inventory to be left untouched")
+	}
+}
+
+func TestFindSpaceInGrid(t *testing.T) {
+	tests := []struct {
+		name          string
+		grid          [][]bool
+		width, height int
+		x, y          int
+		found         bool
+	}{
+		{"empty", pickupGrid("...", "..."), 1, 1, 0, 0, true},
+		{"column by column", pickupGrid("#..", "..."), 1, 1, 0, 1, true},
+		{"tall item skips a short gap", pickupGrid("#..", "..."), 1, 2, 1, 0, true},
+		{"wide item", pickupGrid(".#.", "...", "..."), 2, 2, 0, 1, true},
+		{"no space", pickupGrid(".#.", "#.#"), 2, 1, 0, 0, false},
+		{"empty grid", nil, 1, 1, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		x, y, found := findSpaceInGrid(tt.grid, tt.width, tt.height)
+		if found != tt.found || (found && (x != tt.x || y != tt.y)) {
+			t.Errorf("%s: expected %d,%d %v, got %d,%d %v", tt.name, tt.x, tt.y, tt.found, x, y, found)
+		}
+	}
+}
@@ -21,6 +21,7 @@ func MakeRunewords() error {
 	insertItems := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash, item.LocationInventory)
 	baseItems := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash, item.LocationInventory)
 
+	socketed := false
 	for _, recipe := range Runewords {
 		if !slices.Contains(ctx.CharacterCfg.Game.Leveling.EnabledRunewordRecipes, string(recipe.Name)) {
 			continue
@@ -49,6 +50,7 @@ func MakeRunewords() error {
 					}
 
 					insertItems = removeUsedItems(insertItems, inserts)
+					socketed = true
 				} else {
 					continueProcessing = false
 				}
@@ -57,6 +59,9 @@ func MakeRunewords() error {
 			}
 		}
 	}
+	if socketed {
+		saveStashSnapshot()
+	}
 	return nil
 }
 func SocketItems(ctx *context.Status, recipe Runeword, base data.Item, items ...data.Item) error {
@@ -265,31 +270,37 @@ func hasBaseForRunewordRecipe(items []data.Item, recipe Runeword) (data.Item, bo
 }
 
 func hasItemsForRunewordRecipe(items []data.Item, recipe Runeword) ([]data.Item, bool) {
-
-	RunewordRecipeItems := make(map[string]int)
-	for _, item := range recipe.Runes {
-		RunewordRecipeItems[item]++
+	itemsForRecipe, missing := matchRunewordRunes(items, recipe)
+	if len(missing) > 0 {
+		return nil, false
 	}
 
-	itemsForRecipe := []data.Item{}
+	return itemsForRecipe, true
+}
 
-	for _, item := range items {
-		if count, ok := RunewordRecipeItems[string(item.Name)]; ok {
+// matchRunewordRunes returns the items used as the runes of the recipe, in the order they are found, and the names of
+// the runes that are not available
+func matchRunewordRunes(items []data.Item, recipe Runeword) ([]data.Item, []string) {
+	needed := make(map[string]int)
+	for _, runeName := range recipe.Runes {
+		needed[runeName]++
+	}
 
-			itemsForRecipe = append(itemsForRecipe, item)
+	itemsForRecipe := make([]data.Item, 0, len(recipe.Runes))
+	for _, itm := range items {
+		if needed[string(itm.Name)] > 0 {
+			needed[string(itm.Name)]--
+			itemsForRecipe = append(itemsForRecipe, itm)
+		}
+	}
 
-			// Check if we now have exactly the needed count before decrementing
-			count -= 1
-			if count == 0 {
-				delete(RunewordRecipeItems, string(item.Name))
-				if len(RunewordRecipeItems) == 0 {
-					return itemsForRecipe, true
-				}
-			} else {
-				RunewordRecipeItems[string(item.Name)] = count
-			}
+	missing := make([]string, 0)
+	for _, runeName := range recipe.Runes {
+		if needed[runeName] > 0 {
+			needed[runeName]--
+			missing = append(missing, runeName)
 		}
 	}
 
-	return nil, false
+	return itemsForRecipe, missing
 }
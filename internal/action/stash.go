@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log/slog"
 	"slices"
-	"strconv"
 	"strings"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
@@ -14,9 +13,12 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/data/object"
 	"github.com/hectorgimenez/d2go/pkg/nip"
 	"github.com/hectorgimenez/koolo/internal/action/step"
+	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
+	"github.com/hectorgimenez/koolo/internal/mule"
 	"github.com/hectorgimenez/koolo/internal/ui"
 	"github.com/hectorgimenez/koolo/internal/utils"
 	"github.com/lxn/win"
@@ -60,11 +62,24 @@ func Stash(forceStash bool) error {
 	stashInventory(forceStash)
 	// Add call to dropExcessItems after stashing
 	dropExcessItems()
+	saveStashSnapshot()
 	step.CloseAllMenus()
 
 	return nil
 }
 
+// saveStashSnapshot saves the stash content at the end of every stash routine (stashing, cubing, socketing), the
+// dashboard reports (crafting, muling) read it instead of the live game data
+func saveStashSnapshot() {
+	ctx := context.Get()
+	ctx.RefreshInventory()
+
+	stash := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash)
+	if _, err := mule.SaveStashSnapshot(ctx.Name, stash, ctx.Data.Inventory.ByLocation(item.LocationInventory)); err != nil {
+		ctx.Logger.Warn("Failed to save stash snapshot", "error", err)
+	}
+}
+
 func isStashingRequired(firstRun bool) bool {
 	ctx := context.Get()
 	ctx.SetLastStep("isStashingRequired")
@@ -250,36 +265,39 @@ func shouldStashIt(i data.Item, firstRun bool) (bool, bool, string, string) {
 
 	// Don't stash items in protected slots (highest priority exclusion)
 	if ctx.CharacterCfg.Inventory.InventoryLock[i.Position.Y][i.Position.X] == 0 {
+		recordItemDecision(i, itemlog.DecisionKeep, itemlog.ReasonLockedSlot, nip.Rule{}, "")
 		return false, false, "", ""
 	}
 
 	// These items should NEVER be stashed, regardless of quest status, pickit rules, or first run.
-	fmt.Printf("DEBUG: Evaluating item '%s' for *absolute* exclusion from stash.\n", i.Name)
 	if i.Name == "horadricstaff" { // This is the simplest way given your logs
-		fmt.Printf("DEBUG: ABSOLUTELY PREVENTING stash for '%s' (Horadric Staff exclusion).\n", i.Name)
+		recordItemDecision(i, itemlog.DecisionKeep, itemlog.ReasonProtectedItem, nip.Rule{}, "Horadric Staff is never stashed")
 		return false, false, "", "" // Explicitly do NOT stash the Horadric Staff
 	}
 
 	if i.Name == "TomeOfTownPortal" || i.Name == "TomeOfIdentify" || i.Name == "Key" || i.Name == "WirtsLeg" {
-		fmt.Printf("DEBUG: ABSOLUTELY PREVENTING stash for '%s' (Quest/Special item exclusion).\n", i.Name)
+		recordItemDecision(i, itemlog.DecisionKeep, itemlog.ReasonProtectedItem, nip.Rule{}, "tomes, keys and quest items are never stashed")
 		return false, false, "", ""
 	}
 
 	if _, isLevelingChar := ctx.Char.(context.LevelingCharacter); isLevelingChar && i.IsFromQuest() && i.Name != "HoradricCube" || i.Name == "HoradricStaff" {
+		recordItemDecision(i, itemlog.DecisionKeep, itemlog.ReasonQuestItem, nip.Rule{}, "")
 		return false, false, "", ""
 	}
 
 	if firstRun {
-		fmt.Printf("DEBUG: Allowing stash for '%s' (first run).\n", i.Name)
+		recordItemDecision(i, itemlog.DecisionStash, itemlog.ReasonFirstRun, nip.Rule{}, "found in the inventory on the first run")
 		return true, false, "FirstRun", ""
 	}
 
 	if i.IsRuneword {
+		recordItemDecision(i, itemlog.DecisionStash, itemlog.ReasonRuneword, nip.Rule{}, "")
 		return true, false, "Runeword", ""
 	}
 
 	// Stash items that are part of a recipe which are not covered by the NIP rules
 	if shouldKeepRecipeItem(i) {
+		recordItemDecision(i, itemlog.DecisionStash, itemlog.ReasonRecipe, nip.Rule{}, "")
 		return true, false, "Item is part of a enabled recipe", ""
 	}
 
@@ -294,12 +312,20 @@ func shouldStashIt(i data.Item, firstRun bool) (bool, bool, string, string) {
 
 	// NOW, evaluate pickit rules.
 	tierRule, mercTierRule := ctx.CharacterCfg.Runtime.Rules.EvaluateTiers(i, ctx.CharacterCfg.Runtime.TierRules)
-	if tierRule.Tier() > 0.0 && IsBetterThanEquipped(i, false, PlayerScore) {
-		return true, true, tierRule.RawLine, tierRule.Filename + ":" + strconv.Itoa(tierRule.LineNumber)
+	if tierRule.Tier() > 0.0 {
+		if better, comparison := compareWithEquipped(i, false, PlayerScore); better {
+			comparison.Tier = tierRule.Tier()
+			recordTierDecision(i, itemlog.DecisionDrop, itemlog.ReasonTierUpgrade, tierRule, comparison)
+			return true, true, tierRule.RawLine, itemlog.RuleFile(tierRule)
+		}
 	}
 
-	if mercTierRule.Tier() > 0.0 && IsBetterThanEquipped(i, true, MercScore) {
-		return true, true, mercTierRule.RawLine, mercTierRule.Filename + ":" + strconv.Itoa(mercTierRule.LineNumber)
+	if mercTierRule.Tier() > 0.0 {
+		if better, comparison := compareWithEquipped(i, true, MercScore); better {
+			comparison.Tier = mercTierRule.Tier()
+			recordTierDecision(i, itemlog.DecisionDrop, itemlog.ReasonMercTierUpgrade, mercTierRule, comparison)
+			return true, true, mercTierRule.RawLine, itemlog.RuleFile(mercTierRule)
+		}
 	}
 
 	// NOW, evaluate pickit rules.
@@ -308,16 +334,16 @@ func shouldStashIt(i data.Item, firstRun bool) (bool, bool, string, string) {
 	if res == nip.RuleResultFullMatch {
 		if doesExceedQuantity(rule) {
 			// If it matches a rule but exceeds quantity, we want to drop it, not stash.
-			fmt.Printf("DEBUG: Dropping '%s' because MaxQuantity is exceeded.\n", i.Name)
-			return false, true, rule.RawLine, rule.Filename + ":" + strconv.Itoa(rule.LineNumber)
+			recordItemDecision(i, itemlog.DecisionDrop, itemlog.ReasonMaxQuantity, rule, fmt.Sprintf("already %d or more in stash", rule.MaxQuantity()))
+			return false, true, rule.RawLine, itemlog.RuleFile(rule)
 		} else {
 			// If it matches a rule and quantity is fine, stash it.
-			fmt.Printf("DEBUG: Allowing stash for '%s' (pickit rule match: %s).\n", i.Name, rule.RawLine)
-			return true, false, rule.RawLine, rule.Filename + ":" + strconv.Itoa(rule.LineNumber)
+			recordItemDecision(i, itemlog.DecisionStash, itemlog.ReasonRuleMatch, rule, "")
+			return true, false, rule.RawLine, itemlog.RuleFile(rule)
 		}
 	}
 
-	fmt.Printf("DEBUG: Disallowing stash for '%s' (no rule match and not explicitly kept, and not exceeding quantity).\n", i.Name)
+	recordItemDecision(i, itemlog.DecisionKeep, itemlog.ReasonNoRuleMatch, nip.Rule{}, "not stashed, it will be sold")
 	return false, false, "", "" // Default if no other rule matches
 }
 
@@ -346,10 +372,8 @@ func shouldKeepRecipeItem(i data.Item) bool {
 	recipeMatch := false
 
 	// Check if the item is part of a recipe and if that recipe is enabled
-	// 'Recipes' variable is expected to be defined/imported from 'cube_recipes.go' or similar.
-	// This function (shouldKeepRecipeItem) itself is external to this file.
-	for _, recipe := range Recipes { // Assuming `Recipes` is properly defined/imported
-		if slices.Contains(recipe.Items, string(i.Name)) && slices.Contains(ctx.CharacterCfg.CubeRecipes.EnabledRecipes, recipe.Name) {
+	for _, recipe := range config.CubeRecipeDefinitions {
+		if recipe.Uses(string(i.Name)) && slices.Contains(ctx.CharacterCfg.CubeRecipes.EnabledRecipes, recipe.Name) {
 			recipeMatch = true
 			break
 		}
@@ -436,6 +460,7 @@ func blacklistItem(i data.Item) {
 	ctx := context.Get()
 	ctx.CurrentGame.BlacklistedItems = append(ctx.CurrentGame.BlacklistedItems, i)
 	ctx.Logger.Info(fmt.Sprintf("Blacklisted item %s (UnitID: %d) to prevent immediate re-pickup.", i.Name, i.UnitID))
+	recordItemDecision(i, itemlog.DecisionBlacklist, itemlog.ReasonPreventRepickup, nip.Rule{}, "")
 }
 
 // DropItem handles moving an item from inventory to the ground
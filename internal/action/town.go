@@ -1,15 +1,15 @@
 package action
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data/item"
 	"github.com/hectorgimenez/d2go/pkg/data/skill"
 	"github.com/hectorgimenez/koolo/internal/action/step"
-	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/mule"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
@@ -44,43 +44,18 @@ func PreRun(firstRun bool) error {
 		isStashFull := StashFull()
 
 		if isStashFull {
-			muleProfiles := ctx.CharacterCfg.Muling.MuleProfiles
-			muleIndex := ctx.CharacterCfg.MulingState.CurrentMuleIndex
-
-			if muleIndex >= len(muleProfiles) {
-				ctx.Logger.Error("All mules are full! Cannot stash more items. Stopping.")
+			nextMule, err := mule.NewManager(ctx.Logger).NextMule(ctx.Name)
+			if err != nil {
+				ctx.Logger.Error("Stash is full but there is no mule to switch to. Stopping.", "error", err)
 				ctx.StopSupervisor()
-				return errors.New("all mules are full")
+				return err
 			}
 
-			nextMule := muleProfiles[muleIndex]
-			ctx.Logger.Info("Stash is full, preparing to switch to mule.", "mule", nextMule, "index", muleIndex)
-
-			// Increment the index for the next time we come back
-			ctx.CharacterCfg.MulingState.CurrentMuleIndex++
+			ctx.Logger.Info("Stash is full, preparing to switch to mule.", "mule", nextMule)
 
-			// CRITICAL: Save the updated index to the config file BEFORE switching
-			if err := config.SaveSupervisorConfig(ctx.Name, ctx.CharacterCfg); err != nil {
-				ctx.Logger.Error("Failed to save muling state before switching", "error", err)
-				return err // Stop if we can't save state
-			}
-
-			// Trigger the character switch
-			ctx.CurrentGame.SwitchToCharacter = nextMule
-			ctx.RestartWithCharacter = nextMule
-			ctx.CleanStopRequested = true
-			ctx.StopSupervisor()
+			// Trigger the character switch, the mule will come back to us when it's done
+			ctx.SwitchCharacter(nextMule, ctx.Name, event.SwitchReasonStashFull)
 			return ErrMulingNeeded // Stop current execution
-		} else {
-			// If stash is NOT full and the index is not 0, it means muling just finished.
-			// Reset the index and save.
-			if ctx.CharacterCfg.MulingState.CurrentMuleIndex != 0 {
-				ctx.Logger.Info("Muling process complete, resetting mule index.")
-				ctx.CharacterCfg.MulingState.CurrentMuleIndex = 0
-				if err := config.SaveSupervisorConfig(ctx.Name, ctx.CharacterCfg); err != nil {
-					ctx.Logger.Error("Failed to reset muling state", "error", err)
-				}
-			}
 		}
 	}
 
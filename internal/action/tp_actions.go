@@ -23,12 +23,13 @@ func checkPlayerDeathForTP(ctx *context.Status) error {
 	if ctx.Data.PlayerUnit.IsDead() {
 		return health.ErrDied
 	}
+	thresholds := ctx.HealthManager.Thresholds()
 	// Player chicken check
-	if ctx.Data.PlayerUnit.HPPercent() <= ctx.Data.CharacterCfg.Health.ChickenAt {
+	if ctx.Data.PlayerUnit.HPPercent() <= thresholds.ChickenAt {
 		return health.ErrChicken
 	}
 	// Mercenary chicken check
-	if ctx.Data.MercHPPercent() > 0 && ctx.Data.MercHPPercent() <= ctx.Data.CharacterCfg.Health.MercChickenAt {
+	if ctx.Data.MercHPPercent() > 0 && ctx.Data.MercHPPercent() <= thresholds.MercChickenAt {
 		return health.ErrMercChicken
 	}
 	return nil
@@ -11,10 +11,12 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/stat"
 	"github.com/hectorgimenez/koolo/internal/action"
+	"github.com/hectorgimenez/koolo/internal/config"
 	botCtx "github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/health"
 	"github.com/hectorgimenez/koolo/internal/run"
+	"github.com/hectorgimenez/koolo/internal/runpolicy"
 	"github.com/hectorgimenez/koolo/internal/utils"
 
 	"github.com/hectorgimenez/d2go/pkg/data/skill"
@@ -27,6 +29,7 @@ type Bot struct {
 	lastActivityTime      time.Time
 	lastKnownPosition     data.Position
 	lastPositionCheckTime time.Time
+	runPolicies           *runpolicy.Engine
 	MuleManager
 }
 
@@ -40,6 +43,7 @@ func NewBot(ctx *botCtx.Context, mm MuleManager) *Bot {
 		lastActivityTime:      time.Now(),      // Initialize
 		lastKnownPosition:     data.Position{}, // Will be updated on first game data refresh
 		lastPositionCheckTime: time.Now(),      // Initialize
+		runPolicies:           runpolicy.NewEngine(nil),
 		MuleManager:           mm,
 	}
 }
@@ -88,6 +92,10 @@ func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
 
 	b.updateActivityAndPosition() // Initial update for activity and position
 
+	// Run results are kept for the whole session, retries are counted per game
+	b.runPolicies.SetPolicies(b.runPolicySettings())
+	b.runPolicies.NewGame()
+
 	// This routine is in charge of refreshing the game data and handling cancellation, will work in parallel with any other execution
 	g.Go(func() error {
 		b.ctx.AttachRoutine(botCtx.PriorityBackground)
@@ -349,17 +357,28 @@ func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
 		}()
 
 		b.ctx.AttachRoutine(botCtx.PriorityNormal)
-		for _, r := range runs {
+		for i := 0; i < len(runs); i++ {
+			r := runs[i]
+			runID := string(run.ID(r))
 			select {
 			case <-ctx.Done():
 				return nil
 			default:
+				if play, reason := b.runPolicies.ShouldPlay(runID, time.Now()); !play {
+					b.ctx.Logger.Info(fmt.Sprintf("Skipping run %s: %s", r.Name(), reason))
+					continue
+				}
+
 				skipTownRoutines := false
 				if skipper, ok := r.(run.TownRoutineSkipper); ok && skipper.SkipTownRoutines() {
 					skipTownRoutines = true
 				}
 
+				// Between runs is a safe point to apply reloaded settings
+				b.ctx.ApplyPendingConfig(config.ScopeNow)
+
 				event.Send(event.RunStarted(event.Text(b.ctx.Name, fmt.Sprintf("Starting run: %s", r.Name())), r.Name()))
+				b.ctx.HealthManager.SetRun(runID)
 
 				// Update activity here because a new run sequence is starting.
 				b.updateActivityAndPosition()
@@ -402,12 +421,22 @@ func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
 
 				event.Send(event.RunFinished(event.Text(b.ctx.Name, fmt.Sprintf("Finished run: %s", r.Name())), r.Name(), runFinishReason))
 
+				decision := b.runPolicies.Finished(runID, runFinishReason, time.Now())
 				if err != nil {
-					return err
+					switch decision {
+					case runpolicy.Retry:
+						b.ctx.Logger.Warn(fmt.Sprintf("Run %s failed, retrying", r.Name()), "error", err)
+						i--
+						continue
+					case runpolicy.Continue:
+						b.ctx.Logger.Warn(fmt.Sprintf("Run %s failed, continuing with the next run", r.Name()), "error", err)
+					default:
+						return err
+					}
 				}
 
 				if !skipTownRoutines {
-					err = action.PostRun(r == runs[len(runs)-1])
+					err = action.PostRun(b.isLastPlayableRun(runs, i))
 					if err != nil {
 						return err
 					}
@@ -420,6 +449,28 @@ func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
 	return g.Wait()
 }
 
+// isLastPlayableRun tells whether no run after runs[i] can be played now, runs can be skipped by their policies
+func (b *Bot) isLastPlayableRun(runs []run.Run, i int) bool {
+	now := time.Now()
+	for _, next := range runs[i+1:] {
+		if play, _ := b.runPolicies.ShouldPlay(string(run.ID(next)), now); play {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runPolicySettings returns the run policies of the character settings keyed by run
+func (b *Bot) runPolicySettings() map[string]runpolicy.Policy {
+	policies := make(map[string]runpolicy.Policy, len(b.ctx.CharacterCfg.Game.RunPolicies))
+	for r, p := range b.ctx.CharacterCfg.Game.RunPolicies {
+		policies[string(r)] = p
+	}
+
+	return policies
+}
+
 func (b *Bot) Stop() {
 	b.ctx.SwitchPriority(botCtx.PriorityStop)
 	b.ctx.Detach()
@@ -1,26 +1,104 @@
 package bot
 
 import (
+	gocontext "context"
+	"fmt"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/hectorgimenez/koolo/internal/event"
 )
 
-// handleCharacterSwitch is a specialized event handler for character switching
-func (mng *SupervisorManager) handleCharacterSwitch(evt event.Event) {
-	if evt.Message() == "Switching character for muling" {
-		currentSupervisor := evt.Supervisor()
-		nextCharacter := mng.supervisors[currentSupervisor].GetContext().CurrentGame.SwitchToCharacter
-
-		// Wait for the current supervisor to fully stop
-		time.Sleep(5 * time.Second)
-
-		// Start the new character
-		if err := mng.Start(nextCharacter, false, false); err != nil {
-			mng.logger.Error("Failed to start next character",
-				"from", currentSupervisor,
-				"to", nextCharacter,
-				"error", err.Error())
+const (
+	// characterSwitchStopTimeout is how long we wait for the current character to stop before giving up on the switch
+	characterSwitchStopTimeout = 2 * time.Minute
+	characterSwitchStartDelay  = 5 * time.Second
+)
+
+// handleCharacterSwitch stops the character requesting the switch and starts the next one once it's fully stopped
+func (mng *SupervisorManager) handleCharacterSwitch(_ gocontext.Context, e event.Event) error {
+	evt, ok := e.(event.CharacterSwitchEvent)
+	if !ok {
+		return nil
+	}
+
+	mng.mu.Lock()
+	if evt.ReturnTo != "" {
+		mng.pendingReturns[evt.NextCharacter] = evt.ReturnTo
+	} else {
+		delete(mng.pendingReturns, evt.NextCharacter)
+	}
+	mng.mu.Unlock()
+
+	// Get the client PID while the supervisor is still registered, it's gone after stopping it
+	var pid uint32
+	if ctx := mng.GetContext(evt.CurrentCharacter); ctx != nil && ctx.GameReader != nil {
+		pid = ctx.GameReader.Process.GetPID()
+	}
+
+	// The handler runs in the event listener loop, don't block it while the clients are stopped and started
+	go mng.switchCharacter(evt, pid)
+
+	return nil
+}
+
+func (mng *SupervisorManager) switchCharacter(evt event.CharacterSwitchEvent, pid uint32) {
+	mng.logger.Info("Switching character",
+		slog.String("from", evt.CurrentCharacter),
+		slog.String("to", evt.NextCharacter),
+		slog.String("returnTo", evt.ReturnTo),
+		slog.String("reason", string(evt.Reason)))
+
+	mng.Stop(evt.CurrentCharacter)
+	if err := mng.waitForState(evt.CurrentCharacter, StateStopped, characterSwitchStopTimeout); err != nil {
+		mng.characterSwitchFailed(evt, err)
+		return
+	}
+
+	// The client is not killed on stop unless configured, the next character needs it gone
+	if pid != 0 {
+		if process, err := os.FindProcess(int(pid)); err == nil {
+			_ = process.Kill()
 		}
 	}
+
+	time.Sleep(characterSwitchStartDelay)
+
+	if err := mng.Start(evt.NextCharacter, false, false); err != nil {
+		mng.characterSwitchFailed(evt, err)
+	}
+}
+
+// waitForState polls the supervisor state until it reaches the expected one or the timeout expires
+func (mng *SupervisorManager) waitForState(supervisorName string, expected SupervisorState, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		state := mng.State(supervisorName)
+		if state == expected {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("supervisor %s did not reach %s after %s, current state: %s", supervisorName, expected, timeout, state)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func (mng *SupervisorManager) characterSwitchFailed(evt event.CharacterSwitchEvent, err error) {
+	mng.logger.Error("Character switch failed",
+		slog.String("from", evt.CurrentCharacter),
+		slog.String("to", evt.NextCharacter),
+		slog.String("error", err.Error()))
+
+	mng.mu.Lock()
+	delete(mng.pendingReturns, evt.NextCharacter)
+	mng.mu.Unlock()
+
+	msg := fmt.Sprintf("Failed to switch from %s to %s: %s", evt.CurrentCharacter, evt.NextCharacter, err.Error())
+	select {
+	case mng.stateEvents <- event.CharacterSwitchFailed(event.Text(evt.CurrentCharacter, msg), evt.CurrentCharacter, evt.NextCharacter, evt.Reason, err):
+	default:
+		mng.logger.Warn("Supervisor state event queue is full, dropping event", slog.String("supervisor", evt.CurrentCharacter))
+	}
 }
@@ -1,9 +1,11 @@
 package bot
 
 import (
+	gocontext "context"
 	"fmt"
 	"log/slog"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -17,26 +19,58 @@ import (
 	"github.com/hectorgimenez/koolo/internal/health"
 	"github.com/hectorgimenez/koolo/internal/mule"
 	"github.com/hectorgimenez/koolo/internal/pather"
-	"github.com/hectorgimenez/koolo/internal/utils"
 	"github.com/hectorgimenez/koolo/internal/utils/winproc"
+	"github.com/hectorgimenez/koolo/internal/watchdog"
 	"github.com/lxn/win"
 )
 
 type SupervisorManager struct {
-	logger         *slog.Logger
-	supervisors    map[string]Supervisor
-	crashDetectors map[string]*game.CrashDetector
-	eventListener  *event.Listener
+	logger        *slog.Logger
+	mu            sync.RWMutex
+	entries       map[string]*supervisorEntry
+	eventListener *event.Listener
+	stateEvents   chan event.Event
+	// pendingReturns keeps the character every switched-to character has to return to, keyed by the switched-to character
+	pendingReturns map[string]string
+	incidents      *watchdog.IncidentLog
 }
 
-func NewSupervisorManager(logger *slog.Logger, eventListener *event.Listener) *SupervisorManager {
+// supervisorEntry is the registry record of a supervisor, supervisor and monitor are nil while it's not running. The
+// restart history is kept across restarts.
+type supervisorEntry struct {
+	state      SupervisorState
+	supervisor Supervisor
+	monitor    *watchdog.Monitor
+	restarts   *watchdog.RestartTracker
+	// pendingRestart is the restart waiting for its backoff delay, nil when there is none. Stopping or starting the
+	// supervisor by hand cancels it.
+	pendingRestart gocontext.Context
+	cancelRestart  gocontext.CancelFunc
+}
+
+// SupervisorHealth is the restart history and the recent incidents of a supervisor
+type SupervisorHealth struct {
+	Supervisor string                 `json:"supervisor"`
+	State      SupervisorState        `json:"state"`
+	Restarts   watchdog.RestartStatus `json:"restarts"`
+	Incidents  []watchdog.Incident    `json:"incidents"`
+}
 
-	return &SupervisorManager{
+func NewSupervisorManager(logger *slog.Logger, eventListener *event.Listener) *SupervisorManager {
+	mng := &SupervisorManager{
 		logger:         logger,
-		supervisors:    make(map[string]Supervisor),
-		crashDetectors: make(map[string]*game.CrashDetector),
+		entries:        make(map[string]*supervisorEntry),
 		eventListener:  eventListener,
+		stateEvents:    make(chan event.Event, 100),
+		pendingReturns: make(map[string]string),
+		incidents:      watchdog.NewIncidentLog(),
 	}
+
+	eventListener.Register(mng.handleSupervisorEvent)
+	eventListener.Register(mng.handleCharacterSwitch)
+	go mng.forwardStateEvents()
+
+	return mng
 }
 
 func (mng *SupervisorManager) AvailableSupervisors() []string {
@@ -50,59 +84,238 @@ func (mng *SupervisorManager) AvailableSupervisors() []string {
 	return availableSupervisors
 }
 
-func (mng *SupervisorManager) Start(supervisorName string, attachToExisting bool, manualMode bool, pidHwnd ...uint32) error {
-	// Avoid multiple instances of the supervisor - shitstorm prevention
-	if _, exists := mng.supervisors[supervisorName]; exists {
-		return fmt.Errorf("supervisor %s is already running", supervisorName)
+// State returns the lifecycle state of the given supervisor
+func (mng *SupervisorManager) State(supervisorName string) SupervisorState {
+	mng.mu.RLock()
+	defer mng.mu.RUnlock()
+
+	if entry, found := mng.entries[supervisorName]; found {
+		return entry.state
 	}
 
-	// Reload config to get the latest local changes before starting the supervisor
-	err := config.Load()
-	if err != nil {
-		return fmt.Errorf("error loading config: %w", err)
+	return StateNotStarted
+}
+
+// transition moves the supervisor to the next state, it must be called holding the lock
+func (mng *SupervisorManager) transition(supervisorName string, next SupervisorState) error {
+	entry, found := mng.entries[supervisorName]
+	if !found {
+		entry = &supervisorEntry{state: StateNotStarted}
+		mng.entries[supervisorName] = entry
 	}
 
-	supervisorLogger, err := log.NewLogger(config.Koolo.Debug.Log, config.Koolo.LogSaveDirectory, supervisorName)
-	if err != nil {
-		return err
+	if entry.state == next {
+		return nil
+	}
+	if !entry.state.CanTransitionTo(next) {
+		return InvalidTransitionError{Supervisor: supervisorName, From: entry.state, To: next}
 	}
 
-	var optionalPID uint32
-	var optionalHWND win.HWND
+	previous := entry.state
+	entry.state = next
+	mng.logger.Debug("Supervisor state changed", slog.String("supervisor", supervisorName), slog.String("from", string(previous)), slog.String("to", string(next)))
 
-	if attachToExisting {
-		if len(pidHwnd) == 2 {
-			mng.logger.Info("Attaching to existing game", "pid", pidHwnd[0], "hwnd", pidHwnd[1])
-			optionalPID = pidHwnd[0]
-			optionalHWND = win.HWND(pidHwnd[1])
-		} else {
-			return fmt.Errorf("pid and hwnd are required when attaching to an existing game")
+	// Events are queued, sending them directly could block while the event listener is calling our own handler
+	select {
+	case mng.stateEvents <- event.SupervisorStateChanged(event.Text(supervisorName, fmt.Sprintf("Supervisor %s: %s -> %s", supervisorName, previous, next)), string(previous), string(next)):
+	default:
+		mng.logger.Warn("Supervisor state event queue is full, dropping event", slog.String("supervisor", supervisorName))
+	}
+
+	return nil
+}
+
+func (mng *SupervisorManager) setState(supervisorName string, next SupervisorState) error {
+	mng.mu.Lock()
+	defer mng.mu.Unlock()
+
+	return mng.transition(supervisorName, next)
+}
+
+func (mng *SupervisorManager) forwardStateEvents() {
+	for e := range mng.stateEvents {
+		event.Send(e)
+	}
+}
+
+// handleSupervisorEvent keeps the lifecycle state in sync with the game events sent by the supervisors
+func (mng *SupervisorManager) handleSupervisorEvent(_ gocontext.Context, e event.Event) error {
+	var next SupervisorState
+	switch evt := e.(type) {
+	case event.GameCreatedEvent:
+		next = StateInGame
+	case event.GamePausedEvent:
+		next = StateInGame
+		if evt.Paused {
+			next = StatePaused
 		}
+	default:
+		return nil
 	}
 
-	supervisor, crashDetector, err := mng.buildSupervisor(supervisorName, supervisorLogger, attachToExisting, optionalPID, optionalHWND)
-	if err != nil {
+	mng.mu.Lock()
+	defer mng.mu.Unlock()
+
+	// Game events received after a stop request are ignored
+	if entry, found := mng.entries[e.Supervisor()]; !found || entry.supervisor == nil || !entry.state.CanTransitionTo(next) {
+		return nil
+	}
+
+	return mng.transition(e.Supervisor(), next)
+}
+
+// supervisor returns the running supervisor with the given name
+func (mng *SupervisorManager) supervisor(supervisorName string) (Supervisor, bool) {
+	mng.mu.RLock()
+	defer mng.mu.RUnlock()
+
+	entry, found := mng.entries[supervisorName]
+	if !found || entry.supervisor == nil {
+		return nil, false
+	}
+
+	return entry.supervisor, true
+}
+
+// runningSupervisors returns a snapshot of the running supervisors, safe to iterate without holding the lock
+func (mng *SupervisorManager) runningSupervisors() map[string]Supervisor {
+	mng.mu.RLock()
+	defer mng.mu.RUnlock()
+
+	running := make(map[string]Supervisor, len(mng.entries))
+	for name, entry := range mng.entries {
+		if entry.supervisor != nil {
+			running[name] = entry.supervisor
+		}
+	}
+
+	return running
+}
+
+// restartTracker returns the restart history of the given supervisor
+func (mng *SupervisorManager) restartTracker(supervisorName string) *watchdog.RestartTracker {
+	mng.mu.Lock()
+	defer mng.mu.Unlock()
+
+	entry, found := mng.entries[supervisorName]
+	if !found {
+		entry = &supervisorEntry{state: StateNotStarted}
+		mng.entries[supervisorName] = entry
+	}
+	if entry.restarts == nil {
+		entry.restarts = watchdog.NewRestartTracker(watchdog.DefaultRestartPolicy())
+	}
+
+	return entry.restarts
+}
+
+// scheduleRestart registers a pending restart of the supervisor, the returned context is cancelled when the supervisor
+// is stopped or started by hand before the restart happens
+func (mng *SupervisorManager) scheduleRestart(supervisorName string) gocontext.Context {
+	mng.mu.Lock()
+	defer mng.mu.Unlock()
+
+	mng.cancelRestartLocked(supervisorName)
+	entry := mng.entries[supervisorName]
+	entry.pendingRestart, entry.cancelRestart = gocontext.WithCancel(gocontext.Background())
+
+	return entry.pendingRestart
+}
+
+// takeRestart clears the pending restart before starting the supervisor again, it returns false when it was cancelled
+func (mng *SupervisorManager) takeRestart(supervisorName string, restart gocontext.Context) bool {
+	mng.mu.Lock()
+	defer mng.mu.Unlock()
+
+	entry, found := mng.entries[supervisorName]
+	if !found || entry.pendingRestart != restart || restart.Err() != nil {
+		return false
+	}
+	entry.cancelRestart()
+	entry.pendingRestart, entry.cancelRestart = nil, nil
+
+	return true
+}
+
+// cancelRestartLocked cancels the pending restart of the supervisor if any, it must be called holding the lock
+func (mng *SupervisorManager) cancelRestartLocked(supervisorName string) {
+	entry, found := mng.entries[supervisorName]
+	if !found || entry.cancelRestart == nil {
+		return
+	}
+	entry.cancelRestart()
+	entry.pendingRestart, entry.cancelRestart = nil, nil
+}
+
+// Health returns the restart history and the recent incidents of the given supervisor
+func (mng *SupervisorManager) Health(supervisorName string) SupervisorHealth {
+	return SupervisorHealth{
+		Supervisor: supervisorName,
+		State:      mng.State(supervisorName),
+		Restarts:   mng.restartTracker(supervisorName).Status(time.Now()),
+		Incidents:  mng.incidents.List(supervisorName),
+	}
+}
+
+// Start starts the supervisor by hand, it closes the circuit breaker of a supervisor disabled after repeated failures
+func (mng *SupervisorManager) Start(supervisorName string, attachToExisting bool, manualMode bool, pidHwnd ...uint32) error {
+	mng.restartTracker(supervisorName).Reset()
+	mng.mu.Lock()
+	mng.cancelRestartLocked(supervisorName)
+	mng.mu.Unlock()
+
+	return mng.start(supervisorName, attachToExisting, manualMode, pidHwnd...)
+}
+
+func (mng *SupervisorManager) start(supervisorName string, attachToExisting bool, manualMode bool, pidHwnd ...uint32) error {
+	if attachToExisting && len(pidHwnd) != 2 {
+		return fmt.Errorf("pid and hwnd are required when attaching to an existing game")
+	}
+
+	// Reserve the supervisor before doing anything else, so double starts are rejected - shitstorm prevention
+	mng.mu.Lock()
+	if state := mng.stateLocked(supervisorName); state.IsActive() {
+		mng.mu.Unlock()
+		return fmt.Errorf("supervisor %s is already running (%s)", supervisorName, state)
+	}
+	if err := mng.transition(supervisorName, StateStarting); err != nil {
+		mng.mu.Unlock()
 		return err
 	}
+	mng.mu.Unlock()
 
-	// Set manual mode flag
-	ctx := supervisor.GetContext()
-	if ctx != nil {
-		if manualMode {
-			ctx.ManualModeActive = true
-			supervisorLogger.Info("Manual mode enabled")
+	supervisor, monitor, err := mng.startSupervisor(supervisorName, attachToExisting, manualMode, pidHwnd...)
+	if err != nil {
+		mng.mu.Lock()
+		if mng.stateLocked(supervisorName) == StateStopping {
+			_ = mng.transition(supervisorName, StateStopped)
 		} else {
-			ctx.ManualModeActive = false
-			supervisorLogger.Info("Normal mode enabled")
+			_ = mng.transition(supervisorName, StateCrashed)
 		}
+		mng.mu.Unlock()
+		return err
 	}
 
-	if oldCrashDetector, exists := mng.crashDetectors[supervisorName]; exists {
-		oldCrashDetector.Stop() // Stop the old crash detector if it exists
+	mng.mu.Lock()
+	// Stop was requested while the client was starting, we are the only owner of the client so we clean it up
+	if mng.stateLocked(supervisorName) != StateStarting {
+		mng.mu.Unlock()
+		mng.logger.Info("Supervisor was stopped while starting, closing the client", slog.String("supervisor", supervisorName))
+		monitor.Stop()
+		supervisor.Stop()
+		if killer, ok := supervisor.(interface{ KillClient() error }); ok && !attachToExisting {
+			_ = killer.KillClient()
+		}
+		_ = mng.setState(supervisorName, StateStopped)
+		return fmt.Errorf("supervisor %s was stopped while starting", supervisorName)
 	}
-
-	mng.supervisors[supervisorName] = supervisor
-	mng.crashDetectors[supervisorName] = crashDetector
+	entry := mng.entries[supervisorName]
+	if entry.monitor != nil {
+		entry.monitor.Stop() // Stop the old health monitor if it exists
+	}
+	entry.supervisor = supervisor
+	entry.monitor = monitor
+	mng.mu.Unlock()
 
 	if config.Koolo.GameWindowArrangement {
 		go func() {
@@ -113,26 +326,106 @@ func (mng *SupervisorManager) Start(supervisorName string, attachToExisting bool
 		}()
 	}
 
-	// Start the Crash Detector in a thread to avoid blocking and speed up start
-	go crashDetector.Start()
+	// Start the health monitor in a thread to avoid blocking and speed up start
+	go monitor.Start()
 
 	err = supervisor.Start()
 	if err != nil {
 		mng.logger.Error(fmt.Sprintf("error running supervisor %s: %s", supervisorName, err.Error()))
+
+		// The health monitor takes care of restarting it when the client is gone or frozen
+		mng.mu.Lock()
+		if entry, found := mng.entries[supervisorName]; found && entry.supervisor == supervisor {
+			_ = mng.transition(supervisorName, StateCrashed)
+		}
+		mng.mu.Unlock()
 	}
 
 	return nil
 }
 
-func (mng *SupervisorManager) ReloadConfig() error {
+// startSupervisor loads the config, starts (or attaches to) the game client and builds the supervisor
+func (mng *SupervisorManager) startSupervisor(supervisorName string, attachToExisting bool, manualMode bool, pidHwnd ...uint32) (Supervisor, *watchdog.Monitor, error) {
+	// Reload config to get the latest local changes before starting the supervisor
+	err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading config: %w", err)
+	}
 
+	cfg, found := config.GetCharacter(supervisorName)
+	var settingErrs config.ValidationErrors
+	if found {
+		settingErrs = cfg.Check()
+		// Only the settings that would crash the bot prevent it from starting, the rest are logged
+		if fatal := settingErrs.Fatal(); len(fatal) > 0 {
+			return nil, nil, fmt.Errorf("invalid settings, please fix them in the character settings: %w", fatal)
+		}
+	}
+
+	supervisorLogger, err := log.NewLogger(config.Koolo.Debug.Log, config.Koolo.LogSaveDirectory, supervisorName, config.Koolo.Logging)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, settingErr := range settingErrs {
+		supervisorLogger.Warn("Invalid setting, please fix it in the character settings", "field", settingErr.Field, "error", settingErr.Message)
+	}
+	if found && cfg.Runtime.LevelingBuildErr != nil {
+		supervisorLogger.Error("Error loading the leveling build, using the class defaults", "error", cfg.Runtime.LevelingBuildErr)
+	}
+
+	var optionalPID uint32
+	var optionalHWND win.HWND
+
+	if attachToExisting {
+		mng.logger.Info("Attaching to existing game", "pid", pidHwnd[0], "hwnd", pidHwnd[1])
+		optionalPID = pidHwnd[0]
+		optionalHWND = win.HWND(pidHwnd[1])
+	}
+
+	supervisor, monitor, err := mng.buildSupervisor(supervisorName, supervisorLogger, attachToExisting, manualMode, optionalPID, optionalHWND)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Set manual mode flag
+	ctx := supervisor.GetContext()
+	if ctx != nil {
+		mng.mu.Lock()
+		ctx.ReturnToCharacter = mng.pendingReturns[supervisorName]
+		delete(mng.pendingReturns, supervisorName)
+		mng.mu.Unlock()
+
+		if manualMode {
+			ctx.ManualModeActive = true
+			supervisorLogger.Info("Manual mode enabled")
+		} else {
+			ctx.ManualModeActive = false
+			supervisorLogger.Info("Normal mode enabled")
+		}
+	}
+
+	return supervisor, monitor, nil
+}
+
+func (mng *SupervisorManager) stateLocked(supervisorName string) SupervisorState {
+	if entry, found := mng.entries[supervisorName]; found {
+		return entry.state
+	}
+
+	return StateNotStarted
+}
+
+// ReloadConfig loads the configs from disk and queues the changes of every running supervisor, they are applied by
+// the supervisor itself at the next safe point. The returned diffs describe the changes and when they will be applied.
+func (mng *SupervisorManager) ReloadConfig() ([]config.ConfigDiff, error) {
 	// Load fresh configs
 	if err := config.Load(); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Apply new configs to running supervisors
-	for name, sup := range mng.supervisors {
+	diffs := make([]config.ConfigDiff, 0)
+	for name, sup := range mng.runningSupervisors() {
 		newCfg, exists := config.GetCharacter(name)
 		if !exists {
 			continue
@@ -143,85 +436,126 @@ func (mng *SupervisorManager) ReloadConfig() error {
 			continue
 		}
 
-		// Preserve runtime data
-		//oldRuntimeData := ctx.CharacterCfg.Runtime
+		diff := config.DiffCharacterCfg(name, ctx.CharacterCfg, newCfg)
+		if diff.Empty() {
+			continue
+		}
 
-		// Update the config
-		*ctx.CharacterCfg = *newCfg
-		//ctx.CharacterCfg.Runtime = oldRuntimeData
+		ctx.QueueConfigReload(newCfg, diff)
+		diffs = append(diffs, diff)
+		mng.logger.Info("Config changes queued", slog.String("supervisor", name), slog.Int("changes", len(diff.Changes)), slog.String("scope", string(diff.Scope())))
 	}
 
-	return nil
+	return diffs, nil
 }
 
 func (mng *SupervisorManager) StopAll() {
-	for _, s := range mng.supervisors {
-		s.Stop()
+	for name := range mng.runningSupervisors() {
+		mng.Stop(name)
 	}
 }
 
+// Stop stops the supervisor, a restart waiting for its backoff delay is cancelled
 func (mng *SupervisorManager) Stop(supervisor string) {
-	s, found := mng.supervisors[supervisor]
-	if found {
-		// Log the stop sequence
-		mng.logger.Info("Stopping supervisor instance", slog.String("supervisor", supervisor))
+	mng.mu.Lock()
+	mng.cancelRestartLocked(supervisor)
+	mng.mu.Unlock()
+
+	mng.stop(supervisor)
+}
 
-		// Stop the Supervisor's internal loops and kill the client if configured
-		s.Stop()
+func (mng *SupervisorManager) stop(supervisor string) {
+	mng.mu.Lock()
+	entry, found := mng.entries[supervisor]
+	if !found || !(entry.state.IsActive() || entry.state == StateCrashed) || entry.state == StateStopping {
+		mng.mu.Unlock()
+		return
+	}
 
-		// Delete from the list of active Supervisors
-		delete(mng.supervisors, supervisor)
+	wasStarting := entry.state == StateStarting
+	_ = mng.transition(supervisor, StateStopping)
 
-		// Stop the crash detector associated with it
-		if cd, ok := mng.crashDetectors[supervisor]; ok {
-			cd.Stop()
-			delete(mng.crashDetectors, supervisor)
+	if entry.supervisor == nil {
+		// Still starting, Start will close the client as soon as it's ready
+		if wasStarting {
+			mng.mu.Unlock()
+			mng.logger.Info("Supervisor is starting, it will be stopped when ready", slog.String("supervisor", supervisor))
+			return
 		}
 
-		// The logic to start the next character has been removed from here.
-		// The restartFunc is now the single source of truth for this,
-		// preventing the mule from restarting itself.
+		// Crashed before having a client, nothing to clean up
+		_ = mng.transition(supervisor, StateStopped)
+		mng.mu.Unlock()
+		return
 	}
+
+	s := entry.supervisor
+	monitor := entry.monitor
+	entry.supervisor = nil
+	entry.monitor = nil
+	mng.mu.Unlock()
+
+	// Log the stop sequence
+	mng.logger.Info("Stopping supervisor instance", slog.String("supervisor", supervisor))
+
+	// Stop the Supervisor's internal loops and kill the client if configured
+	s.Stop()
+
+	// Stop the health monitor associated with it
+	if monitor != nil {
+		monitor.Stop()
+	}
+
+	_ = mng.setState(supervisor, StateStopped)
+
+	if err := log.Close(supervisor); err != nil {
+		mng.logger.Warn("Error closing supervisor log file", slog.String("supervisor", supervisor), slog.Any("error", err))
+	}
+
+	// The logic to start the next character has been removed from here.
+	// The restartFunc is now the single source of truth for this,
+	// preventing the mule from restarting itself.
 }
 
 func (mng *SupervisorManager) TogglePause(supervisor string) {
-	s, found := mng.supervisors[supervisor]
-	if found {
+	if s, found := mng.supervisor(supervisor); found {
 		s.TogglePause()
 	}
 }
 
 func (mng *SupervisorManager) Status(characterName string) Stats {
-	for name, supervisor := range mng.supervisors {
-		if name == characterName {
-			return supervisor.Stats()
-		}
+	if s, found := mng.supervisor(characterName); found {
+		stats := s.Stats()
+		stats.State = mng.State(characterName)
+		return stats
 	}
 
-	return Stats{}
+	// Not running (or still building the client), the status is only known by the manager
+	state := mng.State(characterName)
+	if state == StateNotStarted {
+		return Stats{}
+	}
+
+	return Stats{SupervisorStatus: state.Status(), State: state}
 }
 
 func (mng *SupervisorManager) GetData(characterName string) *game.Data {
-	for name, supervisor := range mng.supervisors {
-		if name == characterName {
-			return supervisor.GetData()
-		}
+	if s, found := mng.supervisor(characterName); found {
+		return s.GetData()
 	}
 
 	return nil
 }
 
 func (mng *SupervisorManager) GetContext(characterName string) *context.Context {
-	for name, supervisor := range mng.supervisors {
-		if name == characterName {
-			return supervisor.GetContext()
-		}
+	if s, found := mng.supervisor(characterName); found {
+		return s.GetContext()
 	}
 
 	return nil
 }
 
-func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slog.Logger, attach bool, optionalPID uint32, optionalHWND win.HWND) (Supervisor, *game.CrashDetector, error) {
+func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slog.Logger, attach bool, manualMode bool, optionalPID uint32, optionalHWND win.HWND) (Supervisor, *watchdog.Monitor, error) {
 	cfg, found := config.GetCharacter(supervisorName)
 	if !found {
 		return nil, nil, fmt.Errorf("character %s not found", supervisorName)
@@ -291,10 +625,12 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 		return nil, nil, err
 	}
 
-	supervisor.GetContext().StopSupervisorFn = supervisor.Stop
+	supervisor.GetContext().StopSupervisorFn = func() {
+		mng.Stop(supervisorName)
+	}
 
-	// This function will be used to restart the client - passed to the crashDetector
-	restartFunc := func() {
+	// This function will be used to restart the client - passed to the health monitor
+	restartFunc := func(failure watchdog.Failure) {
 
 		ctx := supervisor.GetContext()
 
@@ -307,28 +643,49 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 		}
 
 		if ctx.CleanStopRequested {
-			if ctx.RestartWithCharacter != "" {
-				mng.logger.Info("Supervisor requested restart with different character",
-					slog.String("from", supervisorName),
-					slog.String("to", ctx.RestartWithCharacter))
-				nextCharacter := ctx.RestartWithCharacter
-				mng.Stop(supervisorName)
-				time.Sleep(5 * time.Second) // Wait before starting new character
-				if err := mng.Start(nextCharacter, false, false); err != nil {
-					mng.logger.Error("Failed to start next character",
-						slog.String("character", nextCharacter),
-						slog.String("error", err.Error()))
-				}
-				return
-			}
+			// Character switches are driven by the CharacterSwitchEvent, the next character is started once we are stopped
 			mng.logger.Info("Supervisor stopped cleanly by game logic. Preventing restart.", slog.String("supervisor", supervisorName))
 			mng.Stop(supervisorName)
 			return
 		}
 
-		mng.logger.Info("Restarting supervisor after crash", slog.String("supervisor", supervisorName))
-		mng.Stop(supervisorName)
-		time.Sleep(5 * time.Second) // Wait a bit before restarting
+		tracker := mng.restartTracker(supervisorName)
+		decision := tracker.Decide(time.Now())
+		mng.incidents.Add(watchdog.Incident{Supervisor: supervisorName, Probe: failure.Probe, Reason: failure.Reason, At: failure.At, Decision: decision})
+
+		_ = mng.setState(supervisorName, StateCrashed)
+
+		// A frozen client is still running, it has to be closed before starting a new one
+		if failure.Probe != watchdog.ProbeProcessAlive {
+			if err := supervisor.KillClient(); err != nil {
+				mng.logger.Warn("Error closing frozen client", slog.String("supervisor", supervisorName), slog.Any("error", err))
+			}
+		}
+
+		if decision.Action == watchdog.ActionDisable {
+			mng.logger.Error("Supervisor disabled after repeated failures, start it again to re-enable it", slog.String("supervisor", supervisorName), slog.String("reason", decision.Reason))
+			mng.Stop(supervisorName)
+			return
+		}
+
+		mng.logger.Info("Restarting supervisor after failure", slog.String("supervisor", supervisorName), slog.String("probe", failure.Probe), slog.Duration("delay", decision.Delay), slog.String("reason", decision.Reason))
+
+		// Keep the character to return to, a mule crashing in the middle of a switch chain still has to go back
+		if ctx.ReturnToCharacter != "" {
+			mng.mu.Lock()
+			mng.pendingReturns[supervisorName] = ctx.ReturnToCharacter
+			mng.mu.Unlock()
+		}
+		// Registered before stopping, so a Stop by hand from now on cancels the restart instead of being ignored
+		restart := mng.scheduleRestart(supervisorName)
+		mng.stop(supervisorName)
+
+		select {
+		case <-restart.Done():
+			mng.logger.Info("Pending restart cancelled, the supervisor was stopped or started by hand", slog.String("supervisor", supervisorName))
+			return
+		case <-time.After(decision.Delay):
+		}
 
 		// Get a list of all available Supervisors
 		supervisorList := mng.AvailableSupervisors()
@@ -372,13 +729,24 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 			}
 
 			// Wait 5 seconds before checking again
-			utils.Sleep(5000)
+			select {
+			case <-restart.Done():
+				mng.logger.Info("Pending restart cancelled, the supervisor was stopped or started by hand", slog.String("supervisor", supervisorName))
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+
+		if !mng.takeRestart(supervisorName, restart) {
+			mng.logger.Info("Pending restart cancelled, the supervisor was stopped or started by hand", slog.String("supervisor", supervisorName))
+			return
 		}
+		tracker.RecordRestart(time.Now())
 
 		gameTitle := "D2R - [" + strconv.FormatInt(int64(pid), 10) + "] - " + supervisorName + " - " + cfg.Realm
 		winproc.SetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(gameTitle))))
 
-		err := mng.Start(supervisorName, false, false)
+		err := mng.start(supervisorName, false, false)
 		if err != nil {
 			mng.logger.Error("Failed to restart supervisor", slog.String("supervisor", supervisorName), slog.String("Error: ", err.Error()))
 		}
@@ -386,16 +754,34 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 
 	gameTitle := "D2R - [" + strconv.FormatInt(int64(pid), 10) + "] - " + supervisorName + " - " + cfg.Realm
 	winproc.SetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(gameTitle))))
-	crashDetector := game.NewCrashDetector(supervisorName, int32(pid), uintptr(hwnd), mng.logger, restartFunc)
+	monitor := watchdog.NewMonitor(supervisorName, mng.logger, mng.restartTracker(supervisorName), restartFunc, mng.healthProbes(ctx.Context, pid, hwnd, manualMode)...)
 
-	return supervisor, crashDetector, nil
+	return supervisor, monitor, nil
 }
 
-func (mng *SupervisorManager) GetSupervisorStats(supervisor string) Stats {
-	if mng.supervisors[supervisor] == nil {
-		return Stats{}
+// healthProbes returns the probes of a supervisor, only the process is checked in manual mode as the player is in control
+func (mng *SupervisorManager) healthProbes(ctx *context.Context, pid uint32, hwnd win.HWND, manualMode bool) []watchdog.Probe {
+	probes := []watchdog.Probe{
+		&watchdog.ProcessAlive{IsRunning: func() bool { return game.IsProcessRunning(pid) }},
 	}
-	return mng.supervisors[supervisor].Stats()
+	if manualMode {
+		return probes
+	}
+
+	return append(probes,
+		&watchdog.WindowResponding{IsHung: func() bool { return game.IsWindowHung(uintptr(hwnd)) }, Grace: 2 * time.Minute},
+		&watchdog.DataFreshness{
+			LastRefresh: func() (time.Time, bool) {
+				return ctx.Health.LastRefresh(), ctx.Health.InGame() && ctx.ExecutionPriority != context.PriorityPause
+			},
+			MaxAge: 2 * time.Minute,
+		},
+		&watchdog.MenuStuck{OutOfGameSince: ctx.Health.OutOfGameSince, MaxDuration: 5 * time.Minute},
+	)
+}
+
+func (mng *SupervisorManager) GetSupervisorStats(supervisor string) Stats {
+	return mng.Status(supervisor)
 }
 
 func (mng *SupervisorManager) rearrangeWindows() {
@@ -416,7 +802,7 @@ func (mng *SupervisorManager) rearrangeWindows() {
 	)
 
 	var column, row int32
-	for _, sp := range mng.supervisors {
+	for _, sp := range mng.runningSupervisors() {
 		// reminder that columns are vertical (they go up and down) and rows are horizontal (they go left and right)
 		if column > maxColumns {
 			column = 0
@@ -33,6 +33,8 @@ const (
 
 type SinglePlayerSupervisor struct {
 	*baseSupervisor
+	// gamesStarted is the number of games started during the session, used by the run conditions
+	gamesStarted int
 }
 
 func (s *SinglePlayerSupervisor) GetData() *game.Data {
@@ -189,6 +191,8 @@ func (s *SinglePlayerSupervisor) Start() error {
 
 		// LOGIC OUTSIDE OF GAME (MENUS)
 		if !s.bot.ctx.Manager.InGame() {
+			s.bot.ctx.Health.SetInGame(false)
+
 			// This outer timer is the ultimate watchdog. If the bot is out of game for too long,
 			// for any reason (including a frozen state read), this will trigger.
 			if time.Since(timeSpentNotInGameStart) > maxTimeNotInGame {
@@ -234,6 +238,10 @@ func (s *SinglePlayerSupervisor) Start() error {
 
 		// In-game logic
 		timeSpentNotInGameStart = time.Now()
+		s.bot.ctx.Health.SetInGame(true)
+
+		// Reloaded settings are applied before building the runs of the new game
+		s.bot.ctx.ApplyPendingConfig(config.ScopeNow, config.ScopeNextGame)
 
 		stringRuns := make([]string, len(s.bot.ctx.CharacterCfg.Game.Runs))
 		for i, r := range s.bot.ctx.CharacterCfg.Game.Runs {
@@ -244,7 +252,8 @@ func (s *SinglePlayerSupervisor) Start() error {
 			return nil
 		}
 
-		runs := run.BuildRuns(s.bot.ctx.CharacterCfg, orderedRuns)
+		s.gamesStarted++
+		runs := run.BuildRuns(s.bot.ctx.CharacterCfg, orderedRuns, s.gamesStarted)
 		gameStart := time.Now()
 		cfg, _ := config.GetCharacter(s.name)
 
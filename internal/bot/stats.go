@@ -15,6 +15,7 @@ const (
 	Starting   SupervisorStatus = "Starting"
 	InGame     SupervisorStatus = "In game"
 	Paused     SupervisorStatus = "Paused"
+	Stopping   SupervisorStatus = "Stopping"
 	Crashed    SupervisorStatus = "Crashed"
 )
 
@@ -98,6 +99,7 @@ func (h *StatsHandler) Stats() Stats {
 type Stats struct {
 	StartedAt           time.Time
 	SupervisorStatus    SupervisorStatus
+	State               SupervisorState `json:"state"`
 	Details             string
 	Drops               []data.Drop
 	Games               []GameStats
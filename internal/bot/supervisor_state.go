@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SupervisorState is the lifecycle state of a supervisor as tracked by the SupervisorManager
+type SupervisorState string
+
+const (
+	StateNotStarted SupervisorState = "NotStarted"
+	StateStarting   SupervisorState = "Starting"
+	StateInGame     SupervisorState = "InGame"
+	StatePaused     SupervisorState = "Paused"
+	StateStopping   SupervisorState = "Stopping"
+	StateStopped    SupervisorState = "Stopped"
+	StateCrashed    SupervisorState = "Crashed"
+)
+
+// supervisorTransitions lists the valid next states for every state
+var supervisorTransitions = map[SupervisorState][]SupervisorState{
+	StateNotStarted: {StateStarting},
+	StateStarting:   {StateInGame, StatePaused, StateStopping, StateCrashed},
+	StateInGame:     {StatePaused, StateStopping, StateCrashed},
+	StatePaused:     {StateInGame, StateStopping, StateCrashed},
+	StateStopping:   {StateStopped, StateCrashed},
+	StateStopped:    {StateStarting},
+	StateCrashed:    {StateStarting, StateStopping},
+}
+
+type InvalidTransitionError struct {
+	Supervisor string
+	From       SupervisorState
+	To         SupervisorState
+}
+
+func (e InvalidTransitionError) Error() string {
+	return fmt.Sprintf("supervisor %s can not go from %s to %s", e.Supervisor, e.From, e.To)
+}
+
+func (s SupervisorState) CanTransitionTo(next SupervisorState) bool {
+	return slices.Contains(supervisorTransitions[s], next)
+}
+
+// IsActive returns true while the supervisor owns a game client (or is about to)
+func (s SupervisorState) IsActive() bool {
+	return s == StateStarting || s == StateInGame || s == StatePaused || s == StateStopping
+}
+
+// Status maps the lifecycle state to the status shown in the dashboard and remotes
+func (s SupervisorState) Status() SupervisorStatus {
+	switch s {
+	case StateStarting:
+		return Starting
+	case StateInGame:
+		return InGame
+	case StatePaused:
+		return Paused
+	case StateStopping:
+		return Stopping
+	case StateCrashed:
+		return Crashed
+	default:
+		return NotStarted
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
-	"sort"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
@@ -314,13 +313,8 @@ func (s AmazonLeveling) KillCouncil() error {
 			}
 		}
 
-		// Order council members by distance
-		sort.Slice(councilMembers, func(i, j int) bool {
-			distanceI := s.PathFinder.DistanceFromMe(councilMembers[i].Position)
-			distanceJ := s.PathFinder.DistanceFromMe(councilMembers[j].Position)
-
-			return distanceI < distanceJ
-		})
+		// Most dangerous council member first, see the threat model
+		action.SortEnemiesByPriority(&councilMembers)
 
 		for _, m := range councilMembers {
 			return m.UnitID, true
@@ -3,7 +3,6 @@ package character
 import (
 	"fmt"
 	"log/slog"
-	"sort"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
@@ -430,12 +429,8 @@ func (s AssassinLeveling) KillCouncil() error {
 			}
 		}
 
-		sort.Slice(councilMembers, func(i, j int) bool {
-			distanceI := s.PathFinder.DistanceFromMe(councilMembers[i].Position)
-			distanceJ := s.PathFinder.DistanceFromMe(councilMembers[j].Position)
-
-			return distanceI < distanceJ
-		})
+		// Most dangerous council member first, see the threat model
+		action.SortEnemiesByPriority(&councilMembers)
 
 		for _, m := range councilMembers {
 			return m.UnitID, true
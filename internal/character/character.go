@@ -16,19 +16,20 @@ func BuildCharacter(ctx *context.Context) (context.Character, error) {
 	}
 
 	if len(ctx.CharacterCfg.Game.Runs) > 0 && (ctx.CharacterCfg.Game.Runs[0] == "leveling" || ctx.CharacterCfg.Game.Runs[0] == "leveling_sequence") {
+		// Leveling characters are wrapped, so stats and skills defined in the build file override the class defaults
 		switch strings.ToLower(ctx.CharacterCfg.Character.Class) {
 		case "sorceress_leveling":
-			return SorceressLeveling{BaseCharacter: bc}, nil
+			return withLevelingBuild(ctx, SorceressLeveling{BaseCharacter: bc}), nil
 		case "necromancer":
-			return &NecromancerLeveling{BaseCharacter: bc}, nil
+			return withLevelingBuild(ctx, &NecromancerLeveling{BaseCharacter: bc}), nil
 		case "paladin":
-			return PaladinLeveling{BaseCharacter: bc}, nil
+			return withLevelingBuild(ctx, PaladinLeveling{BaseCharacter: bc}), nil
 		case "assassin":
-			return AssassinLeveling{BaseCharacter: bc}, nil
+			return withLevelingBuild(ctx, AssassinLeveling{BaseCharacter: bc}), nil
 		case "druid_leveling":
-			return DruidLeveling{BaseCharacter: bc}, nil
+			return withLevelingBuild(ctx, DruidLeveling{BaseCharacter: bc}), nil
 		case "amazon_leveling":
-			return AmazonLeveling{BaseCharacter: bc}, nil
+			return withLevelingBuild(ctx, AmazonLeveling{BaseCharacter: bc}), nil
 		}
 
 		return nil, fmt.Errorf("leveling only available for sorceress, assassin, necromancer, druid and paladin")
@@ -3,7 +3,6 @@ package character
 import (
 	"fmt"
 	"log/slog"
-	"sort"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data/mode"
@@ -575,7 +574,7 @@ func (s DruidLeveling) KillDuriel() error {
 	return s.killMonster(npc.Duriel, data.MonsterTypeUnique)
 }
 
-// Targets multiple council members, sorted by distance
+// Targets multiple council members, the most dangerous first
 func (s DruidLeveling) KillCouncil() error {
 	return s.KillMonsterSequence(func(d game.Data) (data.UnitID, bool) {
 		var councilMembers []data.Monster
@@ -585,9 +584,8 @@ func (s DruidLeveling) KillCouncil() error {
 			}
 		}
 
-		sort.Slice(councilMembers, func(i, j int) bool {
-			return s.PathFinder.DistanceFromMe(councilMembers[i].Position) < s.PathFinder.DistanceFromMe(councilMembers[j].Position)
-		})
+		// Most dangerous council member first, see the threat model
+		action.SortEnemiesByPriority(&councilMembers)
 
 		for _, m := range councilMembers {
 			return m.UnitID, true
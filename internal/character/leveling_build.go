@@ -0,0 +1,122 @@
+package character
+
+import (
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/data/skill"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/context"
+)
+
+// levelingBuildCharacter overrides the stat/skill allocation of a leveling character with the sections defined in
+// its build file (builds_leveling/<build>.json), the class defaults are used for every undefined section.
+type levelingBuildCharacter struct {
+	context.LevelingCharacter
+	ctx *context.Context
+}
+
+func withLevelingBuild(ctx *context.Context, char context.LevelingCharacter) context.LevelingCharacter {
+	return levelingBuildCharacter{LevelingCharacter: char, ctx: ctx}
+}
+
+// build is read on every call, so config reloads are applied without rebuilding the character
+func (c levelingBuildCharacter) build() *config.LevelingBuildConfig {
+	return c.ctx.CharacterCfg.Runtime.LevelingBuild
+}
+
+func (c levelingBuildCharacter) level() int {
+	lvl, _ := c.ctx.Data.PlayerUnit.FindStat(stat.Level, 0)
+	return lvl.Value
+}
+
+func (c levelingBuildCharacter) StatPoints() []context.StatAllocation {
+	build := c.build()
+	if build == nil || len(build.StatPoints) == 0 {
+		return c.LevelingCharacter.StatPoints()
+	}
+
+	targets := make([]context.StatAllocation, 0, len(build.StatPoints))
+	for _, target := range build.StatPoints {
+		st, _ := config.ParseLevelingStat(target.Stat)
+		targets = append(targets, context.StatAllocation{Stat: st, Points: target.Points})
+	}
+
+	return targets
+}
+
+func (c levelingBuildCharacter) SkillPoints() []skill.ID {
+	build := c.build()
+	if build == nil {
+		return c.LevelingCharacter.SkillPoints()
+	}
+
+	if skillSequence, found := build.SkillSequence(c.level()); found {
+		return skillSequence
+	}
+
+	return c.LevelingCharacter.SkillPoints()
+}
+
+func (c levelingBuildCharacter) SkillsToBind() (skill.ID, []skill.ID) {
+	build := c.build()
+	if build == nil {
+		return c.LevelingCharacter.SkillsToBind()
+	}
+
+	mainSkill, skillBindings, found := build.Bindings(c.level())
+	if !found {
+		return c.LevelingCharacter.SkillsToBind()
+	}
+
+	if _, learned := c.ctx.Data.PlayerUnit.Skills[mainSkill]; !learned {
+		mainSkill = skill.AttackSkill
+	}
+
+	// Like the class defaults, skills not learned yet (or not granted by items, e.g. Battle Orders) are not bound
+	learnedBindings := make([]skill.ID, 0, len(skillBindings))
+	for _, sk := range skillBindings {
+		if _, learned := c.ctx.Data.PlayerUnit.Skills[sk]; learned {
+			learnedBindings = append(learnedBindings, sk)
+		}
+	}
+	skillBindings = learnedBindings
+
+	if _, found := c.ctx.Data.Inventory.Find(item.TomeOfTownPortal, item.LocationInventory); found && !slices.Contains(skillBindings, skill.TomeOfTownPortal) {
+		skillBindings = append(skillBindings, skill.TomeOfTownPortal)
+	}
+
+	c.ctx.Logger.Info("Skills bound from leveling build", "mainSkill", mainSkill, "skillBindings", skillBindings)
+	return mainSkill, skillBindings
+}
+
+func (c levelingBuildCharacter) ShouldResetSkills() bool {
+	build := c.build()
+	if build == nil || build.RespecLevel == 0 {
+		return c.LevelingCharacter.ShouldResetSkills()
+	}
+
+	if c.level() < build.RespecLevel {
+		return false
+	}
+
+	// Once the points invested in the early brackets are gone there is nothing else to reset
+	for _, sk := range build.ObsoleteSkills() {
+		if c.ctx.Data.PlayerUnit.Skills[sk].Level > 0 {
+			c.ctx.Logger.Info("Resetting skills: respec level defined in leveling build reached", "respecLevel", build.RespecLevel)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c levelingBuildCharacter) GetAdditionalRunewords() []string {
+	build := c.build()
+	if build == nil || len(build.Runewords) == 0 {
+		return c.LevelingCharacter.GetAdditionalRunewords()
+	}
+
+	return build.Runewords
+}
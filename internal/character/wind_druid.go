@@ -3,7 +3,6 @@ package character
 import (
 	"fmt"
 	"log/slog"
-	"sort"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data/mode"
@@ -15,6 +14,7 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/data/skill"
 	"github.com/hectorgimenez/d2go/pkg/data/stat"
 	"github.com/hectorgimenez/d2go/pkg/data/state"
+	"github.com/hectorgimenez/koolo/internal/action"
 	"github.com/hectorgimenez/koolo/internal/action/step"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
@@ -261,7 +261,7 @@ func (s WindDruid) KillDuriel() error {
 	return s.killMonster(npc.Duriel, data.MonsterTypeUnique)
 }
 
-// Targets multiple council members, sorted by distance
+// Targets multiple council members, the most dangerous first
 func (s WindDruid) KillCouncil() error {
 	return s.KillMonsterSequence(func(d game.Data) (data.UnitID, bool) {
 		var councilMembers []data.Monster
@@ -271,9 +271,8 @@ func (s WindDruid) KillCouncil() error {
 			}
 		}
 
-		sort.Slice(councilMembers, func(i, j int) bool {
-			return s.PathFinder.DistanceFromMe(councilMembers[i].Position) < s.PathFinder.DistanceFromMe(councilMembers[j].Position)
-		})
+		// Most dangerous council member first, see the threat model
+		action.SortEnemiesByPriority(&councilMembers)
 
 		for _, m := range councilMembers {
 			return m.UnitID, true
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupsDir is the folder inside config holding the backups of the bulk operations, it's not a profile itself
+const BackupsDir = "backups"
+
+const bulkOperationFile = "operation.json"
+
+var (
+	ErrEmptyBulkPatch     = errors.New("the patch has no changes, set a merge patch or a path")
+	ErrInvalidBulkPatch   = errors.New("the patch leaves some profiles with invalid settings")
+	ErrNoProfilesSelected = errors.New("no profile matches the selector")
+)
+
+// ProfileSelector picks the profiles a bulk operation applies to, a profile has to match every criteria set. The
+// template is never selected.
+type ProfileSelector struct {
+	Classes []string `json:"classes,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// NamePattern is a glob matched against the profile name, e.g. "sorc*"
+	NamePattern string `json:"namePattern,omitempty"`
+}
+
+func (s ProfileSelector) Matches(name string, cfg *CharacterCfg) (bool, error) {
+	if name == "template" {
+		return false, nil
+	}
+	if len(s.Classes) > 0 && !slices.Contains(s.Classes, cfg.Character.Class) {
+		return false, nil
+	}
+	if len(s.Tags) > 0 && !slices.ContainsFunc(s.Tags, func(tag string) bool { return slices.Contains(cfg.Tags, tag) }) {
+		return false, nil
+	}
+	if s.NamePattern != "" {
+		return filepath.Match(s.NamePattern, name)
+	}
+
+	return true, nil
+}
+
+// BulkPatch changes the settings of many profiles at once. The change is either a JSON merge patch (RFC 7396) using
+// the yaml names of the settings, or a single setting given by its path, e.g. health.chickenAt.
+type BulkPatch struct {
+	Selector   ProfileSelector        `json:"selector"`
+	MergePatch map[string]interface{} `json:"mergePatch,omitempty"`
+	Path       string                 `json:"path,omitempty"`
+	Value      interface{}            `json:"value,omitempty"`
+}
+
+// BulkProfileResult is the outcome of a bulk patch for a single profile
+type BulkProfileResult struct {
+	Supervisor string           `json:"supervisor"`
+	Changes    []ConfigChange   `json:"changes"`
+	Errors     ValidationErrors `json:"errors,omitempty"`
+}
+
+// BulkOperation is an applied bulk patch, the previous files of every changed profile are kept in its backup folder
+type BulkOperation struct {
+	ID           string              `json:"id"`
+	CreatedAt    time.Time           `json:"createdAt"`
+	Patch        BulkPatch           `json:"patch"`
+	Profiles     []BulkProfileResult `json:"profiles"`
+	RolledBackAt *time.Time          `json:"rolledBackAt,omitempty"`
+}
+
+// PreviewBulkPatch returns the changes the patch would make to every selected profile, along with the validation
+// errors it would cause. Profiles the patch doesn't change are left out. Nothing is written.
+func PreviewBulkPatch(patch BulkPatch) ([]BulkProfileResult, error) {
+	results, _, err := patchProfiles(patch)
+
+	return results, err
+}
+
+// ApplyBulkPatch saves the patched configs after backing up their current files, nothing is saved when the patch
+// leaves any profile with invalid settings. Configs are not reloaded, the caller has to do it.
+func ApplyBulkPatch(patch BulkPatch) (BulkOperation, error) {
+	results, patched, err := patchProfiles(patch)
+	if err != nil {
+		return BulkOperation{}, err
+	}
+
+	op := BulkOperation{CreatedAt: time.Now(), Patch: patch, Profiles: results}
+	op.ID = fmt.Sprintf("%s-%03d", op.CreatedAt.Format("20060102-150405"), op.CreatedAt.Nanosecond()/int(time.Millisecond))
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			return op, ErrInvalidBulkPatch
+		}
+	}
+
+	for _, result := range results {
+		if err = backupProfile(op.ID, result.Supervisor); err != nil {
+			return op, fmt.Errorf("error backing up %s: %w", result.Supervisor, err)
+		}
+	}
+	if err = saveBulkOperation(op); err != nil {
+		return op, err
+	}
+
+	for _, result := range results {
+		if err = writeSupervisorConfig(result.Supervisor, patched[result.Supervisor]); err != nil {
+			return op, fmt.Errorf("error saving %s, use the rollback of operation %s to restore the previous configs: %w", result.Supervisor, op.ID, err)
+		}
+	}
+
+	return op, nil
+}
+
+// BulkOperations returns the operations with a backup, the most recent first
+func BulkOperations() ([]BulkOperation, error) {
+	entries, err := os.ReadDir(getAbsPath(filepath.Join("config", BackupsDir)))
+	if errors.Is(err, os.ErrNotExist) {
+		return []BulkOperation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]BulkOperation, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		op, err := loadBulkOperation(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].CreatedAt.After(ops[j].CreatedAt) })
+
+	return ops, nil
+}
+
+// RollbackBulkOperation restores the files of every profile changed by the operation as they were before applying it.
+// Configs are not reloaded, the caller has to do it.
+func RollbackBulkOperation(id string) (BulkOperation, error) {
+	op, err := loadBulkOperation(id)
+	if err != nil {
+		return op, err
+	}
+	if op.RolledBackAt != nil {
+		return op, fmt.Errorf("operation %s was already rolled back at %s", id, op.RolledBackAt.Format(time.DateTime))
+	}
+
+	for _, result := range op.Profiles {
+		for _, file := range []string{"config.yaml", MulingConfigFile} {
+			backupPath := filepath.Join("config", BackupsDir, op.ID, result.Supervisor, file)
+			profilePath := filepath.Join("config", result.Supervisor, file)
+
+			content, err := os.ReadFile(backupPath)
+			if errors.Is(err, os.ErrNotExist) {
+				// The file didn't exist before the operation
+				if err = os.Remove(profilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return op, err
+				}
+				continue
+			}
+			if err != nil {
+				return op, err
+			}
+			if err = os.WriteFile(profilePath, content, 0644); err != nil {
+				return op, fmt.Errorf("error restoring %s: %w", profilePath, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	op.RolledBackAt = &now
+
+	return op, saveBulkOperation(op)
+}
+
+// patchProfiles applies the patch to a copy of the config of every selected profile, it returns the results and the
+// patched configs of the profiles with changes
+func patchProfiles(patch BulkPatch) ([]BulkProfileResult, map[string]*CharacterCfg, error) {
+	mergePatch := patch.MergePatch
+	if patch.Path != "" {
+		mergePatch = pathPatch(patch.Path, patch.Value)
+	}
+	if len(mergePatch) == 0 {
+		return nil, nil, ErrEmptyBulkPatch
+	}
+	if err := checkPatchPaths(reflect.ValueOf(CharacterCfg{}), mergePatch, ""); err != nil {
+		return nil, nil, err
+	}
+
+	characters := GetCharacters()
+	names := make([]string, 0, len(characters))
+	for name := range characters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	selected := 0
+	results := make([]BulkProfileResult, 0)
+	patched := make(map[string]*CharacterCfg)
+	for _, name := range names {
+		cfg := characters[name]
+		matches, err := patch.Selector.Matches(name, cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid name pattern: %w", err)
+		}
+		if !matches {
+			continue
+		}
+		selected++
+
+		raw, err := toRawConfig(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		applyMergePatch(raw, mergePatch)
+
+		newCfg := &CharacterCfg{}
+		if err = decodeRawConfig(raw, newCfg); err != nil {
+			return nil, nil, fmt.Errorf("error applying the patch to %s: %w", name, err)
+		}
+		newCfg.ConfigFolderName = cfg.ConfigFolderName
+		newCfg.ConfigVersion = CurrentConfigVersion
+		newCfg.Runtime = cfg.Runtime
+
+		diff := DiffCharacterCfg(name, cfg, newCfg)
+		if diff.Empty() {
+			continue
+		}
+
+		results = append(results, BulkProfileResult{Supervisor: name, Changes: diff.Changes, Errors: newCfg.Check()})
+		patched[name] = newCfg
+	}
+
+	if selected == 0 {
+		return nil, nil, ErrNoProfilesSelected
+	}
+
+	return results, patched, nil
+}
+
+// pathPatch turns a setting path and its value into the equivalent merge patch
+func pathPatch(path string, value interface{}) map[string]interface{} {
+	keys := strings.Split(path, ".")
+	patch := map[string]interface{}{keys[len(keys)-1]: value}
+	for i := len(keys) - 2; i >= 0; i-- {
+		patch = map[string]interface{}{keys[i]: patch}
+	}
+
+	return patch
+}
+
+// checkPatchPaths makes sure every setting of the patch exists, the yaml decoder would silently ignore them
+func checkPatchPaths(val reflect.Value, patch map[string]interface{}, path string) error {
+	for key, value := range patch {
+		field, found := fieldByPath(val, key)
+		if !found {
+			return fmt.Errorf("unknown setting %s", joinPath(path, key))
+		}
+		if nested, ok := value.(map[string]interface{}); ok && field.Kind() == reflect.Struct {
+			if err := checkPatchPaths(field, nested, joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyMergePatch applies a JSON merge patch (RFC 7396) to the raw content of a config
+func applyMergePatch(target map[string]interface{}, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			targetMap, ok := target[key].(map[string]interface{})
+			if !ok {
+				targetMap = make(map[string]interface{})
+				target[key] = targetMap
+			}
+			applyMergePatch(targetMap, nested)
+			continue
+		}
+
+		target[key] = value
+	}
+}
+
+func backupProfile(id, profileName string) error {
+	backupDir := filepath.Join("config", BackupsDir, id, profileName)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return err
+	}
+
+	for _, file := range []string{"config.yaml", MulingConfigFile} {
+		content, err := os.ReadFile(filepath.Join("config", profileName, file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(filepath.Join(backupDir, file), content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func saveBulkOperation(op BulkOperation) error {
+	content, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	opDir := filepath.Join("config", BackupsDir, op.ID)
+	if err = os.MkdirAll(opDir, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(opDir, bulkOperationFile), content, 0644)
+}
+
+func loadBulkOperation(id string) (BulkOperation, error) {
+	op := BulkOperation{}
+	if id == "" || id != filepath.Base(id) {
+		return op, fmt.Errorf("invalid operation id %q", id)
+	}
+
+	content, err := os.ReadFile(filepath.Join("config", BackupsDir, id, bulkOperationFile))
+	if err != nil {
+		return op, fmt.Errorf("error reading operation %s: %w", id, err)
+	}
+	if err = json.Unmarshal(content, &op); err != nil {
+		return op, fmt.Errorf("error reading operation %s: %w", id, err)
+	}
+
+	return op, nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   map[string]interface{}
+		patch    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "replace a value",
+			target:   map[string]interface{}{"maxGameLength": 1200, "realm": "europe"},
+			patch:    map[string]interface{}{"maxGameLength": 900},
+			expected: map[string]interface{}{"maxGameLength": 900, "realm": "europe"},
+		},
+		{
+			name:     "delete a key with null",
+			target:   map[string]interface{}{"maxGameLength": 1200, "tags": []interface{}{"baal"}},
+			patch:    map[string]interface{}{"tags": nil},
+			expected: map[string]interface{}{"maxGameLength": 1200},
+		},
+		{
+			name:     "delete a missing key",
+			target:   map[string]interface{}{"maxGameLength": 1200},
+			patch:    map[string]interface{}{"tags": nil},
+			expected: map[string]interface{}{"maxGameLength": 1200},
+		},
+		{
+			name:     "merge nested maps",
+			target:   map[string]interface{}{"health": map[string]interface{}{"chickenAt": 20, "healingPotionAt": 70}},
+			patch:    map[string]interface{}{"health": map[string]interface{}{"chickenAt": 30, "manaPotionAt": nil}},
+			expected: map[string]interface{}{"health": map[string]interface{}{"chickenAt": 30, "healingPotionAt": 70}},
+		},
+		{
+			name:     "create missing maps",
+			target:   map[string]interface{}{},
+			patch:    map[string]interface{}{"game": map[string]interface{}{"tristram": map[string]interface{}{"clearPortal": true}}},
+			expected: map[string]interface{}{"game": map[string]interface{}{"tristram": map[string]interface{}{"clearPortal": true}}},
+		},
+		{
+			name:     "replace a value by a map",
+			target:   map[string]interface{}{"health": 10},
+			patch:    map[string]interface{}{"health": map[string]interface{}{"chickenAt": 30}},
+			expected: map[string]interface{}{"health": map[string]interface{}{"chickenAt": 30}},
+		},
+		{
+			name:     "lists are replaced",
+			target:   map[string]interface{}{"game": map[string]interface{}{"runs": []interface{}{"countess", "andariel"}}},
+			patch:    map[string]interface{}{"game": map[string]interface{}{"runs": []interface{}{"pindleskin"}}},
+			expected: map[string]interface{}{"game": map[string]interface{}{"runs": []interface{}{"pindleskin"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		applyMergePatch(tt.target, tt.patch)
+		if !reflect.DeepEqual(tt.target, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.target)
+		}
+	}
+}
+
+func TestPathPatch(t *testing.T) {
+	tests := []struct {
+		path     string
+		value    interface{}
+		expected map[string]interface{}
+	}{
+		{"maxGameLength", 900, map[string]interface{}{"maxGameLength": 900}},
+		{"health.chickenAt", 30, map[string]interface{}{"health": map[string]interface{}{"chickenAt": 30}}},
+		{"game.tristram.clearPortal", nil, map[string]interface{}{"game": map[string]interface{}{"tristram": map[string]interface{}{"clearPortal": nil}}}},
+	}
+
+	for _, tt := range tests {
+		if patch := pathPatch(tt.path, tt.value); !reflect.DeepEqual(patch, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, patch)
+		}
+	}
+}
+
+func TestProfileSelectorMatches(t *testing.T) {
+	cfg := &CharacterCfg{Tags: []string{"baal", "ladder"}}
+	cfg.Character.Class = "sorceress"
+
+	tests := []struct {
+		name     string
+		selector ProfileSelector
+		profile  string
+		expected bool
+	}{
+		{"empty selector", ProfileSelector{}, "sorc1", true},
+		{"template is never selected", ProfileSelector{}, "template", false},
+		{"class", ProfileSelector{Classes: []string{"paladin", "sorceress"}}, "sorc1", true},
+		{"other class", ProfileSelector{Classes: []string{"paladin"}}, "sorc1", false},
+		{"any tag", ProfileSelector{Tags: []string{"cows", "baal"}}, "sorc1", true},
+		{"no tag", ProfileSelector{Tags: []string{"cows"}}, "sorc1", false},
+		{"name pattern", ProfileSelector{NamePattern: "sorc*"}, "sorc1", true},
+		{"other name", ProfileSelector{NamePattern: "pala*"}, "sorc1", false},
+		{"every criteria", ProfileSelector{Classes: []string{"sorceress"}, Tags: []string{"baal"}, NamePattern: "pala*"}, "sorc1", false},
+	}
+
+	for _, tt := range tests {
+		matches, err := tt.selector.Matches(tt.profile, cfg)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if matches != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, matches)
+		}
+	}
+
+	if _, err := (ProfileSelector{NamePattern: "sorc["}).Matches("sorc1", cfg); err == nil {
+		t.Errorf("Expected an error for an invalid name pattern")
+	}
+}
+
+// loadedCharacterCfg returns a valid config as if it was read from its file, so empty settings are decoded the same
+// way they are once patched
+func loadedCharacterCfg(t *testing.T, class string) *CharacterCfg {
+	t.Helper()
+
+	cfg := validCharacterCfg()
+	cfg.Character.Class = class
+	raw, err := toRawConfig(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded := &CharacterCfg{}
+	if err = decodeRawConfig(raw, loaded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded.ConfigVersion = CurrentConfigVersion
+
+	return loaded
+}
+
+func TestPreviewBulkPatch(t *testing.T) {
+	previous := Characters
+	defer func() { Characters = previous }()
+
+	sorc := loadedCharacterCfg(t, "sorceress")
+	Characters = map[string]*CharacterCfg{"sorc1": sorc, "pala1": loadedCharacterCfg(t, "paladin"), "template": loadedCharacterCfg(t, "sorceress")}
+
+	results, err := PreviewBulkPatch(BulkPatch{Selector: ProfileSelector{Classes: []string{"sorceress"}}, Path: "health.chickenAt", Value: 15})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Supervisor != "sorc1" || len(results[0].Changes) != 1 || results[0].Changes[0].Path != "health.chickenAt" {
+		t.Fatalf("Expected only the chickenAt change of sorc1, got %+v", results)
+	}
+	if sorc.Health.ChickenAt != 20 {
+		t.Errorf("Expected the preview to leave the config untouched")
+	}
+
+	// Changes leaving invalid settings are reported
+	results, _ = PreviewBulkPatch(BulkPatch{Path: "health.chickenAt", Value: 90})
+	if len(results) != 2 || len(results[0].Errors) == 0 {
+		t.Errorf("Expected the validation errors of both profiles, got %+v", results)
+	}
+
+	if _, err = PreviewBulkPatch(BulkPatch{Selector: ProfileSelector{Classes: []string{"druid"}}, Path: "health.chickenAt", Value: 15}); !errors.Is(err, ErrNoProfilesSelected) {
+		t.Errorf("Expected ErrNoProfilesSelected, got %v", err)
+	}
+	if _, err = PreviewBulkPatch(BulkPatch{Path: "health.chikenAt", Value: 15}); err == nil {
+		t.Errorf("Expected an error for an unknown setting")
+	}
+	if _, err = PreviewBulkPatch(BulkPatch{}); !errors.Is(err, ErrEmptyBulkPatch) {
+		t.Errorf("Expected ErrEmptyBulkPatch, got %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -10,6 +9,9 @@ import (
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/runcond"
+	"github.com/hectorgimenez/koolo/internal/runpolicy"
+	"github.com/hectorgimenez/koolo/internal/threat"
 	"github.com/hectorgimenez/koolo/internal/utils"
 
 	"os"
@@ -67,6 +69,16 @@ type KooloCfg struct {
 		HighPingThreshold int  `yaml:"highPingThreshold"` // Ping threshold in ms (default 500-1000)
 		SustainedDuration int  `yaml:"sustainedDuration"` // Seconds high ping must persist (default 10-30)
 	} `yaml:"pingMonitor"`
+	Logging LoggingCfg `yaml:"logging"`
+}
+
+// LoggingCfg controls the log files of Koolo and the supervisors, zero values use the defaults of the log package
+type LoggingCfg struct {
+	JSON        bool `yaml:"json"`        // Write JSON lines instead of text
+	MaxSizeMB   int  `yaml:"maxSizeMB"`   // Start a new file when the current one reaches this size
+	RotateHours int  `yaml:"rotateHours"` // Start a new file when the current one is older than this
+	MaxFiles    int  `yaml:"maxFiles"`    // Files kept for Koolo and for every supervisor
+	MaxAgeDays  int  `yaml:"maxAgeDays"`  // Files older than this are deleted
 }
 
 type Day struct {
@@ -85,6 +97,7 @@ type TimeRange struct {
 }
 
 type CharacterCfg struct {
+	ConfigVersion        int    `yaml:"configVersion"`
 	MaxGameLength        int    `yaml:"maxGameLength"`
 	Username             string `yaml:"username"`
 	Password             string `yaml:"password"`
@@ -101,6 +114,13 @@ type CharacterCfg struct {
 
 	ConfigFolderName string `yaml:"-"`
 
+	// Extends is the profile this one inherits its settings from, only the overrides are saved in its config.yaml
+	Extends string `yaml:"extends,omitempty"`
+	// Include are the fragments from config/fragments merged over the extended profile, in order
+	Include []string `yaml:"include,omitempty"`
+	// Tags group profiles for bulk operations, e.g. "baal" or "leveling"
+	Tags []string `yaml:"tags,omitempty"`
+
 	// Packet casting options (disabled by default for safety)
 	PacketCasting struct {
 		UseForEntranceInteraction bool `yaml:"useForEntranceInteraction"`
@@ -109,23 +129,14 @@ type CharacterCfg struct {
 	} `yaml:"packetCasting"`
 
 	Scheduler Scheduler `yaml:"scheduler"`
-	Health    struct {
-		HealingPotionAt     int `yaml:"healingPotionAt"`
-		ManaPotionAt        int `yaml:"manaPotionAt"`
-		RejuvPotionAtLife   int `yaml:"rejuvPotionAtLife"`
-		RejuvPotionAtMana   int `yaml:"rejuvPotionAtMana"`
-		MercHealingPotionAt int `yaml:"mercHealingPotionAt"`
-		MercRejuvPotionAt   int `yaml:"mercRejuvPotionAt"`
-		ChickenAt           int `yaml:"chickenAt"`
-		TownChickenAt       int `yaml:"townChickenAt"`
-		MercChickenAt       int `yaml:"mercChickenAt"`
-	} `yaml:"health"`
+	Health    HealthCfg `yaml:"health"`
 	Inventory struct {
-		InventoryLock      [][]int     `yaml:"inventoryLock"`
-		BeltColumns        BeltColumns `yaml:"beltColumns"`
-		HealingPotionCount int         `yaml:"healingPotionCount"`
-		ManaPotionCount    int         `yaml:"manaPotionCount"`
-		RejuvPotionCount   int         `yaml:"rejuvPotionCount"`
+		InventoryLock      [][]int        `yaml:"inventoryLock"`
+		BeltColumns        BeltColumns    `yaml:"beltColumns"`
+		HealingPotionCount int            `yaml:"healingPotionCount"`
+		ManaPotionCount    int            `yaml:"manaPotionCount"`
+		RejuvPotionCount   int            `yaml:"rejuvPotionCount"`
+		PickupValues       PickupValueCfg `yaml:"pickupValues"`
 	} `yaml:"inventory"`
 	Character struct {
 		Class                        string `yaml:"class"`
@@ -133,11 +144,14 @@ type CharacterCfg struct {
 		StashToShared                bool   `yaml:"stashToShared"`
 		UseTeleport                  bool   `yaml:"useTeleport"`
 		ClearPathDist                int    `yaml:"clearPathDist"`
+		UseHierarchicalPathing       bool   `yaml:"useHierarchicalPathing"`
 		ShouldHireAct2MercFrozenAura bool   `yaml:"shouldHireAct2MercFrozenAura"`
 		UseExtraBuffs                bool   `yaml:"useExtraBuffs"`
 		BuffOnNewArea                bool   `yaml:"buffOnNewArea"`
 		BuffAfterWP                  bool   `yaml:"buffAfterWP"`
-		BerserkerBarb                struct {
+		// DamageTypes are the elements the character deals damage with, targets immune to them are attacked last
+		DamageTypes   []stat.Resist `yaml:"damageTypes"`
+		BerserkerBarb struct {
 			FindItemSwitch              bool `yaml:"find_item_switch"`
 			SkipPotionPickupInTravincal bool `yaml:"skip_potion_pickup_in_travincal"`
 			UseHowl                     bool `yaml:"use_howl"`
@@ -163,19 +177,21 @@ type CharacterCfg struct {
 	} `yaml:"character"`
 
 	Game struct {
-		MinGoldPickupThreshold int                   `yaml:"minGoldPickupThreshold"`
-		UseCainIdentify        bool                  `yaml:"useCainIdentify"`
-		InteractWithShrines    bool                  `yaml:"interactWithShrines"`
-		InteractWithChests     bool                  `yaml:"interactWithChests"`
-		StopLevelingAt         int                   `yaml:"stopLevelingAt"`
-		IsNonLadderChar        bool                  `yaml:"isNonLadderChar"`
-		ClearTPArea            bool                  `yaml:"clearTPArea"`
-		Difficulty             difficulty.Difficulty `yaml:"difficulty"`
-		RandomizeRuns          bool                  `yaml:"randomizeRuns"`
-		Runs                   []Run                 `yaml:"runs"`
-		CreateLobbyGames       bool                  `yaml:"createLobbyGames"`
-		PublicGameCounter      int                   `yaml:"-"`
-		MaxFailedMenuAttempts  int                   `yaml:"maxFailedMenuAttempts"`
+		MinGoldPickupThreshold int                      `yaml:"minGoldPickupThreshold"`
+		UseCainIdentify        bool                     `yaml:"useCainIdentify"`
+		InteractWithShrines    bool                     `yaml:"interactWithShrines"`
+		InteractWithChests     bool                     `yaml:"interactWithChests"`
+		StopLevelingAt         int                      `yaml:"stopLevelingAt"`
+		IsNonLadderChar        bool                     `yaml:"isNonLadderChar"`
+		ClearTPArea            bool                     `yaml:"clearTPArea"`
+		Difficulty             difficulty.Difficulty    `yaml:"difficulty"`
+		RandomizeRuns          bool                     `yaml:"randomizeRuns"`
+		Runs                   []Run                    `yaml:"runs"`
+		RunPolicies            map[Run]runpolicy.Policy `yaml:"runPolicies,omitempty"`
+		RunConditions          map[Run]runcond.Rule     `yaml:"runConditions,omitempty"`
+		CreateLobbyGames       bool                     `yaml:"createLobbyGames"`
+		PublicGameCounter      int                      `yaml:"-"`
+		MaxFailedMenuAttempts  int                      `yaml:"maxFailedMenuAttempts"`
 		Pindleskin             struct {
 			SkipOnImmunities []stat.Resist `yaml:"skipOnImmunities"`
 		} `yaml:"pindleskin"`
@@ -276,6 +292,7 @@ type CharacterCfg struct {
 			HellRequiredFireRes      int      `yaml:"hellRequiredFireRes"`
 			HellRequiredLightRes     int      `yaml:"hellRequiredLightRes"`
 			EnabledRunewordRecipes   []string `yaml:"enabledRunewordRecipes"`
+			Build                    string   `yaml:"build"`
 		} `yaml:"leveling"`
 		LevelingSequence struct {
 			SequenceFile string `yaml:"sequenceFile"`
@@ -309,20 +326,15 @@ type CharacterCfg struct {
 		Enabled bool        `yaml:"enabled"`
 		Items   []item.Name `yaml:"items"`
 	} `yaml:"gambling"`
-	Muling struct {
-		Enabled      bool     `yaml:"enabled"`
-		SwitchToMule string   `yaml:"switchToMule"`
-		ReturnTo     string   `yaml:"returnTo"`
-		MuleProfiles []string `yaml:"muleProfiles"`
-	} `yaml:"muling"`
-	MulingState struct {
-		CurrentMuleIndex int `yaml:"currentMuleIndex"`
-	} `yaml:"mulingState"`
+	// Muling is stored in its own file (muling.yaml), it's only read from config.yaml for older profiles
+	Muling      MulingCfg `yaml:"muling,omitempty"`
 	CubeRecipes struct {
 		Enabled              bool     `yaml:"enabled"`
 		EnabledRecipes       []string `yaml:"enabledRecipes"`
 		SkipPerfectAmethysts bool     `yaml:"skipPerfectAmethysts"`
 		SkipPerfectRubies    bool     `yaml:"skipPerfectRubies"`
+		// KeepQuotas is the amount of every rune or gem (by item name) that cube recipes will never consume
+		KeepQuotas map[string]int `yaml:"keepQuotas"`
 	} `yaml:"cubing"`
 	BackToTown struct {
 		NoHpPotions     bool `yaml:"noHpPotions"`
@@ -334,11 +346,27 @@ type CharacterCfg struct {
 		Rules     nip.Rules   `yaml:"-"`
 		TierRules []int       `yaml:"-"`
 		Drops     []data.Item `yaml:"-"`
+		// LevelingBuild is nil when no build file is found for the class
+		LevelingBuild *LevelingBuildConfig `yaml:"-"`
+		// LevelingBuildErr is why the build file could not be loaded, the class defaults are used instead
+		LevelingBuildErr error `yaml:"-"`
+		// ValueSources is the profile or fragment every setting comes from, by yaml path. Nil when the profile
+		// doesn't extend another one nor include fragments.
+		ValueSources map[string]string `yaml:"-"`
+		// FileVersion is the config version of the file on disk, older than ConfigVersion until the migration is saved
+		FileVersion int `yaml:"-"`
 	} `yaml:"-"`
 }
 
 type BeltColumns [4]string
 
+// PickupValueCfg scores ground items when the inventory is short on space, the most valuable ones are picked up first
+type PickupValueCfg struct {
+	Items         map[string]int `yaml:"items,omitempty"`     // Values by item name (e.g. BerRune), they take precedence over the quality values
+	Qualities     map[string]int `yaml:"qualities,omitempty"` // Values by quality (normal, magic, rare, set, unique...), empty uses the defaults
+	TownTripValue int            `yaml:"townTripValue"`       // Go back to town when an item worth at least this doesn't fit, 0 disables it
+}
+
 func GetCharacter(name string) (*CharacterCfg, bool) {
 	cfgMux.RLock()
 	defer cfgMux.RUnlock()
@@ -399,6 +427,24 @@ func Load() error {
 		return fmt.Errorf("error reading config %s: %w", kooloPath, err)
 	}
 
+	plainTextSecrets, err := resolveSecrets(kooloSecrets(Koolo))
+	if err != nil {
+		return fmt.Errorf("error reading %s secrets: %w", kooloPath, err)
+	}
+	if plainTextSecrets {
+		if err = writeKooloConfig(*Koolo); err != nil {
+			return err
+		}
+	}
+
+	if err = LoadCubeRecipes(); err != nil {
+		return err
+	}
+
+	if err = threat.Load(getAbsPath("config/threat_model.json")); err != nil {
+		return err
+	}
+
 	configDir := getAbsPath("config")
 	entries, err := os.ReadDir(configDir)
 	if err != nil {
@@ -406,27 +452,43 @@ func Load() error {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !entry.IsDir() || entry.Name() == FragmentsDir || entry.Name() == BackupsDir {
 			continue
 		}
 
 		charCfg := CharacterCfg{}
 
 		charConfigPath := getAbsPath(filepath.Join("config", entry.Name(), "config.yaml"))
-		r, err = os.Open(charConfigPath)
+		content, err := os.ReadFile(charConfigPath)
 		if err != nil {
 			return fmt.Errorf("error loading config.yaml: %w", err)
 		}
 
-		d := yaml.NewDecoder(r)
-		if err = d.Decode(&charCfg); err != nil {
-			_ = r.Close()
+		fileVersion, err := decodeCharacterCfg(entry.Name(), content, &charCfg)
+		if err != nil {
 			return fmt.Errorf("error reading %s character config: %w", charConfigPath, err)
 		}
-		_ = r.Close()
 
 		charCfg.ConfigFolderName = entry.Name()
 
+		if err = loadMulingConfig(entry.Name(), &charCfg); err != nil {
+			return err
+		}
+
+		plainTextSecrets, err := resolveSecrets(characterSecrets(&charCfg))
+		if err != nil {
+			return fmt.Errorf("error reading %s character config secrets: %w", charConfigPath, err)
+		}
+
+		// Files of an older version are only migrated in memory, MigrateConfigs saves them once at startup
+		charCfg.Runtime.FileVersion = fileVersion
+		if plainTextSecrets && fileVersion == charCfg.ConfigVersion && entry.Name() != "template" {
+			// Move the plain text secrets to the secret store, the file is only left with their references
+			if err = writeSupervisorConfig(entry.Name(), &charCfg); err != nil {
+				return fmt.Errorf("error saving %s character config secrets: %w", charConfigPath, err)
+			}
+		}
+
 		if charCfg.Game.MaxFailedMenuAttempts == 0 {
 			charCfg.Game.MaxFailedMenuAttempts = 10
 		}
@@ -450,7 +512,14 @@ func Load() error {
 
 		// Load the leveling pickit rules
 
-		if len(charCfg.Game.Runs) > 0 && charCfg.Game.Runs[0] == "leveling" || charCfg.Game.Runs[0] == "leveling_sequence" {
+		if len(charCfg.Game.Runs) > 0 && (charCfg.Game.Runs[0] == "leveling" || charCfg.Game.Runs[0] == "leveling_sequence") {
+			// An invalid build file doesn't prevent the other profiles from loading, the class defaults are used instead
+			levelingBuild, err := LoadLevelingBuild(&charCfg, entry.Name())
+			if err != nil {
+				charCfg.Runtime.LevelingBuildErr = err
+			}
+			charCfg.Runtime.LevelingBuild = levelingBuild
+
 			nips := getLevelingNipFiles(&charCfg, entry.Name())
 
 			for _, nipFile := range nips {
@@ -533,6 +602,19 @@ func ValidateAndSaveConfig(config KooloCfg) error {
 		return errors.New("D2RPath is not valid")
 	}
 
+	if err := writeKooloConfig(config); err != nil {
+		return err
+	}
+
+	return Load()
+}
+
+func writeKooloConfig(config KooloCfg) error {
+	// Secrets are only written as references, their values go to the secret store
+	if err := protectSecrets(kooloSecretScope, kooloSecrets(&config)); err != nil {
+		return err
+	}
+
 	text, err := yaml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("error parsing koolo config: %w", err)
@@ -543,23 +625,47 @@ func ValidateAndSaveConfig(config KooloCfg) error {
 		return fmt.Errorf("error writing koolo config: %w", err)
 	}
 
-	return Load()
+	return nil
 }
 
 func SaveSupervisorConfig(supervisorName string, config *CharacterCfg) error {
-	filePath := filepath.Join("config", supervisorName, "config.yaml")
-	d, err := yaml.Marshal(config)
+	config.ConfigVersion = CurrentConfigVersion
+	err := writeSupervisorConfig(supervisorName, config)
 	config.Validate()
 	if err != nil {
 		return err
 	}
 
+	return Load()
+}
+
+func writeSupervisorConfig(supervisorName string, config *CharacterCfg) error {
+	filePath := filepath.Join("config", supervisorName, "config.yaml")
+
+	// Muling settings have their own file, leave them out of config.yaml
+	mainCfg := *config
+	mainCfg.Muling = MulingCfg{}
+	var d []byte
+	var err error
+	if mainCfg.Extends != "" || len(mainCfg.Include) > 0 {
+		d, err = marshalOverrides(supervisorName, &mainCfg)
+	} else {
+		// Secrets are only written as references, their values go to the secret store
+		if err = protectSecrets(supervisorName, characterSecrets(&mainCfg)); err != nil {
+			return err
+		}
+		d, err = yaml.Marshal(&mainCfg)
+	}
+	if err != nil {
+		return err
+	}
+
 	err = os.WriteFile(filePath, d, 0644)
 	if err != nil {
 		return fmt.Errorf("error writing supervisor config: %w", err)
 	}
 
-	return Load()
+	return saveMulingConfig(supervisorName, config.Muling)
 }
 
 func (c *CharacterCfg) Validate() {
@@ -591,19 +697,13 @@ func getAbsPath(relPath string) string {
 func getLevelingNipFiles(charCfg *CharacterCfg, entryName string) []string {
 	var nips []string
 	levelingPickitPath := getAbsPath(filepath.Join("config", entryName, "pickit_leveling"))
-	levelingBuildPath := getAbsPath(filepath.Join("config", "template", "builds_leveling"))
 	levelingPickitTemplatePath := getAbsPath(filepath.Join("config", "template", "pickit_leveling"))
-	classBuildFile := filepath.Join(levelingBuildPath, charCfg.Character.Class+".json")
 
-	if jsonData, err := utils.GetJsonData(classBuildFile); err == nil {
-		var buildConfig LevelingBuildConfig
-		err = json.Unmarshal(jsonData, &buildConfig)
-		if err == nil {
-			for _, nip := range buildConfig.Nips {
-				nipPath, err := getNipFilePath(levelingPickitPath, levelingPickitTemplatePath, nip)
-				if err == nil {
-					nips = append(nips, nipPath)
-				}
+	if buildConfig := charCfg.Runtime.LevelingBuild; buildConfig != nil {
+		for _, nip := range buildConfig.Nips {
+			nipPath, err := getNipFilePath(levelingPickitPath, levelingPickitTemplatePath, nip)
+			if err == nil {
+				nips = append(nips, nipPath)
 			}
 		}
 	}
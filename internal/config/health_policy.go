@@ -0,0 +1,145 @@
+package config
+
+import (
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+)
+
+// HealthCfg are the life and mana thresholds, in percent. Policies change them in some areas or runs, and the danger
+// mode raises them while dangerous monsters are close.
+type HealthCfg struct {
+	HealingPotionAt     int `yaml:"healingPotionAt"`
+	ManaPotionAt        int `yaml:"manaPotionAt"`
+	RejuvPotionAtLife   int `yaml:"rejuvPotionAtLife"`
+	RejuvPotionAtMana   int `yaml:"rejuvPotionAtMana"`
+	MercHealingPotionAt int `yaml:"mercHealingPotionAt"`
+	MercRejuvPotionAt   int `yaml:"mercRejuvPotionAt"`
+	ChickenAt           int `yaml:"chickenAt"`
+	TownChickenAt       int `yaml:"townChickenAt"`
+	MercChickenAt       int `yaml:"mercChickenAt"`
+
+	Policies   []HealthPolicy `yaml:"policies,omitempty"`
+	DangerMode DangerModeCfg  `yaml:"dangerMode"`
+	Prediction PredictionCfg  `yaml:"prediction"`
+}
+
+// PredictionCfg drinks a rejuvenation potion or chickens before the life crosses the thresholds, when the damage
+// taken over the last Window (ms) would make it cross them within Horizon (ms)
+type PredictionCfg struct {
+	Enabled    bool `yaml:"enabled"`
+	Window     int  `yaml:"window"`
+	Horizon    int  `yaml:"horizon"`
+	MinSamples int  `yaml:"minSamples"`
+}
+
+// HealthOverride changes the thresholds that are set, the others keep their value
+type HealthOverride struct {
+	HealingPotionAt     *int `yaml:"healingPotionAt,omitempty"`
+	ManaPotionAt        *int `yaml:"manaPotionAt,omitempty"`
+	RejuvPotionAtLife   *int `yaml:"rejuvPotionAtLife,omitempty"`
+	RejuvPotionAtMana   *int `yaml:"rejuvPotionAtMana,omitempty"`
+	MercHealingPotionAt *int `yaml:"mercHealingPotionAt,omitempty"`
+	MercRejuvPotionAt   *int `yaml:"mercRejuvPotionAt,omitempty"`
+	ChickenAt           *int `yaml:"chickenAt,omitempty"`
+	MercChickenAt       *int `yaml:"mercChickenAt,omitempty"`
+}
+
+// HealthPolicy overrides the thresholds when every condition set matches: the area is one of Areas, the run is one
+// of Runs and the area terror zone status is TerrorZone. When many policies match, the last one wins.
+type HealthPolicy struct {
+	Name           string    `yaml:"name"`
+	Areas          []area.ID `yaml:"areas,omitempty"`
+	Runs           []Run     `yaml:"runs,omitempty"`
+	TerrorZone     *bool     `yaml:"terrorZone,omitempty"`
+	HealthOverride `yaml:",inline"`
+}
+
+// DangerModeCfg raises the thresholds while any of Monsters (npc IDs) is within Range of the player, thresholds are
+// never lowered by the danger mode
+type DangerModeCfg struct {
+	Enabled        bool     `yaml:"enabled"`
+	Monsters       []npc.ID `yaml:"monsters,omitempty"`
+	Range          int      `yaml:"range"`
+	HealthOverride `yaml:",inline"`
+}
+
+// HealthContext is where the character is, used to pick the health policies
+type HealthContext struct {
+	Area       area.ID
+	Run        Run
+	TerrorZone bool
+	// DangerNearby is set when a monster of the danger mode is within range
+	DangerNearby bool
+}
+
+func (p HealthPolicy) hasConditions() bool {
+	return len(p.Areas) > 0 || len(p.Runs) > 0 || p.TerrorZone != nil
+}
+
+func (p HealthPolicy) matches(hc HealthContext) bool {
+	if !p.hasConditions() {
+		return false
+	}
+	if len(p.Areas) > 0 && !slices.Contains(p.Areas, hc.Area) {
+		return false
+	}
+	if len(p.Runs) > 0 && !slices.Contains(p.Runs, hc.Run) {
+		return false
+	}
+
+	return p.TerrorZone == nil || *p.TerrorZone == hc.TerrorZone
+}
+
+// Resolve returns the thresholds to use in the given context: the matching policies are applied in order, then the
+// danger mode when active
+func (h HealthCfg) Resolve(hc HealthContext) HealthCfg {
+	resolved := h
+	for _, p := range h.Policies {
+		if p.matches(hc) {
+			resolved.apply(p.HealthOverride, false)
+		}
+	}
+	if h.DangerMode.Enabled && hc.DangerNearby {
+		resolved.apply(h.DangerMode.HealthOverride, true)
+	}
+
+	return resolved
+}
+
+// IsDangerMonster tells whether the monster triggers the danger mode at the given distance from the player
+func (h HealthCfg) IsDangerMonster(id npc.ID, distance int) bool {
+	return h.DangerMode.Enabled && distance <= h.DangerMode.Range && slices.Contains(h.DangerMode.Monsters, id)
+}
+
+func (h *HealthCfg) apply(o HealthOverride, onlyRaise bool) {
+	set := func(threshold *int, value *int) {
+		if value != nil && (!onlyRaise || *value > *threshold) {
+			*threshold = *value
+		}
+	}
+
+	set(&h.HealingPotionAt, o.HealingPotionAt)
+	set(&h.ManaPotionAt, o.ManaPotionAt)
+	set(&h.RejuvPotionAtLife, o.RejuvPotionAtLife)
+	set(&h.RejuvPotionAtMana, o.RejuvPotionAtMana)
+	set(&h.MercHealingPotionAt, o.MercHealingPotionAt)
+	set(&h.MercRejuvPotionAt, o.MercRejuvPotionAt)
+	set(&h.ChickenAt, o.ChickenAt)
+	set(&h.MercChickenAt, o.MercChickenAt)
+}
+
+// percentages returns the thresholds set by the override, keyed by their yaml name
+func (o HealthOverride) percentages() map[string]*int {
+	return map[string]*int{
+		"healingPotionAt":     o.HealingPotionAt,
+		"manaPotionAt":        o.ManaPotionAt,
+		"rejuvPotionAtLife":   o.RejuvPotionAtLife,
+		"rejuvPotionAtMana":   o.RejuvPotionAtMana,
+		"mercHealingPotionAt": o.MercHealingPotionAt,
+		"mercRejuvPotionAt":   o.MercRejuvPotionAt,
+		"chickenAt":           o.ChickenAt,
+		"mercChickenAt":       o.MercChickenAt,
+	}
+}
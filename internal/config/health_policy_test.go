@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+)
+
+func percent(v int) *int {
+	return &v
+}
+
+func testHealth() HealthCfg {
+	return HealthCfg{HealingPotionAt: 70, RejuvPotionAtLife: 40, ChickenAt: 20, MercChickenAt: 10}
+}
+
+func TestResolveWithoutPolicies(t *testing.T) {
+	h := testHealth()
+
+	if resolved := h.Resolve(HealthContext{Area: area.ID(108), Run: "chaos"}); resolved.ChickenAt != 20 || resolved.HealingPotionAt != 70 {
+		t.Errorf("Expected the base thresholds, got %+v", resolved)
+	}
+}
+
+func TestResolvePolicies(t *testing.T) {
+	tz := true
+	h := testHealth()
+	h.Policies = []HealthPolicy{
+		{Name: "no conditions", HealthOverride: HealthOverride{ChickenAt: percent(90)}},
+		{Name: "chaos", Runs: []Run{"chaos"}, HealthOverride: HealthOverride{ChickenAt: percent(35), HealingPotionAt: percent(85)}},
+		{Name: "chaos terror zone", Areas: []area.ID{108}, TerrorZone: &tz, HealthOverride: HealthOverride{ChickenAt: percent(50)}},
+	}
+
+	tests := []struct {
+		name    string
+		hc      HealthContext
+		chicken int
+		healing int
+	}{
+		{"other run", HealthContext{Area: area.ID(108), Run: "baal"}, 20, 70},
+		{"run", HealthContext{Area: area.ID(108), Run: "chaos"}, 35, 85},
+		{"last policy wins", HealthContext{Area: area.ID(108), Run: "chaos", TerrorZone: true}, 50, 85},
+		{"every condition must match", HealthContext{Area: area.ID(107), Run: "baal", TerrorZone: true}, 20, 70},
+	}
+
+	for _, tt := range tests {
+		resolved := h.Resolve(tt.hc)
+		if resolved.ChickenAt != tt.chicken || resolved.HealingPotionAt != tt.healing {
+			t.Errorf("%s: expected chicken %d and healing %d, got %d and %d", tt.name, tt.chicken, tt.healing, resolved.ChickenAt, resolved.HealingPotionAt)
+		}
+		if resolved.MercChickenAt != 10 {
+			t.Errorf("%s: expected the thresholds not overridden to keep their value, got %d", tt.name, resolved.MercChickenAt)
+		}
+	}
+	if h.ChickenAt != 20 {
+		t.Errorf("Expected the base thresholds to stay unchanged, got %d", h.ChickenAt)
+	}
+}
+
+func TestResolveDangerMode(t *testing.T) {
+	h := testHealth()
+	h.Policies = []HealthPolicy{{Runs: []Run{"chaos"}, HealthOverride: HealthOverride{ChickenAt: percent(60)}}}
+	h.DangerMode = DangerModeCfg{
+		Enabled:        true,
+		Monsters:       []npc.ID{npc.ID(641)},
+		Range:          15,
+		HealthOverride: HealthOverride{ChickenAt: percent(45), RejuvPotionAtLife: percent(60)},
+	}
+
+	if resolved := h.Resolve(HealthContext{Run: "baal"}); resolved.ChickenAt != 20 {
+		t.Errorf("Expected the danger mode to only apply with dangerous monsters nearby, got %d", resolved.ChickenAt)
+	}
+	if resolved := h.Resolve(HealthContext{Run: "baal", DangerNearby: true}); resolved.ChickenAt != 45 || resolved.RejuvPotionAtLife != 60 {
+		t.Errorf("Expected the danger mode to raise the thresholds, got %+v", resolved)
+	}
+	if resolved := h.Resolve(HealthContext{Run: "chaos", DangerNearby: true}); resolved.ChickenAt != 60 {
+		t.Errorf("Expected the danger mode to never lower a threshold, got %d", resolved.ChickenAt)
+	}
+
+	h.DangerMode.Enabled = false
+	if resolved := h.Resolve(HealthContext{Run: "baal", DangerNearby: true}); resolved.ChickenAt != 20 {
+		t.Errorf("Expected the disabled danger mode to be ignored, got %d", resolved.ChickenAt)
+	}
+}
+
+func TestIsDangerMonster(t *testing.T) {
+	h := testHealth()
+	h.DangerMode = DangerModeCfg{Enabled: true, Monsters: []npc.ID{npc.ID(641)}, Range: 15}
+
+	if !h.IsDangerMonster(npc.ID(641), 15) {
+		t.Errorf("Expected a dangerous monster within range to trigger the danger mode")
+	}
+	if h.IsDangerMonster(npc.ID(641), 16) || h.IsDangerMonster(npc.ID(19), 5) {
+		t.Errorf("Expected far or other monsters to be ignored")
+	}
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FragmentsDir is the folder inside config holding the fragments profiles can include, it's not a profile itself
+const FragmentsDir = "fragments"
+
+// FragmentSourcePrefix prefixes the fragment name in the value sources of a profile
+const FragmentSourcePrefix = "fragment:"
+
+// layerKeys belong to the file they are written in, they are never inherited from a base profile or a fragment
+var layerKeys = []string{"extends", "include", "configVersion"}
+
+func isLayered(raw map[string]interface{}) bool {
+	extends, _ := raw["extends"].(string)
+	include, _ := raw["include"].([]interface{})
+
+	return extends != "" || len(include) > 0
+}
+
+// resolveConfigLayers merges the raw content of a profile over the profile it extends and the fragments it includes,
+// in this order. Maps are merged key by key, any other value (lists included) replaces the inherited one. Along with
+// the merged content it returns the source of every value, by yaml path. Chain holds the profiles already being
+// resolved, to detect profiles extending each other.
+func resolveConfigLayers(profileName string, raw map[string]interface{}, chain []string) (map[string]interface{}, map[string]string, error) {
+	merged := make(map[string]interface{})
+	sources := make(map[string]string)
+
+	if base, _ := raw["extends"].(string); base != "" {
+		if err := checkExtendedProfile(profileName, base); err != nil {
+			return nil, nil, err
+		}
+		if slices.Contains(chain, base) {
+			return nil, nil, fmt.Errorf("profile %s can not extend %s, they extend each other: %s", profileName, base, strings.Join(append(chain, base), " -> "))
+		}
+
+		baseRaw, err := readRawConfig(filepath.Join("config", base, "config.yaml"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading profile %s extended by %s: %w", base, profileName, err)
+		}
+		if _, err = migrateRawConfig(baseRaw); err != nil {
+			return nil, nil, fmt.Errorf("error reading profile %s extended by %s: %w", base, profileName, err)
+		}
+		if merged, sources, err = resolveConfigLayers(base, baseRaw, append(chain, base)); err != nil {
+			return nil, nil, err
+		}
+		dropLayerKeys(merged, sources)
+	}
+
+	include, _ := raw["include"].([]interface{})
+	for _, fragment := range include {
+		fragmentName := fmt.Sprint(fragment)
+		if !isFileName(fragmentName) {
+			return nil, nil, fmt.Errorf("invalid fragment name %q included by %s", fragmentName, profileName)
+		}
+		fragmentRaw, err := readRawConfig(filepath.Join("config", FragmentsDir, fragmentName+".yaml"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading fragment %s included by %s: %w", fragmentName, profileName, err)
+		}
+		if _, err = migrateRawConfig(fragmentRaw); err != nil {
+			return nil, nil, fmt.Errorf("error reading fragment %s included by %s: %w", fragmentName, profileName, err)
+		}
+		dropLayerKeys(fragmentRaw, nil)
+		mergeConfigLayer(merged, fragmentRaw, "", FragmentSourcePrefix+fragmentName, sources)
+	}
+
+	mergeConfigLayer(merged, raw, "", profileName, sources)
+
+	return merged, sources, nil
+}
+
+// ValidateConfigLayers checks the profile a config extends and the fragments it includes exist, before saving them
+func ValidateConfigLayers(profileName, extends string, include []string) error {
+	if extends != "" {
+		if err := checkExtendedProfile(profileName, extends); err != nil {
+			return err
+		}
+	}
+	for _, fragmentName := range include {
+		if !isFileName(fragmentName) {
+			return fmt.Errorf("invalid fragment name %q included by %s", fragmentName, profileName)
+		}
+		if _, err := os.Stat(getAbsPath(filepath.Join("config", FragmentsDir, fragmentName+".yaml"))); err != nil {
+			return fmt.Errorf("fragment %s included by %s doesn't exist", fragmentName, profileName)
+		}
+	}
+
+	return nil
+}
+
+// checkExtendedProfile makes sure a profile only extends another existing profile, names are joined to the config
+// path so they can't be anything else than a folder of it
+func checkExtendedProfile(profileName, base string) error {
+	if !isFileName(base) || base == FragmentsDir || base == BackupsDir {
+		return fmt.Errorf("invalid profile name %q extended by %s", base, profileName)
+	}
+	if base == profileName {
+		return fmt.Errorf("profile %s can not extend itself", profileName)
+	}
+	if _, err := os.Stat(getAbsPath(filepath.Join("config", base, "config.yaml"))); err != nil {
+		return fmt.Errorf("profile %s extended by %s doesn't exist", base, profileName)
+	}
+
+	return nil
+}
+
+// isFileName tells whether the name is a single file or folder name, without any path element
+func isFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && name == filepath.Base(name)
+}
+
+// configLayerRefs returns the profile a config extends and the fragments it includes, as read from its file
+func configLayerRefs(cfg *CharacterCfg) map[string]interface{} {
+	include := make([]interface{}, 0, len(cfg.Include))
+	for _, fragment := range cfg.Include {
+		include = append(include, fragment)
+	}
+
+	return map[string]interface{}{"extends": cfg.Extends, "include": include}
+}
+
+func readRawConfig(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(getAbsPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]interface{})
+	if err = yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+func mergeConfigLayer(dst, src map[string]interface{}, path string, source string, sources map[string]string) {
+	for key, value := range src {
+		valuePath := joinPath(path, key)
+
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			dstMap, ok := dst[key].(map[string]interface{})
+			if !ok {
+				dstMap = make(map[string]interface{})
+				dst[key] = dstMap
+				clearSources(sources, valuePath)
+			}
+			mergeConfigLayer(dstMap, srcMap, valuePath, source, sources)
+			continue
+		}
+
+		dst[key] = value
+		clearSources(sources, valuePath)
+		sources[valuePath] = source
+	}
+}
+
+func clearSources(sources map[string]string, path string) {
+	for sourcePath := range sources {
+		if sourcePath == path || strings.HasPrefix(sourcePath, path+".") {
+			delete(sources, sourcePath)
+		}
+	}
+}
+
+func dropLayerKeys(raw map[string]interface{}, sources map[string]string) {
+	for _, key := range layerKeys {
+		delete(raw, key)
+		if sources != nil {
+			delete(sources, key)
+		}
+	}
+}
+
+// marshalOverrides encodes the settings of a profile that are different from the ones it inherits, along with the
+// profile it extends and the fragments it includes
+func marshalOverrides(profileName string, cfg *CharacterCfg) ([]byte, error) {
+	baseRaw, _, err := resolveConfigLayers(profileName, configLayerRefs(cfg), []string{profileName})
+	if err != nil {
+		return nil, err
+	}
+	dropLayerKeys(baseRaw, nil)
+	base := CharacterCfg{}
+	if err = decodeRawConfig(baseRaw, &base); err != nil {
+		return nil, err
+	}
+	base.Muling = MulingCfg{}
+
+	// Inherited secrets keep the reference of the profile they come from, so they are not saved as an override
+	baseSecrets := characterSecrets(&base)
+	for name, value := range characterSecrets(cfg) {
+		if baseRef := *baseSecrets[name]; IsSecretRef(baseRef) {
+			if inherited, err := secrets().Get(strings.TrimPrefix(baseRef, SecretRefPrefix)); err == nil && inherited == *value {
+				*value = baseRef
+			}
+		}
+	}
+	if err = protectSecrets(profileName, characterSecrets(cfg)); err != nil {
+		return nil, err
+	}
+
+	own, err := toRawConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	inherited, err := toRawConfig(&base)
+	if err != nil {
+		return nil, err
+	}
+	overrides := rawOverrides(own, inherited)
+	overrides["configVersion"] = cfg.ConfigVersion
+
+	// Keep the layer keys at the top of the file, the rest is sorted like the yaml encoder does with maps
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		if !slices.Contains(layerKeys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	keys = append(slices.Clone(layerKeys), keys...)
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range keys {
+		value, found := overrides[key]
+		if !found {
+			continue
+		}
+
+		keyNode, valueNode := &yaml.Node{}, &yaml.Node{}
+		keyNode.SetString(key)
+		if err = valueNode.Encode(value); err != nil {
+			return nil, err
+		}
+		doc.Content = append(doc.Content, keyNode, valueNode)
+	}
+
+	return yaml.Marshal(doc)
+}
+
+func toRawConfig(cfg *CharacterCfg) (map[string]interface{}, error) {
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]interface{})
+	if err = yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+// rawOverrides returns the values of own that are different from the inherited ones, maps are compared key by key
+func rawOverrides(own, inherited map[string]interface{}) map[string]interface{} {
+	overrides := make(map[string]interface{})
+	for key, value := range own {
+		ownMap, ownIsMap := value.(map[string]interface{})
+		inheritedMap, inheritedIsMap := inherited[key].(map[string]interface{})
+		if ownIsMap && inheritedIsMap {
+			if nested := rawOverrides(ownMap, inheritedMap); len(nested) > 0 {
+				overrides[key] = nested
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(value, inherited[key]) {
+			overrides[key] = value
+		}
+	}
+
+	return overrides
+}
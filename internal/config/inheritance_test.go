@@ -0,0 +1,31 @@
+package config
+
+import "testing"
+
+func TestValidateConfigLayersNames(t *testing.T) {
+	tests := []struct {
+		extends string
+		include []string
+	}{
+		{extends: "../sorc"},
+		{extends: "..\\sorc"},
+		{extends: "sorc/../../koolo"},
+		{extends: ".."},
+		{extends: FragmentsDir},
+		{extends: BackupsDir},
+		{extends: "pala"},
+		{include: []string{"../../koolo"}},
+		{include: []string{"chicken/../../secrets"}},
+		{include: []string{""}},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateConfigLayers("pala", tt.extends, tt.include); err == nil {
+			t.Errorf("Expected extends %q and include %q to be rejected", tt.extends, tt.include)
+		}
+	}
+
+	if err := ValidateConfigLayers("pala", "", nil); err != nil {
+		t.Errorf("Expected a config without layers to be valid, got %v", err)
+	}
+}
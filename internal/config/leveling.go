@@ -1,5 +1,270 @@
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data/skill"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/koolo/internal/utils"
+)
+
+// LevelingBuildConfig is the content of a builds_leveling/<build>.json file. Every section is optional, when it's
+// not defined the leveling character will use the allocation hardcoded for its class.
 type LevelingBuildConfig struct {
 	Nips []string `json:"nips"`
+	// StatPoints are target totals (including base stats), assigned in order
+	StatPoints []LevelingStatTarget `json:"statPoints,omitempty"`
+	// SkillPoints are the allocation orders, the first bracket matching the character level is used
+	SkillPoints []LevelingSkillBracket `json:"skillPoints,omitempty"`
+	// SkillsToBind are the skill bindings, the first bracket matching the character level is used
+	SkillsToBind []LevelingSkillBinding `json:"skillsToBind,omitempty"`
+	// RespecLevel is the level where the skills invested only in earlier brackets will be reset, 0 means never
+	RespecLevel int      `json:"respecLevel,omitempty"`
+	Runewords   []string `json:"runewords,omitempty"`
+}
+
+type LevelingStatTarget struct {
+	Stat   string `json:"stat"`
+	Points int    `json:"points"`
+}
+
+type LevelingSkillBracket struct {
+	MinLevel int                       `json:"minLevel"`
+	MaxLevel int                       `json:"maxLevel,omitempty"` // 0 means no upper limit
+	Skills   []LevelingSkillAllocation `json:"skills"`
+}
+
+type LevelingSkillAllocation struct {
+	Skill  string `json:"skill"`
+	Points int    `json:"points,omitempty"` // Defaults to 1
+}
+
+type LevelingSkillBinding struct {
+	MinLevel  int      `json:"minLevel"`
+	MaxLevel  int      `json:"maxLevel,omitempty"` // 0 means no upper limit
+	MainSkill string   `json:"mainSkill"`
+	Skills    []string `json:"skills"`
+}
+
+var levelingStats = map[string]stat.ID{
+	"strength":  stat.Strength,
+	"dexterity": stat.Dexterity,
+	"vitality":  stat.Vitality,
+	"energy":    stat.Energy,
+}
+
+func (b LevelingSkillBracket) Matches(level int) bool {
+	return level >= b.MinLevel && (b.MaxLevel == 0 || level <= b.MaxLevel)
+}
+
+func (b LevelingSkillBinding) Matches(level int) bool {
+	return level >= b.MinLevel && (b.MaxLevel == 0 || level <= b.MaxLevel)
+}
+
+// SkillSequence returns the skill allocation order for the given level, each skill is repeated once per point
+func (b *LevelingBuildConfig) SkillSequence(level int) ([]skill.ID, bool) {
+	for _, bracket := range b.SkillPoints {
+		if bracket.Matches(level) {
+			return bracket.sequence(), true
+		}
+	}
+
+	return nil, false
+}
+
+// Bindings returns the main skill and the skills to bind for the given level
+func (b *LevelingBuildConfig) Bindings(level int) (skill.ID, []skill.ID, bool) {
+	for _, binding := range b.SkillsToBind {
+		if !binding.Matches(level) {
+			continue
+		}
+
+		mainSkill, _ := ParseSkillName(binding.MainSkill)
+		skills := make([]skill.ID, 0, len(binding.Skills))
+		for _, name := range binding.Skills {
+			sk, _ := ParseSkillName(name)
+			skills = append(skills, sk)
+		}
+
+		return mainSkill, skills, true
+	}
+
+	return 0, nil, false
+}
+
+// ObsoleteSkills returns the skills that are only allocated by brackets ending before the respec level
+func (b *LevelingBuildConfig) ObsoleteSkills() []skill.ID {
+	if b.RespecLevel == 0 {
+		return nil
+	}
+
+	current, _ := b.SkillSequence(b.RespecLevel)
+	obsolete := make([]skill.ID, 0)
+	for _, bracket := range b.SkillPoints {
+		if bracket.MaxLevel == 0 || bracket.MaxLevel >= b.RespecLevel {
+			continue
+		}
+		for _, sk := range bracket.sequence() {
+			if !slices.Contains(current, sk) && !slices.Contains(obsolete, sk) {
+				obsolete = append(obsolete, sk)
+			}
+		}
+	}
+
+	return obsolete
+}
+
+func (b LevelingSkillBracket) sequence() []skill.ID {
+	sequence := make([]skill.ID, 0, len(b.Skills))
+	for _, allocation := range b.Skills {
+		sk, _ := ParseSkillName(allocation.Skill)
+		for range max(allocation.Points, 1) {
+			sequence = append(sequence, sk)
+		}
+	}
+
+	return sequence
+}
+
+func (b *LevelingBuildConfig) validate() error {
+	for _, target := range b.StatPoints {
+		if _, found := ParseLevelingStat(target.Stat); !found {
+			return fmt.Errorf("unknown stat %q, allowed values are strength, dexterity, vitality and energy", target.Stat)
+		}
+	}
+
+	for _, bracket := range b.SkillPoints {
+		for _, allocation := range bracket.Skills {
+			if _, found := ParseSkillName(allocation.Skill); !found {
+				return fmt.Errorf("unknown skill %q", allocation.Skill)
+			}
+		}
+	}
+
+	for _, binding := range b.SkillsToBind {
+		if _, found := ParseSkillName(binding.MainSkill); !found {
+			return fmt.Errorf("unknown main skill %q", binding.MainSkill)
+		}
+		for _, name := range binding.Skills {
+			if _, found := ParseSkillName(name); !found {
+				return fmt.Errorf("unknown skill to bind %q", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseLevelingStat returns the stat ID for one of the allocatable stats (strength, dexterity, vitality, energy)
+func ParseLevelingStat(name string) (stat.ID, bool) {
+	st, found := levelingStats[strings.ToLower(strings.TrimSpace(name))]
+	return st, found
+}
+
+// ParseSkillName returns the skill ID matching the given name, case, spaces and apostrophes are ignored
+// so "Blessed Hammer", "blessed hammer" and "BlessedHammer" are all valid.
+func ParseSkillName(name string) (skill.ID, bool) {
+	normalized := normalizeSkillName(name)
+	if normalized == "" {
+		return 0, false
+	}
+
+	for id, skillName := range skill.SkillNames {
+		if normalizeSkillName(skillName) == normalized {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+func normalizeSkillName(name string) string {
+	return strings.NewReplacer(" ", "", "'", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+// LoadLevelingBuild reads the leveling build for the given profile, the build name defaults to the class name.
+// Profile builds (config/<profile>/builds_leveling) take precedence over the template ones.
+func LoadLevelingBuild(charCfg *CharacterCfg, profileName string) (*LevelingBuildConfig, error) {
+	buildName := charCfg.Game.Leveling.Build
+	if buildName == "" {
+		buildName = charCfg.Character.Class
+	}
+
+	return ReadLevelingBuild(profileName, buildName)
+}
+
+// ReadLevelingBuild reads the given build by name, it returns nil without error when the build file doesn't exist.
+// Without profile only the template builds are read.
+func ReadLevelingBuild(profileName, buildName string) (*LevelingBuildConfig, error) {
+	if buildName == "" || buildName != filepath.Base(buildName) {
+		return nil, fmt.Errorf("invalid leveling build name %q", buildName)
+	}
+	if profileName != filepath.Base(profileName) {
+		return nil, fmt.Errorf("invalid profile name %q", profileName)
+	}
+
+	buildFile := ""
+	for _, dir := range levelingBuildDirs(profileName) {
+		candidate := filepath.Join(dir, buildName+".json")
+		if _, err := os.Stat(candidate); err == nil {
+			buildFile = candidate
+			break
+		}
+	}
+	if buildFile == "" {
+		return nil, nil
+	}
+
+	jsonData, err := utils.GetJsonData(buildFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading leveling build %s: %w", buildFile, err)
+	}
+
+	var buildConfig LevelingBuildConfig
+	if err = json.Unmarshal(jsonData, &buildConfig); err != nil {
+		return nil, fmt.Errorf("error parsing leveling build %s: %w", buildFile, err)
+	}
+
+	if err = buildConfig.validate(); err != nil {
+		return nil, fmt.Errorf("invalid leveling build %s: %w", buildFile, err)
+	}
+
+	return &buildConfig, nil
+}
+
+// ListLevelingBuilds returns the name of every leveling build available for the given profile
+func ListLevelingBuilds(profileName string) []string {
+	builds := make([]string, 0)
+	for _, dir := range levelingBuildDirs(profileName) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), ".json")
+			if !slices.Contains(builds, name) {
+				builds = append(builds, name)
+			}
+		}
+	}
+	slices.Sort(builds)
+
+	return builds
+}
+
+func levelingBuildDirs(profileName string) []string {
+	templateDir := getAbsPath(filepath.Join("config", "template", "builds_leveling"))
+	if profileName == "" {
+		return []string{templateDir}
+	}
+
+	return []string{getAbsPath(filepath.Join("config", profileName, "builds_leveling")), templateDir}
 }
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data/skill"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+)
+
+const (
+	levelingMaxLevel       = 99
+	levelingMaxSkillPoints = 20
+	statPointsPerLevel     = 5
+	skillPointsPerLevel    = 1
+	defaultNightmareLevel  = 41
+	defaultHellLevel       = 70
+)
+
+// skillRowRequiredLevel is the character level required to invest the first point in a skill, indexed by skill tree row
+var skillRowRequiredLevel = []int{1, 6, 12, 18, 24, 30}
+
+// levelingClassBaseStats are the starting strength, dexterity, vitality and energy of every class
+var levelingClassBaseStats = map[string][4]int{
+	"amazon":      {20, 25, 20, 15},
+	"assassin":    {20, 20, 20, 25},
+	"barbarian":   {30, 20, 25, 10},
+	"druid":       {15, 20, 25, 20},
+	"necromancer": {15, 25, 15, 25},
+	"paladin":     {25, 20, 25, 15},
+	"sorceress":   {10, 25, 10, 35},
+}
+
+var levelingSimulatedStats = []stat.ID{stat.Strength, stat.Dexterity, stat.Vitality, stat.Energy}
+
+type LevelingQuestReward struct {
+	Name        string `json:"name"`
+	Level       int    `json:"level"`
+	StatPoints  int    `json:"statPoints,omitempty"`
+	SkillPoints int    `json:"skillPoints,omitempty"`
+}
+
+type LevelingSimulation struct {
+	Class    string                    `json:"class"`
+	Levels   []LevelingSimulationLevel `json:"levels"`
+	Warnings []string                  `json:"warnings,omitempty"`
+}
+
+type LevelingSimulationLevel struct {
+	Level              int            `json:"level"`
+	Strength           int            `json:"strength"`
+	Dexterity          int            `json:"dexterity"`
+	Vitality           int            `json:"vitality"`
+	Energy             int            `json:"energy"`
+	UnspentStatPoints  int            `json:"unspentStatPoints"`
+	UnspentSkillPoints int            `json:"unspentSkillPoints"`
+	Skills             map[string]int `json:"skills"`
+	Events             []string       `json:"events,omitempty"`
+}
+
+// DefaultLevelingQuestRewards returns the quests giving stat or skill points (Den of Evil, Radament, Lam Esen's Tome
+// and Izual) for every difficulty, nightmareLevel and hellLevel are the levels where those difficulties are started.
+// Quests are expected to be completed at the same pace on every difficulty.
+func DefaultLevelingQuestRewards(nightmareLevel, hellLevel int) []LevelingQuestReward {
+	if nightmareLevel <= 1 {
+		nightmareLevel = defaultNightmareLevel
+	}
+	if hellLevel <= nightmareLevel {
+		hellLevel = defaultHellLevel
+	}
+
+	rewards := make([]LevelingQuestReward, 0, 12)
+	for _, d := range []struct {
+		name       string
+		startLevel int
+		span       int
+	}{
+		{"Normal", 1, nightmareLevel - 1},
+		{"Nightmare", nightmareLevel, hellLevel - nightmareLevel},
+		{"Hell", hellLevel, levelingMaxLevel - hellLevel},
+	} {
+		// Approximate position of each quest inside the difficulty, based on the act where it is done
+		at := func(progress float64) int {
+			return min(d.startLevel+int(float64(d.span)*progress), levelingMaxLevel)
+		}
+		rewards = append(rewards,
+			LevelingQuestReward{Name: d.name + " Den of Evil", Level: at(0.05), SkillPoints: 1},
+			LevelingQuestReward{Name: d.name + " Radament", Level: at(0.35), SkillPoints: 1},
+			LevelingQuestReward{Name: d.name + " Lam Esen's Tome", Level: at(0.55), StatPoints: 5},
+			LevelingQuestReward{Name: d.name + " Izual", Level: at(0.75), SkillPoints: 2},
+		)
+	}
+
+	return rewards
+}
+
+// SimulateLevelingBuild simulates the stat and skill allocation of a build from level 1 to 99, following the same
+// rules than the bot uses in game: stat targets are filled in order and skills are allocated following the sequence
+// of the bracket matching the current level. Skill level requirements (based on the skill tree row) are respected,
+// skill prerequisites are not checked.
+func SimulateLevelingBuild(class string, build *LevelingBuildConfig, rewards []LevelingQuestReward) (LevelingSimulation, error) {
+	baseStats, found := levelingClassBaseStats[levelingBaseClass(class)]
+	if !found {
+		return LevelingSimulation{}, fmt.Errorf("unknown class %q", class)
+	}
+	if build == nil {
+		return LevelingSimulation{}, fmt.Errorf("no build definition provided for class %q", class)
+	}
+	if err := build.validate(); err != nil {
+		return LevelingSimulation{}, err
+	}
+
+	simulation := LevelingSimulation{Class: class, Levels: make([]LevelingSimulationLevel, 0, levelingMaxLevel)}
+	if len(build.StatPoints) == 0 {
+		simulation.Warnings = append(simulation.Warnings, "build has no statPoints, the class defaults are hardcoded and can not be simulated")
+	}
+	if len(build.SkillPoints) == 0 {
+		simulation.Warnings = append(simulation.Warnings, "build has no skillPoints, the class defaults are hardcoded and can not be simulated")
+	}
+
+	stats := make(map[stat.ID]int, len(levelingSimulatedStats))
+	for i, st := range levelingSimulatedStats {
+		stats[st] = baseStats[i]
+	}
+	hardPoints := make(map[skill.ID]int)
+	statPoints, skillPoints := 0, 0
+	warned := make(map[skill.ID]bool)
+
+	for lvl := 1; lvl <= levelingMaxLevel; lvl++ {
+		events := make([]string, 0)
+		if lvl > 1 {
+			statPoints += statPointsPerLevel
+			skillPoints += skillPointsPerLevel
+		}
+
+		for _, reward := range rewards {
+			if reward.Level != lvl {
+				continue
+			}
+			statPoints += reward.StatPoints
+			skillPoints += reward.SkillPoints
+			events = append(events, reward.Name)
+		}
+
+		if build.RespecLevel > 0 && lvl == build.RespecLevel {
+			for i, st := range levelingSimulatedStats {
+				statPoints += stats[st] - baseStats[i]
+				stats[st] = baseStats[i]
+			}
+			for sk, points := range hardPoints {
+				skillPoints += points
+				delete(hardPoints, sk)
+			}
+			events = append(events, "Respec")
+		}
+
+		statPoints = simulateStatAllocation(build, stats, statPoints)
+		skillPoints = simulateSkillAllocation(build, lvl, hardPoints, skillPoints, func(sk skill.ID) {
+			if !warned[sk] {
+				warned[sk] = true
+				simulation.Warnings = append(simulation.Warnings, fmt.Sprintf("%s is not part of a skill tree, points can not be allocated", skill.SkillNames[sk]))
+			}
+		})
+
+		levelSkills := make(map[string]int, len(hardPoints))
+		for sk, points := range hardPoints {
+			levelSkills[skill.SkillNames[sk]] = points
+		}
+		simulation.Levels = append(simulation.Levels, LevelingSimulationLevel{
+			Level:              lvl,
+			Strength:           stats[stat.Strength],
+			Dexterity:          stats[stat.Dexterity],
+			Vitality:           stats[stat.Vitality],
+			Energy:             stats[stat.Energy],
+			UnspentStatPoints:  statPoints,
+			UnspentSkillPoints: skillPoints,
+			Skills:             levelSkills,
+			Events:             events,
+		})
+	}
+
+	return simulation, nil
+}
+
+// simulateStatAllocation mirrors action.EnsureStatPoints, it returns the remaining stat points
+func simulateStatAllocation(build *LevelingBuildConfig, stats map[stat.ID]int, remaining int) int {
+	for _, target := range build.StatPoints {
+		if remaining == 0 {
+			break
+		}
+		st, _ := ParseLevelingStat(target.Stat)
+		if stats[st] >= target.Points {
+			continue
+		}
+		spend := min(target.Points-stats[st], remaining)
+		stats[st] += spend
+		remaining -= spend
+	}
+
+	return remaining
+}
+
+// simulateSkillAllocation mirrors action.EnsureSkillPoints, it returns the remaining skill points. Allocation stops
+// at the first skill that can't be increased, as it happens in game when the click doesn't spend the point.
+func simulateSkillAllocation(build *LevelingBuildConfig, lvl int, hardPoints map[skill.ID]int, remaining int, notInTree func(skill.ID)) int {
+	sequence, found := build.SkillSequence(lvl)
+	if !found {
+		return remaining
+	}
+
+	targetLevels := make(map[skill.ID]int)
+	for _, sk := range sequence {
+		if remaining == 0 {
+			break
+		}
+		targetLevels[sk]++
+		if hardPoints[sk] >= targetLevels[sk] {
+			continue
+		}
+
+		sd, found := skill.Skills[sk]
+		if !found || sd.Desc().Row < 1 || sd.Desc().Row > len(skillRowRequiredLevel) {
+			notInTree(sk)
+			break
+		}
+		row := sd.Desc().Row
+
+		// Every point after the first one requires one more character level
+		if hardPoints[sk] >= levelingMaxSkillPoints || lvl < skillRowRequiredLevel[row-1]+hardPoints[sk] {
+			break
+		}
+		hardPoints[sk]++
+		remaining--
+	}
+
+	return remaining
+}
+
+// levelingBaseClass maps leveling class names (sorceress_leveling, amazon_leveling...) to the base class name
+func levelingBaseClass(class string) string {
+	class = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(class)), "_leveling")
+	if slices.Contains([]string{"barb", "berserker"}, class) {
+		return "barbarian"
+	}
+
+	return class
+}
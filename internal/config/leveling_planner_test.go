@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data/skill"
+)
+
+func readTemplateBuild(t *testing.T, name string) *LevelingBuildConfig {
+	t.Helper()
+
+	data, err := os.ReadFile("../../config/template/builds_leveling/" + name + ".json")
+	if err != nil {
+		t.Fatalf("Error reading build %s: %v", name, err)
+	}
+	var build LevelingBuildConfig
+	if err = json.Unmarshal(data, &build); err != nil {
+		t.Fatalf("Error parsing build %s: %v", name, err)
+	}
+
+	return &build
+}
+
+func TestSimulateLevelingBuild(t *testing.T) {
+	build := readTemplateBuild(t, "paladin_hammerdin")
+
+	simulation, err := SimulateLevelingBuild("paladin", build, DefaultLevelingQuestRewards(0, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(simulation.Warnings) > 0 {
+		t.Errorf("Expected no warnings, got %v", simulation.Warnings)
+	}
+	if len(simulation.Levels) != levelingMaxLevel {
+		t.Fatalf("Expected %d levels, got %d", levelingMaxLevel, len(simulation.Levels))
+	}
+
+	respec := simulation.Levels[build.RespecLevel-1]
+	if respec.Skills[skill.SkillNames[skill.HolyFire]] != 0 || respec.Skills[skill.SkillNames[skill.Zeal]] != 0 {
+		t.Errorf("Expected the Holy Fire skills to be reset at level %d, got %v", build.RespecLevel, respec.Skills)
+	}
+
+	// 98 levels and 15 points from Lam Esen's Tome, the strength and dexterity targets are filled and the rest goes to
+	// vitality
+	last := simulation.Levels[len(simulation.Levels)-1]
+	if last.Strength != 95 || last.Dexterity != 100 || last.Vitality != 380 || last.Energy != 15 || last.UnspentStatPoints != 0 {
+		t.Errorf("Unexpected stats at level 99: %+v", last)
+	}
+
+	expectedSkills := map[skill.ID]int{skill.BlessedHammer: 19, skill.Concentration: 20, skill.Vigor: 20, skill.BlessedAim: 20, skill.HolyShield: 19}
+	for sk, points := range expectedSkills {
+		if name := skill.SkillNames[sk]; last.Skills[name] != points {
+			t.Errorf("Expected %d points in %s at level 99, got %d", points, name, last.Skills[name])
+		}
+	}
+}
+
+func TestSimulateLevelingBuildErrors(t *testing.T) {
+	build := readTemplateBuild(t, "paladin_hammerdin")
+
+	if _, err := SimulateLevelingBuild("warlock", build, nil); err == nil {
+		t.Errorf("Expected an error for an unknown class")
+	}
+	if _, err := SimulateLevelingBuild("paladin", nil, nil); err == nil {
+		t.Errorf("Expected an error without build")
+	}
+
+	build.SkillPoints[0].Skills[0].Skill = "Fireball of Doom"
+	if _, err := SimulateLevelingBuild("paladin", build, nil); err == nil {
+		t.Errorf("Expected an error for an unknown skill")
+	}
+}
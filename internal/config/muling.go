@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MulingConfigFile holds the muling settings of a profile, they are kept out of config.yaml
+const MulingConfigFile = "muling.yaml"
+
+type MulingCfg struct {
+	Enabled      bool     `yaml:"enabled"`
+	SwitchToMule string   `yaml:"switchToMule"`
+	ReturnTo     string   `yaml:"returnTo"`
+	MuleProfiles []string `yaml:"muleProfiles"`
+}
+
+// loadMulingConfig reads the muling settings of the profile from its dedicated file. Profiles saved before the file
+// existed keep the settings read from config.yaml, they are moved to the dedicated file on the next save.
+func loadMulingConfig(profileName string, charCfg *CharacterCfg) error {
+	mulingPath := getAbsPath(filepath.Join("config", profileName, MulingConfigFile))
+	content, err := os.ReadFile(mulingPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", mulingPath, err)
+	}
+
+	mulingCfg := MulingCfg{}
+	if err = yaml.Unmarshal(content, &mulingCfg); err != nil {
+		return fmt.Errorf("error parsing %s: %w", mulingPath, err)
+	}
+	charCfg.Muling = mulingCfg
+
+	return nil
+}
+
+func saveMulingConfig(profileName string, mulingCfg MulingCfg) error {
+	d, err := yaml.Marshal(mulingCfg)
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(filepath.Join("config", profileName, MulingConfigFile), d, 0644); err != nil {
+		return fmt.Errorf("error writing muling config: %w", err)
+	}
+
+	return nil
+}
@@ -1,74 +1,256 @@
 package config
 
-var AvailableRecipes = []string{
-	// List out all the recipe names from cube_recipes.go
-	"Perfect Amethyst",
-	"Perfect Diamond",
-	"Perfect Emerald",
-	"Perfect Ruby",
-	"Perfect Sapphire",
-	"Perfect Topaz",
-	"Perfect Skull",
-	"Token of Absolution",
-	"Upgrade El",
-	"Upgrade Eld",
-	"Upgrade Tir",
-	"Upgrade Nef",
-	"Upgrade Eth",
-	"Upgrade Ith",
-	"Upgrade Tal",
-	"Upgrade Ral",
-	"Upgrade Ort",
-	"Upgrade Thul",
-	"Upgrade Amn",
-	"Upgrade Sol",
-	"Upgrade Shael",
-	"Upgrade Dol",
-	"Upgrade Hel",
-	"Upgrade Io",
-	"Upgrade Lum",
-	"Upgrade Ko",
-	"Upgrade Fal",
-	"Upgrade Lem",
-	"Upgrade Pul",
-	"Upgrade Um",
-	"Upgrade Mal",
-	"Upgrade Ist",
-	"Upgrade Gul",
-	"Upgrade Vex",
-	"Upgrade Ohm",
-	"Upgrade Lo",
-	"Upgrade Sur",
-	"Upgrade Ber",
-	"Upgrade Jah",
-	"Upgrade Cham",
-	"Add Sockets to Weapon",
-	"Add Sockets to Armor",
-	"Add Sockets to Helm",
-	"Add Sockets to Shield",
-	"Reroll GrandCharms",
-	"Caster Amulet",
-	"Caster Ring",
-	"Caster Belt",
-	"Caster Boots",
-	"Blood Amulet",
-	"Blood Ring",
-	"Blood Gloves",
-	"Blood Boots",
-	"Blood Belt",
-	"Blood Helm",
-	"Blood Armor",
-	"Blood Weapon",
-	"Safety Shield",
-	"Safety Armor",
-	"Safety Boots",
-	"Safety Gloves",
-	"Safety Belt",
-	"Safety Helm",
-	"Hitpower Gloves",
-	"Hitpower Boots",
-	"Hitpower Belt",
-	"Hitpower Helm",
-	"Hitpower Armor",
-	"Hitpower Shield",
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/utils"
+)
+
+// defaultCubeRecipes are the recipe definitions shipped with the bot, config/cube_recipes.json replaces them if present
+//
+//go:embed cube_recipes.json
+var defaultCubeRecipes []byte
+
+var (
+	// CubeRecipeDefinitions are the loaded cube recipes, in the order they are displayed and processed
+	CubeRecipeDefinitions []CubeRecipeDefinition
+	// AvailableRecipes are the names of CubeRecipeDefinitions, used by the settings UI
+	AvailableRecipes []string
+)
+
+// CubeRecipeDefinition describes a Horadric Cube recipe: the ingredients taken from the stash, the items that need
+// to be bought before transmuting (one of them, gambled as magic) and the items produced.
+type CubeRecipeDefinition struct {
+	Name        string           `json:"name"`
+	Ingredients []CubeIngredient `json:"ingredients"`
+	Purchase    []string         `json:"purchase,omitempty"`
+	Outputs     []CubeOutput     `json:"outputs,omitempty"`
+}
+
+// CubeIngredient matches one or more items of the recipe. Every set condition must match.
+type CubeIngredient struct {
+	// Name or Names match the item name, when both are empty the item is matched by Types
+	Name  string   `json:"name,omitempty"`
+	Names []string `json:"names,omitempty"`
+	// Types match the item type, see cubeIngredientTypes for the allowed values
+	Types   []string `json:"types,omitempty"`
+	Quality string   `json:"quality,omitempty"`
+	Count   int      `json:"count,omitempty"` // Defaults to 1
+	// MinLevelReq and MaxLevelReq limit the required level of the item, 0 means no limit
+	MinLevelReq int `json:"minLevelReq,omitempty"`
+	MaxLevelReq int `json:"maxLevelReq,omitempty"`
+	// Socketable only matches items without sockets that can have them
+	Socketable bool `json:"socketable,omitempty"`
+	// UnmatchedByPickit skips items matching any pickit rule, so we don't consume items we want to keep
+	UnmatchedByPickit bool `json:"unmatchedByPickit,omitempty"`
+}
+
+// CubeOutput is the item produced by the recipe. Named outputs can be used as ingredients by other recipes,
+// FromIngredient (1 based) and FromPurchase describe items modified by the recipe (sockets, crafting, rerolls).
+type CubeOutput struct {
+	Name           string `json:"name,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+	FromIngredient int    `json:"fromIngredient,omitempty"`
+	FromPurchase   bool   `json:"fromPurchase,omitempty"`
+}
+
+var cubeIngredientTypes = map[string]string{
+	"weapon":       item.TypeWeapon,
+	"axe":          item.TypeAxe,
+	"sword":        item.TypeSword,
+	"spear":        item.TypeSpear,
+	"polearm":      item.TypePolearm,
+	"mace":         item.TypeMace,
+	"bow":          item.TypeBow,
+	"wand":         item.TypeWand,
+	"staff":        item.TypeStaff,
+	"scepter":      item.TypeScepter,
+	"club":         item.TypeClub,
+	"hammer":       item.TypeHammer,
+	"knife":        item.TypeKnife,
+	"crossbow":     item.TypeCrossbow,
+	"handtohand":   item.TypeHandtoHand,
+	"handtohand2":  item.TypeHandtoHand2,
+	"orb":          item.TypeOrb,
+	"amazonbow":    item.TypeAmazonBow,
+	"amazonspear":  item.TypeAmazonSpear,
+	"armor":        item.TypeArmor,
+	"helm":         item.TypeHelm,
+	"primalhelm":   item.TypePrimalHelm,
+	"pelt":         item.TypePelt,
+	"circlet":      item.TypeCirclet,
+	"shield":       item.TypeShield,
+	"auricshields": item.TypeAuricShields,
+	"voodooheads":  item.TypeVoodooHeads,
+}
+
+var cubeQualities = map[string]item.Quality{
+	"normal":   item.QualityNormal,
+	"superior": item.QualitySuperior,
+	"magic":    item.QualityMagic,
+	"set":      item.QualitySet,
+	"rare":     item.QualityRare,
+	"unique":   item.QualityUnique,
+	"crafted":  item.QualityCrafted,
+}
+
+// LoadCubeRecipes reads config/cube_recipes.json when it exists, otherwise the embedded definitions are used
+func LoadCubeRecipes() error {
+	recipesPath := getAbsPath("config/cube_recipes.json")
+	if _, err := os.Stat(recipesPath); errors.Is(err, os.ErrNotExist) {
+		recipes, err := parseCubeRecipes(defaultCubeRecipes)
+		if err != nil {
+			return fmt.Errorf("error loading embedded cube recipes: %w", err)
+		}
+		setCubeRecipes(recipes)
+		return nil
+	}
+
+	jsonData, err := utils.GetJsonData(recipesPath)
+	if err != nil {
+		return fmt.Errorf("error reading cube recipes %s: %w", recipesPath, err)
+	}
+
+	recipes, err := parseCubeRecipes(jsonData)
+	if err != nil {
+		return fmt.Errorf("error loading cube recipes %s: %w", recipesPath, err)
+	}
+	setCubeRecipes(recipes)
+
+	return nil
+}
+
+// GetCubeRecipe returns the recipe definition with the given name
+func GetCubeRecipe(name string) (CubeRecipeDefinition, bool) {
+	for _, recipe := range CubeRecipeDefinitions {
+		if recipe.Name == name {
+			return recipe, true
+		}
+	}
+
+	return CubeRecipeDefinition{}, false
+}
+
+func setCubeRecipes(recipes []CubeRecipeDefinition) {
+	names := make([]string, 0, len(recipes))
+	for _, recipe := range recipes {
+		names = append(names, recipe.Name)
+	}
+	CubeRecipeDefinitions = recipes
+	AvailableRecipes = names
+}
+
+func parseCubeRecipes(jsonData []byte) ([]CubeRecipeDefinition, error) {
+	var recipes []CubeRecipeDefinition
+	if err := json.Unmarshal(jsonData, &recipes); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(recipes))
+	for _, recipe := range recipes {
+		if seen[recipe.Name] {
+			return nil, fmt.Errorf("duplicated recipe %q", recipe.Name)
+		}
+		seen[recipe.Name] = true
+
+		if err := recipe.validate(); err != nil {
+			return nil, fmt.Errorf("recipe %q: %w", recipe.Name, err)
+		}
+	}
+
+	return recipes, nil
+}
+
+func (r CubeRecipeDefinition) validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("recipe name is required")
+	}
+	if len(r.Ingredients) == 0 {
+		return errors.New("recipe has no ingredients")
+	}
+
+	for i, ingredient := range r.Ingredients {
+		if ingredient.Name == "" && len(ingredient.Names) == 0 && len(ingredient.Types) == 0 {
+			return fmt.Errorf("ingredient %d has no name, names or types", i+1)
+		}
+		for _, t := range ingredient.Types {
+			if _, found := cubeIngredientTypes[t]; !found {
+				return fmt.Errorf("ingredient %d has unknown type %q", i+1, t)
+			}
+		}
+		if _, found := ParseCubeQuality(ingredient.Quality); ingredient.Quality != "" && !found {
+			return fmt.Errorf("ingredient %d has unknown quality %q", i+1, ingredient.Quality)
+		}
+		if ingredient.Count < 0 {
+			return fmt.Errorf("ingredient %d has a negative count", i+1)
+		}
+	}
+
+	for _, output := range r.Outputs {
+		if output.FromIngredient < 0 || output.FromIngredient > len(r.Ingredients) {
+			return fmt.Errorf("output references unknown ingredient %d", output.FromIngredient)
+		}
+		if output.FromPurchase && len(r.Purchase) == 0 {
+			return errors.New("output references a purchase but the recipe doesn't purchase any item")
+		}
+		if _, found := ParseCubeQuality(output.Quality); output.Quality != "" && !found {
+			return fmt.Errorf("output has unknown quality %q", output.Quality)
+		}
+	}
+
+	return nil
+}
+
+// Uses returns true if any ingredient of the recipe is matched by the item name
+func (r CubeRecipeDefinition) Uses(itemName string) bool {
+	for _, ingredient := range r.Ingredients {
+		if ingredient.MatchesName(itemName) && (ingredient.Name != "" || len(ingredient.Names) > 0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesName returns true when the ingredient accepts the item name, ingredients matched by type accept any name
+func (i CubeIngredient) MatchesName(itemName string) bool {
+	if i.Name == "" && len(i.Names) == 0 {
+		return true
+	}
+	if i.Name == itemName {
+		return true
+	}
+	for _, name := range i.Names {
+		if name == itemName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ItemTypes returns the d2go item types matched by the ingredient
+func (i CubeIngredient) ItemTypes() []string {
+	types := make([]string, 0, len(i.Types))
+	for _, t := range i.Types {
+		types = append(types, cubeIngredientTypes[t])
+	}
+
+	return types
+}
+
+func (i CubeIngredient) Amount() int {
+	return max(i.Count, 1)
+}
+
+// ParseCubeQuality returns the item quality for the given name (normal, magic, rare...)
+func ParseCubeQuality(name string) (item.Quality, bool) {
+	q, found := cubeQualities[strings.ToLower(strings.TrimSpace(name))]
+	return q, found
 }
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestEmbeddedCubeRecipes(t *testing.T) {
+	recipes, err := parseCubeRecipes(defaultCubeRecipes)
+	if err != nil {
+		t.Fatalf("Invalid embedded cube recipes: %v", err)
+	}
+	if len(recipes) == 0 {
+		t.Fatalf("Expected embedded cube recipes")
+	}
+
+	setCubeRecipes(recipes)
+	if recipe, found := GetCubeRecipe("Upgrade El"); !found || !recipe.Uses("ElRune") {
+		t.Errorf("Expected the Upgrade El recipe to use El runes, got %+v", recipe)
+	}
+	if len(AvailableRecipes) != len(recipes) {
+		t.Errorf("Expected %d recipe names, got %d", len(recipes), len(AvailableRecipes))
+	}
+}
+
+func TestParseCubeRecipesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		recipes string
+	}{
+		{"invalid json", `[{"name": "Upgrade El"`},
+		{"duplicated", `[{"name": "A", "ingredients": [{"name": "ElRune"}]}, {"name": "A", "ingredients": [{"name": "ElRune"}]}]`},
+		{"no name", `[{"ingredients": [{"name": "ElRune"}]}]`},
+		{"no ingredients", `[{"name": "A"}]`},
+		{"empty ingredient", `[{"name": "A", "ingredients": [{"count": 2}]}]`},
+		{"unknown type", `[{"name": "A", "ingredients": [{"types": ["boomerang"]}]}]`},
+		{"unknown quality", `[{"name": "A", "ingredients": [{"name": "Ring", "quality": "legendary"}]}]`},
+		{"negative count", `[{"name": "A", "ingredients": [{"name": "ElRune", "count": -1}]}]`},
+		{"unknown ingredient output", `[{"name": "A", "ingredients": [{"name": "ElRune"}], "outputs": [{"fromIngredient": 2}]}]`},
+		{"output without purchase", `[{"name": "A", "ingredients": [{"name": "ElRune"}], "outputs": [{"fromPurchase": true}]}]`},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseCubeRecipes([]byte(tt.recipes)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/nip"
+)
+
+// ChangeScope tells when a config change can be applied to a running supervisor
+type ChangeScope string
+
+const (
+	// ScopeNow changes are applied at the next safe point, between runs
+	ScopeNow ChangeScope = "now"
+	// ScopeNextGame changes are applied before the next game starts
+	ScopeNextGame ChangeScope = "next_game"
+	// ScopeRestart changes are only used when the supervisor is started again
+	ScopeRestart ChangeScope = "restart"
+)
+
+const (
+	// PickitRulesPath is the change reported when the pickit rules (and tier rules) are different after a reload
+	PickitRulesPath = "runtime.rules"
+	// LevelingBuildPath is the change reported when the leveling build file is different after a reload
+	LevelingBuildPath = "runtime.levelingBuild"
+)
+
+// changeScopes maps config paths (yaml names) to the scope of their changes, the longest matching prefix wins.
+// Paths not listed here are applied on the next game.
+var changeScopes = map[string]ChangeScope{
+	// Client and account settings are only used when the game client is started
+	"username":        ScopeRestart,
+	"password":        ScopeRestart,
+	"authMethod":      ScopeRestart,
+	"authToken":       ScopeRestart,
+	"realm":           ScopeRestart,
+	"characterName":   ScopeRestart,
+	"commandLineArgs": ScopeRestart,
+	"classicMode":     ScopeRestart,
+	"packetCasting":   ScopeRestart,
+	"character.class": ScopeRestart,
+
+	// Settings read every time they are used, nothing is built from them
+	"killD2OnStop":         ScopeNow,
+	"useCentralizedPickit": ScopeNow,
+	"scheduler":            ScopeNow,
+	"inventory":            ScopeNow,
+	"gambling":             ScopeNow,
+	"muling":               ScopeNow,
+	"cubing":               ScopeNow,
+	"backtotown":           ScopeNow,
+	PickitRulesPath:        ScopeNow,
+
+	// Read by the health goroutine during the whole game, it can't be changed between runs
+	"health": ScopeNextGame,
+}
+
+// sensitivePaths are never reported with their values
+var sensitivePaths = []string{"password", "authToken"}
+
+var scopeOrder = []ChangeScope{ScopeNow, ScopeNextGame, ScopeRestart}
+
+type ConfigChange struct {
+	Path  string      `json:"path"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+	Scope ChangeScope `json:"scope"`
+}
+
+// ConfigDiff is the list of changes between the running config of a supervisor and the reloaded one
+type ConfigDiff struct {
+	Supervisor string         `json:"supervisor"`
+	Changes    []ConfigChange `json:"changes"`
+}
+
+func (d ConfigDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Scope returns the most restrictive scope of all the changes
+func (d ConfigDiff) Scope() ChangeScope {
+	scope := ScopeNow
+	for _, change := range d.Changes {
+		if slices.Index(scopeOrder, change.Scope) > slices.Index(scopeOrder, scope) {
+			scope = change.Scope
+		}
+	}
+
+	return scope
+}
+
+// DiffCharacterCfg compares every setting of both configs by its yaml path. Runtime data is not compared except
+// for the pickit rules and the leveling build, which are reported as changes when their files changed.
+func DiffCharacterCfg(supervisor string, oldCfg, newCfg *CharacterCfg) ConfigDiff {
+	diff := ConfigDiff{Supervisor: supervisor, Changes: make([]ConfigChange, 0)}
+	diffValues("", reflect.ValueOf(oldCfg).Elem(), reflect.ValueOf(newCfg).Elem(), &diff.Changes)
+
+	if pickitRulesChanged(oldCfg.Runtime.Rules, newCfg.Runtime.Rules) {
+		diff.Changes = append(diff.Changes, ConfigChange{
+			Path:  PickitRulesPath,
+			Old:   len(oldCfg.Runtime.Rules),
+			New:   len(newCfg.Runtime.Rules),
+			Scope: changeScope(PickitRulesPath),
+		})
+	}
+	if !reflect.DeepEqual(oldCfg.Runtime.LevelingBuild, newCfg.Runtime.LevelingBuild) {
+		diff.Changes = append(diff.Changes, ConfigChange{Path: LevelingBuildPath, Scope: changeScope(LevelingBuildPath)})
+	}
+
+	return diff
+}
+
+// ApplyChanges copies the values of the given changes from src to dst, the rest of dst is left untouched.
+// Dropped items and the rest of the runtime data of dst are always kept.
+func ApplyChanges(dst, src *CharacterCfg, changes []ConfigChange) {
+	for _, change := range changes {
+		switch change.Path {
+		case PickitRulesPath:
+			dst.Runtime.Rules = src.Runtime.Rules
+			dst.Runtime.TierRules = src.Runtime.TierRules
+		case LevelingBuildPath:
+			dst.Runtime.LevelingBuild = src.Runtime.LevelingBuild
+		default:
+			dstField, found := fieldByPath(reflect.ValueOf(dst).Elem(), change.Path)
+			srcField, _ := fieldByPath(reflect.ValueOf(src).Elem(), change.Path)
+			if found && dstField.CanSet() {
+				dstField.Set(srcField)
+			}
+		}
+	}
+}
+
+func diffValues(path string, oldVal, newVal reflect.Value, changes *[]ConfigChange) {
+	if oldVal.Kind() == reflect.Struct && oldVal.Type() != reflect.TypeOf(time.Time{}) {
+		for i := 0; i < oldVal.NumField(); i++ {
+			field := oldVal.Type().Field(i)
+			name, skip := yamlFieldName(field)
+			if skip {
+				continue
+			}
+			diffValues(joinPath(path, name), oldVal.Field(i), newVal.Field(i), changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+		return
+	}
+
+	change := ConfigChange{Path: path, Old: oldVal.Interface(), New: newVal.Interface(), Scope: changeScope(path)}
+	if slices.Contains(sensitivePaths, path) {
+		change.Old, change.New = "***", "***"
+	}
+	*changes = append(*changes, change)
+}
+
+func fieldByPath(val reflect.Value, path string) (reflect.Value, bool) {
+	if path == "" {
+		return val, true
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		name, skip := yamlFieldName(val.Type().Field(i))
+		if skip {
+			continue
+		}
+		if name == "" {
+			// Inlined struct, its fields are at the same level
+			if field, found := fieldByPath(val.Field(i), path); found {
+				return field, true
+			}
+			continue
+		}
+		if name == path {
+			return val.Field(i), true
+		}
+		if rest, found := strings.CutPrefix(path, name+"."); found && val.Field(i).Kind() == reflect.Struct {
+			return fieldByPath(val.Field(i), rest)
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// yamlFieldName returns the name used by the yaml encoder, empty for inlined structs
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", true
+	}
+
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "", true
+	}
+	if strings.Contains(opts, "inline") {
+		return "", false
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" || name == "" {
+		return path + name
+	}
+
+	return path + "." + name
+}
+
+func changeScope(path string) ChangeScope {
+	scope, matched := ScopeNextGame, ""
+	for prefix, prefixScope := range changeScopes {
+		if (path == prefix || strings.HasPrefix(path, prefix+".")) && len(prefix) > len(matched) {
+			scope, matched = prefixScope, prefix
+		}
+	}
+
+	return scope
+}
+
+func pickitRulesChanged(oldRules, newRules nip.Rules) bool {
+	if len(oldRules) != len(newRules) {
+		return true
+	}
+	for i := range oldRules {
+		if oldRules[i].RawLine != newRules[i].RawLine || oldRules[i].Filename != newRules[i].Filename {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChangeScope(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected ChangeScope
+	}{
+		{"password", ScopeRestart},
+		{"character.class", ScopeRestart},
+		{"character.useTeleport", ScopeNextGame},
+		{"health.chickenAt", ScopeNextGame},
+		{"health", ScopeNextGame},
+		{"inventory.beltColumns", ScopeNow},
+		{"inventoryRules", ScopeNextGame},
+		{"game.runs", ScopeNextGame},
+		{PickitRulesPath, ScopeNow},
+		{LevelingBuildPath, ScopeNextGame},
+	}
+
+	for _, tt := range tests {
+		if scope := changeScope(tt.path); scope != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.path, tt.expected, scope)
+		}
+	}
+}
+
+func TestDiffCharacterCfg(t *testing.T) {
+	oldCfg := &CharacterCfg{MaxGameLength: 1200, Password: "old"}
+	oldCfg.Health.ChickenAt = 20
+	oldCfg.Game.Runs = []Run{"pindleskin"}
+
+	newCfg := &CharacterCfg{MaxGameLength: 1200, Password: "new"}
+	newCfg.Health.ChickenAt = 30
+	newCfg.Game.Runs = []Run{"pindleskin", "nihlathak"}
+
+	diff := DiffCharacterCfg("sorc", oldCfg, newCfg)
+	paths := make([]string, 0, len(diff.Changes))
+	for _, change := range diff.Changes {
+		paths = append(paths, change.Path)
+	}
+	slices.Sort(paths)
+	if expected := []string{"game.runs", "health.chickenAt", "password"}; !slices.Equal(paths, expected) {
+		t.Fatalf("Expected changes %v, got %v", expected, paths)
+	}
+
+	for _, change := range diff.Changes {
+		if change.Path == "password" && (change.Old != "***" || change.New != "***") {
+			t.Errorf("Expected the password values to be hidden, got %v and %v", change.Old, change.New)
+		}
+		if change.Path == "health.chickenAt" && (change.Old != 20 || change.New != 30 || change.Scope != ScopeNextGame) {
+			t.Errorf("Unexpected chickenAt change: %+v", change)
+		}
+	}
+	if diff.Scope() != ScopeRestart {
+		t.Errorf("Expected the diff to require a restart, got %s", diff.Scope())
+	}
+
+	if diff = DiffCharacterCfg("sorc", oldCfg, oldCfg); !diff.Empty() {
+		t.Errorf("Expected no changes, got %v", diff.Changes)
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	dst := &CharacterCfg{MaxGameLength: 1200, Password: "old"}
+	dst.Health.ChickenAt = 20
+	dst.Game.Runs = []Run{"pindleskin"}
+	dst.Runtime.TierRules = []int{1}
+
+	src := &CharacterCfg{MaxGameLength: 600, Password: "new"}
+	src.Health.ChickenAt = 30
+	src.Game.Runs = []Run{"nihlathak"}
+
+	// Only the now changes are applied, the rest stay pending
+	changes := slices.DeleteFunc(DiffCharacterCfg("sorc", dst, src).Changes, func(change ConfigChange) bool {
+		return change.Scope != ScopeNow
+	})
+	ApplyChanges(dst, src, changes)
+
+	if dst.Health.ChickenAt != 30 {
+		t.Errorf("Expected chickenAt to be applied, got %d", dst.Health.ChickenAt)
+	}
+	if dst.MaxGameLength != 1200 || dst.Password != "old" || !slices.Equal(dst.Game.Runs, []Run{"pindleskin"}) {
+		t.Errorf("Expected the changes of other scopes to be left untouched, got %d %s %v", dst.MaxGameLength, dst.Password, dst.Game.Runs)
+	}
+	if len(dst.Runtime.TierRules) != 1 {
+		t.Errorf("Expected the runtime data to be kept")
+	}
+
+	ApplyChanges(dst, src, []ConfigChange{{Path: "game.runs"}, {Path: "unknown.path"}})
+	if !slices.Equal(dst.Game.Runs, []Run{"nihlathak"}) {
+		t.Errorf("Expected the runs to be applied, got %v", dst.Game.Runs)
+	}
+}
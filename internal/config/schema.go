@@ -0,0 +1,398 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/koolo/internal/gamename"
+	"github.com/hectorgimenez/koolo/internal/runcond"
+	"github.com/hectorgimenez/koolo/internal/runpolicy"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the version of the character config format, files with an older configVersion are
+// migrated when loaded
+const CurrentConfigVersion = 1
+
+// damageTypes are the immunities the threat model can compare with the character damage
+var damageTypes = []stat.Resist{"cold", "fire", "light", "poison"}
+
+const (
+	inventoryLockRows    = 4
+	inventoryLockColumns = 10
+)
+
+// ValidationError is an invalid setting, Field is its yaml path as shown in the settings UI. Fatal errors would crash
+// the bot, the supervisor can't be started with them.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Fatal   bool   `json:"fatal,omitempty"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ByField returns the error message of every invalid field, the first error wins when a field has many
+func (e ValidationErrors) ByField() map[string]string {
+	fields := make(map[string]string, len(e))
+	for _, err := range e {
+		if _, found := fields[err.Field]; !found {
+			fields[err.Field] = err.Message
+		}
+	}
+
+	return fields
+}
+
+// Fatal returns the errors preventing the supervisor from starting
+func (e ValidationErrors) Fatal() ValidationErrors {
+	fatal := ValidationErrors{}
+	for _, err := range e {
+		if err.Fatal {
+			fatal = append(fatal, err)
+		}
+	}
+
+	return fatal
+}
+
+func (e *ValidationErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationErrors) addFatal(field, format string, args ...interface{}) {
+	*e = append(*e, ValidationError{Field: field, Message: fmt.Sprintf(format, args...), Fatal: true})
+}
+
+// Check validates the settings against the config schema, unlike Validate it never changes the config
+func (c *CharacterCfg) Check() ValidationErrors {
+	errs := ValidationErrors{}
+
+	if c.MaxGameLength < 0 {
+		errs.add("maxGameLength", "can not be negative")
+	}
+
+	for _, r := range c.Game.Runs {
+		if _, found := AvailableRuns[r]; !found {
+			errs.add("game.runs", "unknown run %q", r)
+		}
+	}
+
+	for _, r := range slices.Sorted(maps.Keys(c.Game.RunConditions)) {
+		field := fmt.Sprintf("game.runConditions.%s", r)
+		if _, found := AvailableRuns[r]; !found {
+			errs.add(field, "unknown run %q", r)
+		}
+		if _, err := runcond.Parse(c.Game.RunConditions[r].When); err != nil {
+			errs.add(field+".when", "invalid condition: %v", err)
+		}
+	}
+
+	for _, r := range slices.Sorted(maps.Keys(c.Game.RunPolicies)) {
+		p := c.Game.RunPolicies[r]
+		field := fmt.Sprintf("game.runPolicies.%s", r)
+		if _, found := AvailableRuns[r]; !found {
+			errs.add(field, "unknown run %q", r)
+		}
+		if p.MaxRetries < 0 || p.SkipAfter < 0 || p.Cooldown < 0 {
+			errs.add(field, "maxRetries, skipAfter and cooldown can not be negative")
+		}
+		if p.OnFailure != "" && p.OnFailure != runpolicy.OnFailureEndGame && p.OnFailure != runpolicy.OnFailureContinue {
+			errs.add(field+".onFailure", "must be %q or %q, got %q", runpolicy.OnFailureEndGame, runpolicy.OnFailureContinue, p.OnFailure)
+		}
+		for _, reason := range p.FailOn {
+			if !slices.Contains(runpolicy.FailureReasons, reason) {
+				errs.add(field+".failOn", "unknown reason %q, must be one of %q", reason, runpolicy.FailureReasons)
+			}
+		}
+	}
+
+	thresholds := map[string]int{
+		"health.healingPotionAt":     c.Health.HealingPotionAt,
+		"health.manaPotionAt":        c.Health.ManaPotionAt,
+		"health.rejuvPotionAtLife":   c.Health.RejuvPotionAtLife,
+		"health.rejuvPotionAtMana":   c.Health.RejuvPotionAtMana,
+		"health.mercHealingPotionAt": c.Health.MercHealingPotionAt,
+		"health.mercRejuvPotionAt":   c.Health.MercRejuvPotionAt,
+		"health.chickenAt":           c.Health.ChickenAt,
+		"health.townChickenAt":       c.Health.TownChickenAt,
+		"health.mercChickenAt":       c.Health.MercChickenAt,
+	}
+	for field, value := range thresholds {
+		if value < 0 || value > 100 {
+			errs.add(field, "must be a percentage between 0 and 100, got %d", value)
+		}
+	}
+
+	for i, p := range c.Health.Policies {
+		field := fmt.Sprintf("health.policies[%d]", i)
+		if !p.hasConditions() {
+			errs.add(field, "policy %q needs areas, runs or terrorZone to know where it applies", p.Name)
+		}
+		for _, id := range p.Areas {
+			if _, found := area.Areas[id]; !found {
+				errs.add(field+".areas", "unknown area %d", id)
+			}
+		}
+		for _, r := range p.Runs {
+			if _, found := AvailableRuns[r]; !found {
+				errs.add(field+".runs", "unknown run %q", r)
+			}
+		}
+		checkHealthOverride(&errs, field, p.HealthOverride)
+	}
+	if c.Health.DangerMode.Enabled {
+		if len(c.Health.DangerMode.Monsters) == 0 {
+			errs.add("health.dangerMode.monsters", "at least one monster is needed to enable the danger mode")
+		}
+		if c.Health.DangerMode.Range <= 0 {
+			errs.add("health.dangerMode.range", "must be positive, got %d", c.Health.DangerMode.Range)
+		}
+		checkHealthOverride(&errs, "health.dangerMode", c.Health.DangerMode.HealthOverride)
+	}
+	if p := c.Health.Prediction; p.Enabled {
+		if p.Window <= 0 {
+			errs.add("health.prediction.window", "must be positive, got %d", p.Window)
+		}
+		if p.Horizon <= 0 {
+			errs.add("health.prediction.horizon", "must be positive, got %d", p.Horizon)
+		}
+		if p.MinSamples < 2 {
+			errs.add("health.prediction.minSamples", "at least 2 samples are needed to compute the damage taken, got %d", p.MinSamples)
+		}
+	}
+
+	// Chicken thresholds above the potion ones would leave the game before drinking any potion
+	if c.Health.ChickenAt > 0 && c.Health.HealingPotionAt > 0 && c.Health.ChickenAt >= c.Health.HealingPotionAt {
+		errs.add("health.chickenAt", "must be lower than the healing potion threshold (%d%%)", c.Health.HealingPotionAt)
+	}
+	if c.Health.ChickenAt > 0 && c.Health.RejuvPotionAtLife > 0 && c.Health.ChickenAt >= c.Health.RejuvPotionAtLife {
+		errs.add("health.chickenAt", "must be lower than the rejuvenation potion threshold (%d%%)", c.Health.RejuvPotionAtLife)
+	}
+	if c.Health.MercChickenAt > 0 && c.Health.MercHealingPotionAt > 0 && c.Health.MercChickenAt >= c.Health.MercHealingPotionAt {
+		errs.add("health.mercChickenAt", "must be lower than the merc healing potion threshold (%d%%)", c.Health.MercHealingPotionAt)
+	}
+
+	// The inventory lock is indexed by inventory position, a smaller one crashes the bot
+	if len(c.Inventory.InventoryLock) != inventoryLockRows {
+		errs.addFatal("inventory.inventoryLock", "must have %d rows, got %d", inventoryLockRows, len(c.Inventory.InventoryLock))
+	}
+	for y, row := range c.Inventory.InventoryLock {
+		if len(row) != inventoryLockColumns {
+			errs.addFatal("inventory.inventoryLock", "row %d must have %d columns, got %d", y, inventoryLockColumns, len(row))
+		}
+		for x, cell := range row {
+			if cell != 0 && cell != 1 {
+				errs.add("inventory.inventoryLock", "cell %d,%d must be 0 (locked) or 1 (unlocked), got %d", y, x, cell)
+			}
+		}
+	}
+
+	for i, column := range c.Inventory.BeltColumns {
+		if column != "healing" && column != "mana" && column != "rejuvenation" {
+			errs.add("inventory.beltColumns", "column %d must be healing, mana or rejuvenation, got %q", i, column)
+		}
+	}
+
+	for name, value := range c.Inventory.PickupValues.Items {
+		if value < 0 {
+			errs.add("inventory.pickupValues.items", "value of %s must be positive, got %d", name, value)
+		}
+	}
+	for quality, value := range c.Inventory.PickupValues.Qualities {
+		if _, found := ParseCubeQuality(quality); !found {
+			errs.add("inventory.pickupValues.qualities", "unknown item quality %q", quality)
+		}
+		if value < 0 {
+			errs.add("inventory.pickupValues.qualities", "value of %s must be positive, got %d", quality, value)
+		}
+	}
+	if c.Inventory.PickupValues.TownTripValue < 0 {
+		errs.add("inventory.pickupValues.townTripValue", "must be positive, got %d", c.Inventory.PickupValues.TownTripValue)
+	}
+
+	if c.Extends != "" || len(c.Include) > 0 {
+		if _, _, err := resolveConfigLayers(c.ConfigFolderName, configLayerRefs(c), []string{c.ConfigFolderName}); err != nil {
+			errs.addFatal("extends", "%s", err.Error())
+		}
+	}
+
+	for _, damageType := range c.Character.DamageTypes {
+		if !slices.Contains(damageTypes, damageType) {
+			errs.add("character.damageTypes", "unknown damage type %q, allowed values are cold, fire, light and poison", damageType)
+		}
+	}
+
+	// Game name templates are only used when creating lobby games
+	if c.Game.CreateLobbyGames {
+		nameErr, passwordErr := gamename.Validate(c.Companion.GameNameTemplate, c.Companion.GamePassword, c.CharacterName)
+		if nameErr != nil {
+			errs.add("companion.gameNameTemplate", "%s", nameErr.Error())
+		}
+		if passwordErr != nil {
+			errs.add("companion.gamePassword", "%s", passwordErr.Error())
+		}
+	}
+
+	for _, id := range c.Game.TerrorZone.Areas {
+		if tz, found := area.Areas[id]; !found || !tz.CanBeTerrorized() {
+			errs.add("game.terror_zone.areas", "area %d can not be terrorized", id)
+		}
+	}
+
+	return errs
+}
+
+func checkHealthOverride(errs *ValidationErrors, field string, o HealthOverride) {
+	percentages := o.percentages()
+	for _, name := range slices.Sorted(maps.Keys(percentages)) {
+		if value := percentages[name]; value != nil && (*value < 0 || *value > 100) {
+			errs.add(field+"."+name, "must be a percentage between 0 and 100, got %d", *value)
+		}
+	}
+}
+
+// configMigration upgrades a character config file to the given version, working on the raw yaml content
+type configMigration struct {
+	version     int
+	description string
+	migrate     func(raw map[string]interface{})
+}
+
+var configMigrations = []configMigration{
+	{
+		version:     1,
+		description: "muling state is kept out of config.yaml, switchToMule becomes the first mule profile",
+		migrate: func(raw map[string]interface{}) {
+			delete(raw, "mulingState")
+
+			muling, ok := raw["muling"].(map[string]interface{})
+			if !ok {
+				return
+			}
+			switchToMule, _ := muling["switchToMule"].(string)
+			profiles, _ := muling["muleProfiles"].([]interface{})
+			if switchToMule != "" && len(profiles) == 0 {
+				muling["muleProfiles"] = []interface{}{switchToMule}
+			}
+		},
+	},
+}
+
+// decodeCharacterCfg decodes a character config file, migrating it first when it was written by an older version and
+// merging the profile it extends and its fragments. It returns the version of the file before the migration.
+func decodeCharacterCfg(profileName string, content []byte, charCfg *CharacterCfg) (int, error) {
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return 0, err
+	}
+
+	fileVersion, err := migrateRawConfig(raw)
+	if err != nil {
+		return fileVersion, err
+	}
+
+	merged, sources, err := resolveConfigLayers(profileName, raw, []string{profileName})
+	if err != nil {
+		return fileVersion, err
+	}
+	if err = decodeRawConfig(merged, charCfg); err != nil {
+		return fileVersion, err
+	}
+	if isLayered(raw) {
+		charCfg.Runtime.ValueSources = sources
+	}
+
+	return fileVersion, nil
+}
+
+// migrateRawConfig upgrades the raw content of a config file written by an older version, it returns the version of
+// the file before the migration
+func migrateRawConfig(raw map[string]interface{}) (int, error) {
+	fileVersion, _ := raw["configVersion"].(int)
+	if fileVersion > CurrentConfigVersion {
+		return fileVersion, fmt.Errorf("config version %d is newer than the supported one (%d), please update Koolo", fileVersion, CurrentConfigVersion)
+	}
+
+	for _, migration := range configMigrations {
+		if migration.version > fileVersion {
+			migration.migrate(raw)
+		}
+	}
+	raw["configVersion"] = CurrentConfigVersion
+
+	return fileVersion, nil
+}
+
+func decodeRawConfig(raw map[string]interface{}, charCfg *CharacterCfg) error {
+	content, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(content, charCfg)
+}
+
+// ConfigMigration is a character config file saved with the current version by MigrateConfigs
+type ConfigMigration struct {
+	Profile     string
+	Path        string
+	FromVersion int
+}
+
+// MigrateConfigs saves the character configs that Load migrated in memory, keeping a backup of every original file.
+// It is meant to be called once at startup, so loading the configs never writes them.
+func MigrateConfigs() ([]ConfigMigration, error) {
+	cfgMux.Lock()
+	defer cfgMux.Unlock()
+
+	migrations := make([]ConfigMigration, 0)
+	for _, name := range slices.Sorted(maps.Keys(Characters)) {
+		charCfg := Characters[name]
+		// The template keeps its comments for new profiles
+		if name == "template" || charCfg.Runtime.FileVersion == charCfg.ConfigVersion {
+			continue
+		}
+
+		charConfigPath := getAbsPath(filepath.Join("config", name, "config.yaml"))
+		if err := saveMigratedConfig(name, charConfigPath, charCfg.Runtime.FileVersion, charCfg); err != nil {
+			return migrations, fmt.Errorf("error saving migrated %s character config: %w", charConfigPath, err)
+		}
+		migrations = append(migrations, ConfigMigration{Profile: name, Path: charConfigPath, FromVersion: charCfg.Runtime.FileVersion})
+		charCfg.Runtime.FileVersion = charCfg.ConfigVersion
+	}
+
+	return migrations, nil
+}
+
+// saveMigratedConfig writes a migrated config, keeping a backup of the original file
+func saveMigratedConfig(profileName string, charConfigPath string, fromVersion int, charCfg *CharacterCfg) error {
+	original, err := os.ReadFile(charConfigPath)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(fmt.Sprintf("%s.v%d.bak", charConfigPath, fromVersion), original, 0644); err != nil {
+		return fmt.Errorf("error saving config backup: %w", err)
+	}
+
+	return writeSupervisorConfig(profileName, charCfg)
+}
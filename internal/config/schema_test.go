@@ -0,0 +1,108 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func validCharacterCfg() *CharacterCfg {
+	cfg := &CharacterCfg{MaxGameLength: 1200}
+	cfg.Game.Runs = []Run{CountessRun, AndarielRun}
+	cfg.Health = HealthCfg{HealingPotionAt: 70, RejuvPotionAtLife: 40, ChickenAt: 20, MercHealingPotionAt: 50, MercChickenAt: 10}
+	cfg.Inventory.InventoryLock = make([][]int, inventoryLockRows)
+	for y := range cfg.Inventory.InventoryLock {
+		cfg.Inventory.InventoryLock[y] = slices.Repeat([]int{1}, inventoryLockColumns)
+	}
+	cfg.Inventory.BeltColumns = BeltColumns{"healing", "healing", "mana", "rejuvenation"}
+
+	return cfg
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *CharacterCfg)
+		field  string
+		fatal  bool
+	}{
+		{"negative game length", func(cfg *CharacterCfg) { cfg.MaxGameLength = -1 }, "maxGameLength", false},
+		{"unknown run", func(cfg *CharacterCfg) { cfg.Game.Runs = append(cfg.Game.Runs, "diablo3") }, "game.runs", false},
+		{"threshold above 100", func(cfg *CharacterCfg) { cfg.Health.ManaPotionAt = 120 }, "health.manaPotionAt", false},
+		{"chicken above potions", func(cfg *CharacterCfg) { cfg.Health.ChickenAt = 80 }, "health.chickenAt", false},
+		{"unknown belt column", func(cfg *CharacterCfg) { cfg.Inventory.BeltColumns[0] = "stamina" }, "inventory.beltColumns", false},
+		{"unknown damage type", func(cfg *CharacterCfg) { cfg.Character.DamageTypes = append(cfg.Character.DamageTypes, "magic") }, "character.damageTypes", false},
+		{"missing inventory lock row", func(cfg *CharacterCfg) { cfg.Inventory.InventoryLock = cfg.Inventory.InventoryLock[:3] }, "inventory.inventoryLock", true},
+		{"short inventory lock row", func(cfg *CharacterCfg) { cfg.Inventory.InventoryLock[1] = []int{1, 1} }, "inventory.inventoryLock", true},
+	}
+
+	if errs := validCharacterCfg().Check(); len(errs) > 0 {
+		t.Fatalf("Expected a valid config, got %v", errs)
+	}
+
+	for _, tt := range tests {
+		cfg := validCharacterCfg()
+		tt.modify(cfg)
+
+		errs := cfg.Check()
+		if _, found := errs.ByField()[tt.field]; !found {
+			t.Errorf("%s: expected an error on %s, got %v", tt.name, tt.field, errs)
+			continue
+		}
+		if fatal := len(errs.Fatal()) > 0; fatal != tt.fatal {
+			t.Errorf("%s: expected fatal %v, got %v", tt.name, tt.fatal, fatal)
+		}
+	}
+}
+
+func TestMigrateRawConfig(t *testing.T) {
+	raw := map[string]interface{}{
+		"mulingState": map[string]interface{}{"active": true},
+		"muling":      map[string]interface{}{"switchToMule": "mule1"},
+	}
+	fileVersion, err := migrateRawConfig(raw)
+	if err != nil || fileVersion != 0 {
+		t.Fatalf("Expected version 0 to be migrated, got %d %v", fileVersion, err)
+	}
+	if _, found := raw["mulingState"]; found {
+		t.Errorf("Expected the muling state to be removed")
+	}
+	if profiles := raw["muling"].(map[string]interface{})["muleProfiles"]; !slices.Equal(profiles.([]interface{}), []interface{}{"mule1"}) {
+		t.Errorf("Expected switchToMule to become the first mule profile, got %v", profiles)
+	}
+	if raw["configVersion"] != CurrentConfigVersion {
+		t.Errorf("Expected the config version to be updated, got %v", raw["configVersion"])
+	}
+
+	// Migrations already applied are not applied again
+	raw = map[string]interface{}{
+		"configVersion": CurrentConfigVersion,
+		"muling":        map[string]interface{}{"switchToMule": "mule1"},
+	}
+	if fileVersion, err = migrateRawConfig(raw); err != nil || fileVersion != CurrentConfigVersion {
+		t.Errorf("Expected the current version, got %d %v", fileVersion, err)
+	}
+	if _, found := raw["muling"].(map[string]interface{})["muleProfiles"]; found {
+		t.Errorf("Expected the config not to be migrated again")
+	}
+
+	if _, err = migrateRawConfig(map[string]interface{}{"configVersion": CurrentConfigVersion + 1}); err == nil {
+		t.Errorf("Expected an error for a config written by a newer version")
+	}
+}
+
+func TestDecodeMigratedCharacterCfg(t *testing.T) {
+	content := []byte("maxGameLength: 900\nmuling:\n  switchToMule: mule1\n  muleProfiles: [mule2]\n")
+
+	var cfg CharacterCfg
+	fileVersion, err := decodeCharacterCfg("sorc", content, &cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The file version is kept so MigrateConfigs saves config.yaml when it changed
+	if fileVersion != 0 || cfg.ConfigVersion != CurrentConfigVersion {
+		t.Errorf("Expected the config to be migrated from version 0 to %d, got %d to %d", CurrentConfigVersion, fileVersion, cfg.ConfigVersion)
+	}
+	if cfg.MaxGameLength != 900 || !slices.Equal(cfg.Muling.MuleProfiles, []string{"mule2"}) {
+		t.Errorf("Expected the settings to be kept, got %d %v", cfg.MaxGameLength, cfg.Muling.MuleProfiles)
+	}
+}
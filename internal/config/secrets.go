@@ -0,0 +1,339 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/billgraziano/dpapi"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// SecretRefPrefix marks a config value as a reference to the secret store, e.g. secret://profile/password
+	SecretRefPrefix = "secret://"
+	// MasterPassphraseEnv is the environment variable holding the passphrase of the secret store. When it's not set
+	// a random passphrase is generated and kept encrypted for the current Windows user.
+	MasterPassphraseEnv = "KOOLO_MASTER_PASSPHRASE"
+
+	secretsFile       = "config/secrets.enc"
+	masterKeyFile     = "config/secrets.key"
+	kooloSecretScope  = "koolo"
+	secretsVersion    = 1
+	scryptN           = 1 << 15
+	scryptR           = 8
+	scryptP           = 1
+	secretKeyLength   = 32
+	secretSaltLength  = 16
+	masterKeyLength   = 32
+	secretsFileAccess = 0600
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretBackend stores the values of the secret references found in the config files, keys are the references
+// without the secret:// prefix
+type SecretBackend interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+var (
+	secretsMux    sync.Mutex
+	secretBackend SecretBackend
+)
+
+// SetSecretBackend replaces the store used to resolve secret references, by default secrets are kept in an encrypted
+// file in the config directory
+func SetSecretBackend(backend SecretBackend) {
+	secretsMux.Lock()
+	defer secretsMux.Unlock()
+	secretBackend = backend
+}
+
+func secrets() SecretBackend {
+	secretsMux.Lock()
+	defer secretsMux.Unlock()
+	if secretBackend == nil {
+		secretBackend = NewFileSecretBackend(getAbsPath(secretsFile), masterPassphrase)
+	}
+
+	return secretBackend
+}
+
+func SecretRef(scope, name string) string {
+	return SecretRefPrefix + scope + "/" + name
+}
+
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretRefPrefix)
+}
+
+// characterSecrets are the character settings kept in the secret store, by their name in the reference
+func characterSecrets(cfg *CharacterCfg) map[string]*string {
+	return map[string]*string{
+		"password":  &cfg.Password,
+		"authToken": &cfg.AuthToken,
+	}
+}
+
+func kooloSecrets(cfg *KooloCfg) map[string]*string {
+	return map[string]*string{
+		"discord.token":  &cfg.Discord.Token,
+		"telegram.token": &cfg.Telegram.Token,
+	}
+}
+
+// resolveSecrets replaces the secret references of the given fields by their values. It returns true when a field
+// still holds a plain text value, the file has to be saved again to move it to the secret store.
+func resolveSecrets(fields map[string]*string) (bool, error) {
+	plainText := false
+	for _, value := range fields {
+		if !IsSecretRef(*value) {
+			plainText = plainText || *value != ""
+			continue
+		}
+
+		ref := *value
+		resolved, err := secrets().Get(strings.TrimPrefix(ref, SecretRefPrefix))
+		if err != nil {
+			return false, fmt.Errorf("error resolving %s: %w", ref, err)
+		}
+		*value = resolved
+	}
+
+	return plainText, nil
+}
+
+// protectSecrets moves the values of the given fields to the secret store and replaces them by their references,
+// empty values are removed from the store
+func protectSecrets(scope string, fields map[string]*string) error {
+	for name, value := range fields {
+		if IsSecretRef(*value) {
+			continue
+		}
+
+		key := scope + "/" + name
+		if *value == "" {
+			if err := secrets().Delete(key); err != nil {
+				return fmt.Errorf("error removing secret %s: %w", key, err)
+			}
+			continue
+		}
+
+		if err := secrets().Set(key, *value); err != nil {
+			return fmt.Errorf("error storing secret %s: %w", key, err)
+		}
+		*value = SecretRef(scope, name)
+	}
+
+	return nil
+}
+
+// masterPassphrase returns the passphrase from the environment, or the one generated for the current Windows user
+func masterPassphrase() ([]byte, error) {
+	if passphrase := os.Getenv(MasterPassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	keyPath := getAbsPath(masterKeyFile)
+	encrypted, err := os.ReadFile(keyPath)
+	if err == nil {
+		return dpapi.DecryptBytes(encrypted)
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading %s: %w", keyPath, err)
+	}
+
+	passphrase := make([]byte, masterKeyLength)
+	if _, err = rand.Read(passphrase); err != nil {
+		return nil, err
+	}
+	if encrypted, err = dpapi.EncryptBytes(passphrase); err != nil {
+		return nil, fmt.Errorf("error protecting the master passphrase: %w", err)
+	}
+	if err = os.WriteFile(keyPath, encrypted, secretsFileAccess); err != nil {
+		return nil, fmt.Errorf("error writing %s: %w", keyPath, err)
+	}
+
+	return passphrase, nil
+}
+
+// encryptedSecrets is the content of the secrets file, Data is the json encoded map of secrets sealed with AES-GCM
+// using a key derived from the master passphrase with scrypt
+type encryptedSecrets struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// FileSecretBackend keeps every secret in a single encrypted file, it's decrypted the first time a secret is used
+type FileSecretBackend struct {
+	mu         sync.Mutex
+	path       string
+	passphrase func() ([]byte, error)
+	gcm        cipher.AEAD
+	salt       []byte
+	values     map[string]string
+}
+
+func NewFileSecretBackend(path string, passphrase func() ([]byte, error)) *FileSecretBackend {
+	return &FileSecretBackend{path: path, passphrase: passphrase}
+}
+
+func (b *FileSecretBackend) Get(key string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return "", err
+	}
+
+	value, found := b.values[key]
+	if !found {
+		return "", ErrSecretNotFound
+	}
+
+	return value, nil
+}
+
+func (b *FileSecretBackend) Set(key, value string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return err
+	}
+	if current, found := b.values[key]; found && current == value {
+		return nil
+	}
+
+	b.values[key] = value
+	return b.save()
+}
+
+func (b *FileSecretBackend) Delete(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return err
+	}
+	if _, found := b.values[key]; !found {
+		return nil
+	}
+
+	delete(b.values, key)
+	return b.save()
+}
+
+func (b *FileSecretBackend) load() error {
+	if b.values != nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, secretSaltLength)
+		if _, err = rand.Read(salt); err != nil {
+			return err
+		}
+		if err = b.unlock(salt); err != nil {
+			return err
+		}
+		b.values = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading secret store %s: %w", b.path, err)
+	}
+
+	stored := encryptedSecrets{}
+	if err = json.Unmarshal(content, &stored); err != nil {
+		return fmt.Errorf("error reading secret store %s: %w", b.path, err)
+	}
+	if stored.Version != secretsVersion {
+		return fmt.Errorf("unsupported secret store version %d", stored.Version)
+	}
+	if err = b.unlock(stored.Salt); err != nil {
+		return err
+	}
+
+	plain, err := b.gcm.Open(nil, stored.Nonce, stored.Data, nil)
+	if err != nil {
+		return errors.New("can not decrypt the secret store, wrong master passphrase or corrupted file")
+	}
+
+	values := make(map[string]string)
+	if err = json.Unmarshal(plain, &values); err != nil {
+		return fmt.Errorf("error decoding secret store: %w", err)
+	}
+	b.values = values
+
+	return nil
+}
+
+// unlock derives the encryption key from the master passphrase
+func (b *FileSecretBackend) unlock(salt []byte) error {
+	passphrase, err := b.passphrase()
+	if err != nil {
+		return fmt.Errorf("error getting the master passphrase: %w", err)
+	}
+
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, secretKeyLength)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	if b.gcm, err = cipher.NewGCM(block); err != nil {
+		return err
+	}
+	b.salt = salt
+
+	return nil
+}
+
+func (b *FileSecretBackend) save() error {
+	plain, err := json.Marshal(b.values)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, b.gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(encryptedSecrets{
+		Version: secretsVersion,
+		Salt:    b.salt,
+		Nonce:   nonce,
+		Data:    b.gcm.Seal(nil, nonce, plain, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, a partial write would lose every secret
+	tmpPath := b.path + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(tmpPath, content, secretsFileAccess); err != nil {
+		return fmt.Errorf("error writing secret store: %w", err)
+	}
+
+	return os.Rename(tmpPath, b.path)
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func staticPassphrase(passphrase string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return []byte(passphrase), nil
+	}
+}
+
+func TestFileSecretBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "secrets.enc")
+
+	backend := NewFileSecretBackend(path, staticPassphrase("correct horse"))
+	if _, err := backend.Get("sorc/password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound on an empty store, got %v", err)
+	}
+	if err := backend.Set("sorc/password", "hunter2"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := backend.Set("koolo/discord.token", "token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the store to be saved: %v", err)
+	}
+	if bytes.Contains(content, []byte("hunter2")) {
+		t.Errorf("Expected the secrets to be encrypted")
+	}
+
+	// A new backend reads the secrets saved by the previous one
+	reloaded := NewFileSecretBackend(path, staticPassphrase("correct horse"))
+	if value, err := reloaded.Get("sorc/password"); err != nil || value != "hunter2" {
+		t.Errorf("Expected the saved password, got %q %v", value, err)
+	}
+	if err = reloaded.Delete("koolo/discord.token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reloaded = NewFileSecretBackend(path, staticPassphrase("correct horse"))
+	if _, err = reloaded.Get("koolo/discord.token"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected the deleted secret to be gone, got %v", err)
+	}
+	if value, _ := reloaded.Get("sorc/password"); value != "hunter2" {
+		t.Errorf("Expected the other secrets to be kept, got %q", value)
+	}
+
+	wrong := NewFileSecretBackend(path, staticPassphrase("wrong"))
+	if _, err = wrong.Get("sorc/password"); err == nil {
+		t.Errorf("Expected an error with a wrong passphrase")
+	}
+}
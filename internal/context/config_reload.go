@@ -0,0 +1,61 @@
+package context
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+// pendingConfigReload keeps a reloaded config until its changes can be safely applied
+type pendingConfigReload struct {
+	mu      sync.Mutex
+	cfg     *config.CharacterCfg
+	changes []config.ConfigChange
+}
+
+// QueueConfigReload stores the reloaded config to be applied at the next safe points, replacing any pending one
+func (ctx *Context) QueueConfigReload(newCfg *config.CharacterCfg, diff config.ConfigDiff) {
+	ctx.configReload.mu.Lock()
+	defer ctx.configReload.mu.Unlock()
+
+	ctx.configReload.cfg = newCfg
+	ctx.configReload.changes = diff.Changes
+}
+
+// ApplyPendingConfig applies the pending changes of the given scopes. It must only be called from the bot loop at a
+// safe point: between runs for the settings only the bot loop reads (ScopeNow), before the game starts for the ones
+// the game goroutines (like the health checks) read concurrently (ScopeNextGame).
+func (ctx *Context) ApplyPendingConfig(scopes ...config.ChangeScope) {
+	ctx.configReload.mu.Lock()
+	defer ctx.configReload.mu.Unlock()
+
+	if ctx.configReload.cfg == nil {
+		return
+	}
+
+	applicable := make([]config.ConfigChange, 0)
+	pending := make([]config.ConfigChange, 0)
+	for _, change := range ctx.configReload.changes {
+		if slices.Contains(scopes, change.Scope) {
+			applicable = append(applicable, change)
+		} else {
+			pending = append(pending, change)
+		}
+	}
+	if len(applicable) == 0 {
+		return
+	}
+
+	config.ApplyChanges(ctx.CharacterCfg, ctx.configReload.cfg, applicable)
+	for _, change := range applicable {
+		ctx.Logger.Info("Config change applied", slog.String("path", change.Path), slog.String("scope", string(change.Scope)))
+	}
+
+	ctx.configReload.changes = pending
+	// Only restart changes left, they are kept to be reported but never applied to the running config
+	if !slices.ContainsFunc(pending, func(change config.ConfigChange) bool { return change.Scope != config.ScopeRestart }) {
+		ctx.configReload.cfg = nil
+	}
+}
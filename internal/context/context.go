@@ -1,6 +1,7 @@
 package context
 
 import (
+	"fmt"
 	"log/slog"
 	"runtime"
 	"strconv"
@@ -61,10 +62,13 @@ type Context struct {
 	StopSupervisorFn     StopFunc
 	CleanStopRequested   bool
 	RestartWithCharacter string
+	ReturnToCharacter    string // Set when this character was started by a character switch expecting to come back
 	PacketSender         *game.PacketSender
 	IsLevelingCharacter  *bool
 	ManualModeActive     bool // Manual play mode: stops after character selection
 	LastPortalTick       time.Time // NEW FIELD: Tracks last portal creation for spam prevention
+	Health               *ClientHealth
+	configReload         pendingConfigReload
 }
 
 type Debug struct {
@@ -87,12 +91,23 @@ type CurrentGameHelper struct {
 	SwitchToCharacter string
 	// Used to store the original character name when muling, so we can switch back.
 	OriginalCharacter string
-	CurrentMuleIndex  int
 	ShouldCheckStash  bool
 	StashFull         bool
 	mutex             sync.Mutex
 }
 
+// SwitchCharacter stops the supervisor and asks the manager to start the next character once it's fully stopped.
+// returnTo is the character the next one has to switch back to, empty if the chain ends there.
+func (ctx *Context) SwitchCharacter(nextCharacter, returnTo string, reason event.CharacterSwitchReason) {
+	ctx.Logger.Info("Switching character", "from", ctx.Name, "to", nextCharacter, "returnTo", returnTo, "reason", reason)
+	ctx.CurrentGame.SwitchToCharacter = nextCharacter
+	ctx.RestartWithCharacter = nextCharacter
+	ctx.CleanStopRequested = true
+
+	event.Send(event.CharacterSwitch(event.Text(ctx.Name, fmt.Sprintf("Switching character to %s (%s)", nextCharacter, reason)), ctx.Name, nextCharacter, returnTo, reason))
+	ctx.StopSupervisor()
+}
+
 func (ctx *Context) StopSupervisor() {
 	if ctx.StopSupervisorFn != nil {
 		ctx.Logger.Info("Game logic requested supervisor stop.", "source", "context")
@@ -119,6 +134,7 @@ func NewContext(name string) *Status {
 		SkillPointIndex:  0,
 		ForceAttack:      false,
 		ManualModeActive: false, // Explicitly initialize to false
+		Health:           NewClientHealth(),
 	}
 	ctx.AttachRoutine(PriorityNormal)
 
@@ -173,6 +189,7 @@ func (ctx *Context) RefreshGameData() {
 		ctx.IsLevelingCharacter = &isLevelingCharacter
 	}
 	ctx.Data.IsLevelingCharacter = *ctx.IsLevelingCharacter
+	ctx.Health.MarkRefreshed()
 
 }
 
@@ -0,0 +1,61 @@
+package context
+
+import (
+	"sync"
+	"time"
+)
+
+// ClientHealth keeps the signals used by the health monitor to detect a frozen client
+type ClientHealth struct {
+	mu             sync.RWMutex
+	lastRefresh    time.Time
+	inGame         bool
+	outOfGameSince time.Time
+}
+
+func NewClientHealth() *ClientHealth {
+	return &ClientHealth{outOfGameSince: time.Now()}
+}
+
+func (h *ClientHealth) MarkRefreshed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastRefresh = time.Now()
+}
+
+// SetInGame is called by the supervisor loop, the time out of game starts counting when leaving the game
+func (h *ClientHealth) SetInGame(inGame bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.inGame == inGame {
+		return
+	}
+	h.inGame = inGame
+	if !inGame {
+		h.outOfGameSince = time.Now()
+	}
+}
+
+func (h *ClientHealth) LastRefresh() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.lastRefresh
+}
+
+func (h *ClientHealth) InGame() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.inGame
+}
+
+// OutOfGameSince returns false while in game
+func (h *ClientHealth) OutOfGameSince() (time.Time, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.outOfGameSince, !h.inGame
+}
@@ -1,16 +1,62 @@
 package event
 
+type CharacterSwitchReason string
+
+const (
+	SwitchReasonStashFull    CharacterSwitchReason = "stash full"
+	SwitchReasonMuleFull     CharacterSwitchReason = "mule full"
+	SwitchReasonMulingDone   CharacterSwitchReason = "muling finished"
+	SwitchReasonMulingFailed CharacterSwitchReason = "muling failed"
+)
+
 // CharacterSwitchEvent represents a request to switch to a different character
 type CharacterSwitchEvent struct {
 	BaseEvent
 	CurrentCharacter string
 	NextCharacter    string
+	// ReturnTo is the profile the next character has to switch back to when it's done, empty at the end of the chain
+	ReturnTo string
+	Reason   CharacterSwitchReason
 }
 
-func CharacterSwitch(be BaseEvent, currentCharacter string, nextCharacter string) CharacterSwitchEvent {
+func CharacterSwitch(be BaseEvent, currentCharacter string, nextCharacter string, returnTo string, reason CharacterSwitchReason) CharacterSwitchEvent {
 	return CharacterSwitchEvent{
 		BaseEvent:        be,
 		CurrentCharacter: currentCharacter,
 		NextCharacter:    nextCharacter,
+		ReturnTo:         returnTo,
+		Reason:           reason,
+	}
+}
+
+// CharacterSwitchFailedEvent is sent when the next character of a switch could not be started
+type CharacterSwitchFailedEvent struct {
+	BaseEvent
+	CurrentCharacter string
+	NextCharacter    string
+	Reason           CharacterSwitchReason
+	Error            string
+}
+
+func CharacterSwitchFailed(be BaseEvent, currentCharacter string, nextCharacter string, reason CharacterSwitchReason, err error) CharacterSwitchFailedEvent {
+	return CharacterSwitchFailedEvent{
+		BaseEvent:        be,
+		CurrentCharacter: currentCharacter,
+		NextCharacter:    nextCharacter,
+		Reason:           reason,
+		Error:            err.Error(),
+	}
+}
+
+// AllMulesFullEvent is sent when a character has to mule but every one of its mule profiles is full
+type AllMulesFullEvent struct {
+	BaseEvent
+	Mules []string
+}
+
+func AllMulesFull(be BaseEvent, mules []string) AllMulesFullEvent {
+	return AllMulesFullEvent{
+		BaseEvent: be,
+		Mules:     mules,
 	}
 }
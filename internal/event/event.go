@@ -3,9 +3,13 @@ package event
 import (
 	"image"
 	"time"
+
+	"github.com/hectorgimenez/koolo/internal/runpolicy"
 )
 
-type FinishReason string
+// FinishReason is why a game or run finished, the run policies count them as failures
+type FinishReason = runpolicy.Reason
+
 type InteractionType string
 
 type Event interface {
@@ -2,14 +2,15 @@ package event
 
 import (
 	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/runpolicy"
 )
 
 const (
-	FinishedOK          FinishReason = "ok"
-	FinishedDied        FinishReason = "death"
-	FinishedChicken     FinishReason = "chicken"
-	FinishedMercChicken FinishReason = "merc chicken"
-	FinishedError       FinishReason = "error"
+	FinishedOK          = runpolicy.ReasonOK
+	FinishedDied        = runpolicy.ReasonDied
+	FinishedChicken     = runpolicy.ReasonChicken
+	FinishedMercChicken = runpolicy.ReasonMercChicken
+	FinishedError       = runpolicy.ReasonError
 
 	InteractionTypeEntrance InteractionType = "entrance"
 	InteractionTypeNPC      InteractionType = "npc"
@@ -181,3 +182,18 @@ func ResetCompanionGameInfo(be BaseEvent, leader string) ResetCompanionGameInfoE
 		Leader:    leader,
 	}
 }
+
+// SupervisorStateChangedEvent is sent by the supervisor manager on every lifecycle transition
+type SupervisorStateChangedEvent struct {
+	BaseEvent
+	From string
+	To   string
+}
+
+func SupervisorStateChanged(be BaseEvent, from, to string) SupervisorStateChangedEvent {
+	return SupervisorStateChangedEvent{
+		BaseEvent: be,
+		From:      from,
+		To:        to,
+	}
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"github.com/hectorgimenez/koolo/internal/utils/winproc"
+	"golang.org/x/sys/windows"
+)
+
+const stillActive = 259
+
+// IsProcessRunning returns false when the game client process is gone or can't be queried
+func IsProcessRunning(pid uint32) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_INFORMATION, false, pid)
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var exitCode uint32
+	if err = windows.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false
+	}
+
+	return exitCode == stillActive
+}
+
+// IsWindowHung returns true when the window is not processing messages, as reported by Windows
+func IsWindowHung(hwnd uintptr) bool {
+	hung, _, _ := winproc.IsHungAppWindow.Call(hwnd)
+
+	return hung != 0
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/billgraziano/dpapi"
 	"github.com/hectorgimenez/d2go/pkg/data/difficulty"
 	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/gamename"
 	"github.com/hectorgimenez/koolo/internal/utils"
 	"github.com/lxn/win"
 	"golang.org/x/sys/windows"
@@ -22,10 +23,11 @@ type Manager struct {
 	gr             *MemoryReader
 	hid            *HID
 	supervisorName string
+	gameNames      *gamename.Generator
 }
 
 func NewGameManager(gr *MemoryReader, hid *HID, sueprvisorName string) *Manager {
-	return &Manager{gr: gr, hid: hid, supervisorName: sueprvisorName}
+	return &Manager{gr: gr, hid: hid, supervisorName: sueprvisorName, gameNames: gamename.NewGenerator()}
 }
 
 func (gm *Manager) ExitGame() error {
@@ -116,6 +118,15 @@ func (gm *Manager) clearGameNameOrPasswordField() {
 }
 
 func (gm *Manager) CreateLobbyGame(gameCounter int) (string, error) {
+	cfg, _ := config.GetCharacter(gm.supervisorName)
+	gameName, gamePassword, err := gm.gameNames.Next(cfg.Companion.GameNameTemplate, cfg.Companion.GamePassword, gamename.Vars{
+		Counter:   gameCounter,
+		Character: cfg.CharacterName,
+		Now:       time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
 
 	// Click "Create game" tab
 	gm.hid.Click(LeftButton, 845, 54)
@@ -129,7 +140,6 @@ func (gm *Manager) CreateLobbyGame(gameCounter int) (string, error) {
 		difficulty.Hell:      {X: 1065, Y: 252},
 	}
 
-	cfg, _ := config.GetCharacter(gm.supervisorName)
 	difficultyPos := difficultyPosition[cfg.Game.Difficulty]
 	gm.hid.Click(LeftButton, difficultyPos.X, difficultyPos.Y)
 	utils.Sleep(200)
@@ -137,7 +147,6 @@ func (gm *Manager) CreateLobbyGame(gameCounter int) (string, error) {
 	// Click the game name textbox, delete text and type new game name
 	gm.hid.Click(LeftButton, 1000, 116)
 	gm.clearGameNameOrPasswordField()
-	gameName := cfg.Companion.GameNameTemplate + fmt.Sprintf("%d", gameCounter)
 	for _, ch := range gameName {
 		gm.hid.PressKey(gm.hid.GetASCIICode(fmt.Sprintf("%c", ch)))
 	}
@@ -145,7 +154,6 @@ func (gm *Manager) CreateLobbyGame(gameCounter int) (string, error) {
 	// Same for password
 	gm.hid.Click(LeftButton, 1000, 161)
 	utils.Sleep(200)
-	gamePassword := cfg.Companion.GamePassword
 	if gamePassword != "" {
 		gm.clearGameNameOrPasswordField()
 		for _, ch := range gamePassword {
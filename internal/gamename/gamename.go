@@ -0,0 +1,362 @@
+package gamename
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxLength is the longest game name or password accepted by D2R
+	MaxLength = 15
+
+	defaultRandomLength = 4
+	// recentNamesSize is the number of generated names remembered to avoid reusing them
+	recentNamesSize = 100
+	maxAttempts     = 20
+	alphanumerics   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// words are short and easy to type, any of them is used by the {word} placeholder
+var words = []string{
+	"amber", "ash", "bolt", "brook", "cedar", "cinder", "cloud", "coral", "crow", "dawn", "dune", "dusk", "ember",
+	"fern", "flint", "frost", "gale", "glade", "hawk", "haze", "iris", "ivy", "jade", "lark", "maple", "marsh", "mist",
+	"moss", "oak", "onyx", "opal", "pine", "quill", "rain", "raven", "reed", "ridge", "rook", "sage", "shade", "slate",
+	"sleet", "storm", "thorn", "tide", "vale", "wisp", "wren",
+}
+
+var longestWord = func() int {
+	longest := 0
+	for _, w := range words {
+		longest = max(longest, len(w))
+	}
+	return longest
+}()
+
+// Vars are the values of the placeholders of a template
+type Vars struct {
+	Counter   int
+	Character string
+	Now       time.Time
+}
+
+type partKind int
+
+const (
+	partLiteral partKind = iota
+	partCounter
+	partDate
+	partCharacter
+	partRandom
+	partWord
+)
+
+type part struct {
+	kind  partKind
+	text  string
+	width int
+}
+
+// Template is a parsed game name or password template. Placeholders are written between braces:
+//
+//	{counter} or {counter:N}  game counter, zero padded to N digits
+//	{date}                    current date as MMDD
+//	{char} or {char:N}        character name, only the first N characters
+//	{rand} or {rand:N}        N random letters and digits, 4 by default
+//	{word}                    random word from a built-in list
+//
+// The game accepts most characters but the bot types the values as plain key presses, without shift, so only
+// letters, digits and '-' can be typed. Other characters are left out of the template and the character name.
+type Template struct {
+	parts []part
+	// removed are the characters left out of the template
+	removed string
+}
+
+// Parse parses the template, legacy is appended as a placeholder when the template has none, e.g. {counter} to keep
+// the old "game-" templates generating "game-1", "game-2"...
+func Parse(template string, legacy string) (Template, error) {
+	t, err := parse(template)
+	if err != nil || legacy == "" || t.hasPlaceholders() {
+		return t, err
+	}
+
+	suffix, err := parse(legacy)
+	if err != nil {
+		return t, err
+	}
+	t.parts = append(t.parts, suffix.parts...)
+	t.removed += suffix.removed
+
+	return t, nil
+}
+
+func parse(template string) (Template, error) {
+	t := Template{}
+	for len(template) > 0 {
+		open := strings.IndexByte(template, '{')
+		if closing := strings.IndexByte(template, '}'); closing >= 0 && (open < 0 || closing < open) {
+			return t, errors.New("unexpected '}'")
+		}
+		if open < 0 {
+			open = len(template)
+		}
+		if open > 0 {
+			literal := sanitize(template[:open])
+			for _, ch := range template[:open] {
+				if !allowedChar(ch) && !strings.ContainsRune(t.removed, ch) {
+					t.removed += string(ch)
+				}
+			}
+			if literal != "" {
+				t.parts = append(t.parts, part{kind: partLiteral, text: literal})
+			}
+		}
+		if open == len(template) {
+			break
+		}
+
+		closing := strings.IndexByte(template[open:], '}')
+		if closing < 0 {
+			return t, errors.New("missing '}'")
+		}
+		p, err := parsePlaceholder(template[open+1 : open+closing])
+		if err != nil {
+			return t, err
+		}
+		t.parts = append(t.parts, p)
+		template = template[open+closing+1:]
+	}
+
+	return t, nil
+}
+
+func parsePlaceholder(placeholder string) (part, error) {
+	name, arg, hasArg := strings.Cut(placeholder, ":")
+	width := 0
+	if hasArg {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > MaxLength {
+			return part{}, fmt.Errorf("{%s} length must be a number between 1 and %d", placeholder, MaxLength)
+		}
+		width = n
+	}
+
+	switch name {
+	case "counter":
+		return part{kind: partCounter, width: width}, nil
+	case "char":
+		return part{kind: partCharacter, width: width}, nil
+	case "rand":
+		if width == 0 {
+			width = defaultRandomLength
+		}
+		return part{kind: partRandom, width: width}, nil
+	case "date", "word":
+		if hasArg {
+			return part{}, fmt.Errorf("{%s} doesn't take a length", name)
+		}
+		if name == "date" {
+			return part{kind: partDate}, nil
+		}
+		return part{kind: partWord}, nil
+	}
+
+	return part{}, fmt.Errorf("unknown placeholder {%s}", placeholder)
+}
+
+func (t Template) hasPlaceholders() bool {
+	for _, p := range t.parts {
+		if p.kind != partLiteral {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRandom tells whether rendering the template twice can give different values with the same vars
+func (t Template) IsRandom() bool {
+	for _, p := range t.parts {
+		if p.kind == partRandom || p.kind == partWord {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxLength returns the longest value the template can render with the given vars
+func (t Template) MaxLength(v Vars) int {
+	length := 0
+	for _, p := range t.parts {
+		switch p.kind {
+		case partWord:
+			length += longestWord
+		case partRandom:
+			length += p.width
+		default:
+			length += len(p.render(v, nil))
+		}
+	}
+	return length
+}
+
+// Render returns the value of the template, failing when it's longer than D2R allows
+func (t Template) Render(v Vars, rnd *rand.Rand) (string, error) {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		sb.WriteString(p.render(v, rnd))
+	}
+
+	value := sb.String()
+	if len(value) > MaxLength {
+		return value, fmt.Errorf("%q is longer than %d characters", value, MaxLength)
+	}
+
+	return value, nil
+}
+
+func (p part) render(v Vars, rnd *rand.Rand) string {
+	switch p.kind {
+	case partCounter:
+		return fmt.Sprintf("%0*d", p.width, v.Counter)
+	case partDate:
+		return v.Now.Format("0102")
+	case partCharacter:
+		name := sanitize(v.Character)
+		if p.width > 0 && len(name) > p.width {
+			name = name[:p.width]
+		}
+		return name
+	case partRandom:
+		b := make([]byte, p.width)
+		for i := range b {
+			b[i] = alphanumerics[randIntn(rnd, len(alphanumerics))]
+		}
+		return string(b)
+	case partWord:
+		return words[randIntn(rnd, len(words))]
+	}
+
+	return p.text
+}
+
+func randIntn(rnd *rand.Rand, n int) int {
+	if rnd == nil {
+		return rand.Intn(n)
+	}
+	return rnd.Intn(n)
+}
+
+// allowedChar tells whether the bot can type the character, see Template
+func allowedChar(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '-'
+}
+
+func sanitize(s string) string {
+	var sb strings.Builder
+	for _, ch := range s {
+		if allowedChar(ch) {
+			sb.WriteRune(ch)
+		}
+	}
+	return sb.String()
+}
+
+// Validate checks that the name and password templates are valid and can't render values longer than D2R allows for
+// the given character, counters are assumed to stay below 10000. Characters the bot can't type are reported too, the
+// templates still work without them.
+func Validate(nameTemplate, passwordTemplate, character string) (nameErr, passwordErr error) {
+	v := Vars{Counter: 9999, Character: character, Now: time.Now()}
+
+	if t, err := Parse(nameTemplate, "{counter}"); err != nil {
+		nameErr = err
+	} else if length := t.MaxLength(v); length > MaxLength {
+		nameErr = fmt.Errorf("game names can be up to %d characters long, this template can generate %d", MaxLength, length)
+	} else if t.removed != "" {
+		nameErr = removedCharsError(t.removed)
+	}
+
+	if t, err := Parse(passwordTemplate, ""); err != nil {
+		passwordErr = err
+	} else if length := t.MaxLength(v); length > MaxLength {
+		passwordErr = fmt.Errorf("passwords can be up to %d characters long, this template can generate %d", MaxLength, length)
+	} else if t.removed != "" {
+		passwordErr = removedCharsError(t.removed)
+	}
+
+	return nameErr, passwordErr
+}
+
+func removedCharsError(removed string) error {
+	return fmt.Errorf("characters %q are left out, only letters, digits and '-' can be typed by the bot", removed)
+}
+
+// Generator renders game names and passwords, remembering the recent names to avoid creating the same game twice
+type Generator struct {
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	recent []string
+	used   map[string]bool
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		rnd:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		used: make(map[string]bool),
+	}
+}
+
+// Next returns a new game name and password. Names without placeholders get the counter appended, if the name was
+// recently used the random parts are generated again, or the counter increased when there are none.
+func (g *Generator) Next(nameTemplate, passwordTemplate string, v Vars) (string, string, error) {
+	name, err := Parse(nameTemplate, "{counter}")
+	if err != nil {
+		return "", "", fmt.Errorf("invalid game name template: %w", err)
+	}
+	password, err := Parse(passwordTemplate, "")
+	if err != nil {
+		return "", "", fmt.Errorf("invalid game password template: %w", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		vars := v
+		if !name.IsRandom() {
+			vars.Counter += attempt
+		}
+
+		gameName, err := name.Render(vars, g.rnd)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid game name: %w", err)
+		}
+		if g.used[strings.ToLower(gameName)] {
+			continue
+		}
+
+		gamePassword, err := password.Render(vars, g.rnd)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid game password: %w", err)
+		}
+		g.remember(gameName)
+
+		return gameName, gamePassword, nil
+	}
+
+	return "", "", fmt.Errorf("couldn't generate a game name not used recently after %d attempts", maxAttempts)
+}
+
+func (g *Generator) remember(name string) {
+	name = strings.ToLower(name)
+	if len(g.recent) >= recentNamesSize {
+		delete(g.used, g.recent[0])
+		g.recent = g.recent[1:]
+	}
+	g.recent = append(g.recent, name)
+	g.used[name] = true
+}
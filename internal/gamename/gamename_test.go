@@ -0,0 +1,127 @@
+package gamename
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLegacyTemplateAppendsCounter(t *testing.T) {
+	g := NewGenerator()
+	name, password, err := g.Next("game-", "xxx", Vars{Counter: 7})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "game-7" {
+		t.Errorf("Expected game-7, got %s", name)
+	}
+	if password != "xxx" {
+		t.Errorf("Expected the password to stay static, got %s", password)
+	}
+}
+
+func TestRenderPlaceholders(t *testing.T) {
+	tmpl, err := Parse("{char:4}{date}-{counter:3}", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	v := Vars{Counter: 5, Character: "Sor.Ceress", Now: time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC)}
+	name, err := tmpl.Render(v, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "SorC0309-005" {
+		t.Errorf("Expected SorC0309-005, got %s", name)
+	}
+}
+
+func TestRenderRandomParts(t *testing.T) {
+	tmpl, err := Parse("{word}{rand:5}", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	name, err := tmpl.Render(Vars{}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(name) < 5 || len(name) > MaxLength || sanitize(name) != name {
+		t.Errorf("Expected a random name using allowed characters, got %q", name)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, template := range []string{"game{", "game}", "{unknown}", "{rand:0}", "{rand:x}", "{date:2}"} {
+		if _, err := Parse(template, ""); err == nil {
+			t.Errorf("Expected %q to be invalid", template)
+		}
+	}
+}
+
+func TestUntypeableCharsAreLeftOut(t *testing.T) {
+	g := NewGenerator()
+	name, password, err := g.Next("my_game.", "pass word!", Vars{Counter: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "mygame3" || password != "password" {
+		t.Errorf("Expected mygame3 and password, got %s and %s", name, password)
+	}
+
+	nameErr, passwordErr := Validate("my_game.", "pass word!", "Paladin")
+	if nameErr == nil || !strings.Contains(nameErr.Error(), `"_."`) {
+		t.Errorf("Expected the left out characters of the name to be reported, got %v", nameErr)
+	}
+	if passwordErr == nil || !strings.Contains(passwordErr.Error(), `" !"`) {
+		t.Errorf("Expected the left out characters of the password to be reported, got %v", passwordErr)
+	}
+}
+
+func TestRenderTooLong(t *testing.T) {
+	tmpl, _ := Parse("averylonggamename{counter}", "")
+	if _, err := tmpl.Render(Vars{Counter: 1}, nil); err == nil {
+		t.Errorf("Expected names longer than %d characters to fail", MaxLength)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if nameErr, passwordErr := Validate("{char}-{rand}", "{rand:6}", "Paladin"); nameErr != nil || passwordErr != nil {
+		t.Errorf("Expected templates to be valid, got %v and %v", nameErr, passwordErr)
+	}
+	if nameErr, _ := Validate("{char}-{rand}", "", "AVeryLongCharName"); nameErr == nil {
+		t.Errorf("Expected the game name to be too long for the character")
+	}
+	if _, passwordErr := Validate("game-", "{rand:16}", "Paladin"); passwordErr == nil {
+		t.Errorf("Expected the password to be too long")
+	}
+}
+
+func TestGeneratorAvoidsRecentNames(t *testing.T) {
+	g := NewGenerator()
+	first, _, _ := g.Next("game-", "", Vars{Counter: 1})
+	second, _, err := g.Next("game-", "", Vars{Counter: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.EqualFold(first, second) {
+		t.Errorf("Expected a different name than the recent %s", first)
+	}
+	if second != "game-2" {
+		t.Errorf("Expected the counter to be increased, got %s", second)
+	}
+
+	g = NewGenerator()
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		name, _, err := g.Next("{rand:3}", "", Vars{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if seen[name] {
+			t.Fatalf("Expected recent names not to repeat, got %s twice", name)
+		}
+		seen[name] = true
+	}
+}
@@ -0,0 +1,82 @@
+package damage
+
+import "time"
+
+// Sample is the life of the character, in percent, at the given time
+type Sample struct {
+	At   time.Time
+	Life float64
+}
+
+// Tracker keeps the recent life history of the character to know how fast it is losing life
+type Tracker struct {
+	window  time.Duration
+	samples []Sample
+}
+
+// NewTracker returns a tracker computing the damage taken over the last window
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{window: window}
+}
+
+// Add records the life at the given time, samples older than the window are forgotten. Samples must be added in order,
+// older ones are ignored.
+func (t *Tracker) Add(at time.Time, life float64) {
+	if len(t.samples) > 0 && !at.After(t.samples[len(t.samples)-1].At) {
+		return
+	}
+	t.samples = append(t.samples, Sample{At: at, Life: life})
+
+	first := 0
+	for first < len(t.samples)-1 && at.Sub(t.samples[first].At) > t.window {
+		first++
+	}
+	t.samples = t.samples[first:]
+}
+
+// Reset forgets the life history, e.g. when going back to town
+func (t *Tracker) Reset() {
+	t.samples = t.samples[:0]
+}
+
+// Samples returns how many samples are in the window
+func (t *Tracker) Samples() int {
+	return len(t.samples)
+}
+
+// DPS returns the life lost per second over the window, in percent. Only losses are counted, healing doesn't hide the
+// damage being taken.
+func (t *Tracker) DPS() float64 {
+	if len(t.samples) < 2 {
+		return 0
+	}
+
+	lost := 0.0
+	for i := 1; i < len(t.samples); i++ {
+		if d := t.samples[i-1].Life - t.samples[i].Life; d > 0 {
+			lost += d
+		}
+	}
+	elapsed := t.samples[len(t.samples)-1].At.Sub(t.samples[0].At).Seconds()
+
+	return lost / elapsed
+}
+
+// Projected returns the life expected after horizon at the current damage rate, false until minSamples are recorded
+func (t *Tracker) Projected(horizon time.Duration, minSamples int) (float64, bool) {
+	if len(t.samples) < max(minSamples, 2) {
+		return 0, false
+	}
+
+	return t.samples[len(t.samples)-1].Life - t.DPS()*horizon.Seconds(), true
+}
+
+// WillCross tells whether the life, still above threshold, is expected to be at or below it after horizon
+func (t *Tracker) WillCross(threshold float64, horizon time.Duration, minSamples int) bool {
+	projected, ok := t.Projected(horizon, minSamples)
+	if !ok || threshold <= 0 {
+		return false
+	}
+
+	return t.samples[len(t.samples)-1].Life > threshold && projected <= threshold
+}
@@ -0,0 +1,106 @@
+package damage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const sampleInterval = 100 * time.Millisecond
+
+// replay feeds the life trace, one sample every 100ms, and returns the index of the first sample where the threshold
+// is expected to be crossed before the next sample, -1 if never
+func replay(tracker *Tracker, trace []float64, threshold float64) int {
+	start := time.Unix(0, 0)
+	for i, life := range trace {
+		tracker.Add(start.Add(time.Duration(i)*sampleInterval), life)
+		if tracker.WillCross(threshold, sampleInterval, 3) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestDPS(t *testing.T) {
+	tracker := NewTracker(time.Second)
+	start := time.Unix(0, 0)
+	for i, life := range []float64{100, 95, 90, 85, 80} {
+		tracker.Add(start.Add(time.Duration(i)*sampleInterval), life)
+	}
+
+	if dps := tracker.DPS(); math.Abs(dps-50) > 0.001 {
+		t.Errorf("Expected 50%% life lost per second, got %v", dps)
+	}
+}
+
+func TestDPSIgnoresHealing(t *testing.T) {
+	tracker := NewTracker(time.Second)
+	start := time.Unix(0, 0)
+	for i, life := range []float64{100, 90, 100, 90, 100} {
+		tracker.Add(start.Add(time.Duration(i)*sampleInterval), life)
+	}
+
+	if dps := tracker.DPS(); math.Abs(dps-50) > 0.001 {
+		t.Errorf("Expected healing not to hide the damage taken, got %v", dps)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tracker := NewTracker(300 * time.Millisecond)
+	// Heavy damage first, then nothing for a while
+	trace := []float64{100, 60, 60, 60, 60, 60, 60}
+	start := time.Unix(0, 0)
+	for i, life := range trace {
+		tracker.Add(start.Add(time.Duration(i)*sampleInterval), life)
+	}
+
+	if tracker.DPS() != 0 {
+		t.Errorf("Expected the old damage to be forgotten, got %v", tracker.DPS())
+	}
+	if tracker.Samples() != 4 {
+		t.Errorf("Expected 4 samples in the window, got %d", tracker.Samples())
+	}
+}
+
+func TestPredictsBurst(t *testing.T) {
+	// Lightning enchanted boss: the character loses ~15% every sample and goes from above to well below the threshold
+	// between two samples
+	trace := []float64{100, 100, 88, 74, 58, 41, 25, 8}
+
+	if i := replay(NewTracker(500*time.Millisecond), trace, 30); i != 5 {
+		t.Errorf("Expected the chicken threshold crossing to be predicted at sample 5, got %d", i)
+	}
+}
+
+func TestSteadyDamageNotPredictedEarly(t *testing.T) {
+	// Slow damage, the threshold check on the current life is enough
+	trace := []float64{60, 59, 58, 57, 56, 55, 54, 53, 52, 51, 50, 49, 48}
+
+	if i := replay(NewTracker(time.Second), trace, 30); i != -1 {
+		t.Errorf("Expected no prediction for slow damage far from the threshold, got sample %d", i)
+	}
+	if i := replay(NewTracker(time.Second), trace, 48); i != 11 {
+		t.Errorf("Expected the crossing to be predicted one sample before, got %d", i)
+	}
+}
+
+func TestNeedsSamples(t *testing.T) {
+	tracker := NewTracker(time.Second)
+	start := time.Unix(0, 0)
+	tracker.Add(start, 100)
+	tracker.Add(start.Add(sampleInterval), 40)
+
+	if tracker.WillCross(30, sampleInterval, 3) {
+		t.Errorf("Expected no prediction before having enough samples")
+	}
+	tracker.Add(start.Add(2*sampleInterval), 35)
+	if !tracker.WillCross(30, sampleInterval, 3) {
+		t.Errorf("Expected a prediction with enough samples")
+	}
+
+	tracker.Reset()
+	if tracker.Samples() != 0 || tracker.DPS() != 0 {
+		t.Errorf("Expected the history to be empty after reset")
+	}
+}
@@ -3,6 +3,8 @@ package health
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
@@ -10,7 +12,10 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/data/item"
 	"github.com/hectorgimenez/d2go/pkg/data/stat"
 	"github.com/hectorgimenez/d2go/pkg/data/state"
+	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/health/damage"
+	"github.com/hectorgimenez/koolo/internal/pather"
 )
 
 var ErrDied = errors.New("you died :(")
@@ -20,6 +25,7 @@ var ErrMercChicken = errors.New("mercenary chicken")
 const (
 	healingInterval       = time.Second * 4
 	healingMercInterval   = time.Second * 3
+	damageLogInterval     = time.Second * 1
 	manaInterval          = time.Second * 4
 	rejuvInterval         = time.Second * 1
 	useStaminaPotMaxLevel = 10
@@ -34,6 +40,12 @@ type Manager struct {
 	lastMercHeal  time.Time
 	beltManager   *BeltManager
 	data          *game.Data
+	// runMu guards run, it's set from the bot loop while the health loop reads it
+	runMu         sync.Mutex
+	run           config.Run
+	damage        *damage.Tracker
+	damageWindow  time.Duration
+	lastDamageLog time.Time
 }
 
 func NewHealthManager(bm *BeltManager, data *game.Data) *Manager {
@@ -43,10 +55,52 @@ func NewHealthManager(bm *BeltManager, data *game.Data) *Manager {
 	}
 }
 
+// SetRun sets the run being played, used to pick the health policies
+func (hm *Manager) SetRun(name string) {
+	hm.runMu.Lock()
+	defer hm.runMu.Unlock()
+
+	hm.run = config.Run(name)
+}
+
+func (hm *Manager) currentRun() config.Run {
+	hm.runMu.Lock()
+	defer hm.runMu.Unlock()
+
+	return hm.run
+}
+
+// Thresholds returns the health thresholds for the current area and run, with the danger mode applied when a
+// dangerous monster is close
+func (hm *Manager) Thresholds() config.HealthCfg {
+	health := hm.data.CharacterCfg.Health
+	currentArea := hm.data.PlayerUnit.Area
+
+	dangerNearby := false
+	if health.DangerMode.Enabled {
+		for _, m := range hm.data.Monsters.Enemies() {
+			if health.IsDangerMonster(m.Name, pather.DistanceFromPoint(hm.data.PlayerUnit.Position, m.Position)) {
+				dangerNearby = true
+				break
+			}
+		}
+	}
+
+	return health.Resolve(config.HealthContext{
+		Area:         currentArea,
+		Run:          hm.currentRun(),
+		TerrorZone:   slices.Contains(hm.data.TerrorZones, currentArea),
+		DangerNearby: dangerNearby,
+	})
+}
+
 func (hm *Manager) HandleHealthAndMana() error {
-	hpConfig := hm.data.CharacterCfg.Health
+	hpConfig := hm.Thresholds()
 	// Safe area, skipping
 	if hm.data.PlayerUnit.Area.IsTown() {
+		if hm.damage != nil {
+			hm.damage.Reset()
+		}
 		return nil
 	}
 
@@ -64,6 +118,11 @@ func (hm *Manager) HandleHealthAndMana() error {
 		return fmt.Errorf("%w: Current Merc Health: %d percent", ErrMercChicken, hm.data.MercHPPercent())
 	}
 
+	// Life could cross the thresholds before the next check when taking burst damage
+	if err := hm.handlePredictedDamage(hpConfig); err != nil {
+		return err
+	}
+
 	// Player rejuvenation potion check
 	if time.Since(hm.lastRejuv) > rejuvInterval &&
 		(hm.data.PlayerUnit.HPPercent() <= hpConfig.RejuvPotionAtLife ||
@@ -113,6 +172,52 @@ func (hm *Manager) HandleHealthAndMana() error {
 	return nil
 }
 
+// handlePredictedDamage drinks a rejuvenation potion, or chickens without one, when the damage taken recently would bring
+// life below the thresholds before the next check
+func (hm *Manager) handlePredictedDamage(hpConfig config.HealthCfg) error {
+	prediction := hpConfig.Prediction
+	if !prediction.Enabled {
+		return nil
+	}
+
+	window := time.Duration(prediction.Window) * time.Millisecond
+	if hm.damage == nil || hm.damageWindow != window {
+		hm.damage = damage.NewTracker(window)
+		hm.damageWindow = window
+	}
+
+	now := time.Now()
+	life := hm.data.PlayerUnit.HPPercent()
+	hm.damage.Add(now, float64(life))
+
+	horizon := time.Duration(prediction.Horizon) * time.Millisecond
+	projected, ok := hm.damage.Projected(horizon, prediction.MinSamples)
+	if !ok {
+		return nil
+	}
+	if dps := hm.damage.DPS(); dps > 0 && now.Sub(hm.lastDamageLog) > damageLogInterval {
+		hm.beltManager.logger.Debug(fmt.Sprintf("Taking damage: %.1f%% life per second. HP: %d, projected in %s: %.1f", dps, life, horizon, projected))
+		hm.lastDamageLog = now
+	}
+
+	chicken := hm.damage.WillCross(float64(hpConfig.ChickenAt), horizon, prediction.MinSamples)
+	rejuv := hm.damage.WillCross(float64(hpConfig.RejuvPotionAtLife), horizon, prediction.MinSamples)
+	if !chicken && !rejuv {
+		return nil
+	}
+
+	hm.beltManager.logger.Debug(fmt.Sprintf("Life predicted below thresholds: %.1f%% life per second. HP: %d, projected in %s: %.1f", hm.damage.DPS(), life, horizon, projected))
+	if time.Since(hm.lastRejuv) > rejuvInterval && hm.beltManager.DrinkPotion(data.RejuvenationPotion, false) {
+		hm.lastRejuv = now
+		return nil
+	}
+	if chicken {
+		return fmt.Errorf("%w: Current Health: %d percent, predicted %.0f percent", ErrChicken, life, projected)
+	}
+
+	return nil
+}
+
 func (hm *Manager) ShouldPickStaminaPot() bool {
 	if hm.data.CharacterCfg.Game.Difficulty == difficulty.Normal {
 		if lvl, found := hm.data.PlayerUnit.FindStat(stat.Level, 0); found {
@@ -0,0 +1,221 @@
+package itemlog
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/d2go/pkg/nip"
+)
+
+// recordsSize is the number of decisions kept in memory for every supervisor
+const recordsSize = 1000
+
+type Decision string
+
+const (
+	DecisionPickup    Decision = "pickup"
+	DecisionSkip      Decision = "skip"
+	DecisionStash     Decision = "stash"
+	DecisionKeep      Decision = "keep"
+	DecisionDrop      Decision = "drop"
+	DecisionSell      Decision = "sell"
+	DecisionBlacklist Decision = "blacklist"
+)
+
+// Reason is a stable code explaining a decision, the human-readable details go to Record.Detail
+type Reason string
+
+const (
+	ReasonRuleMatch       Reason = "nip_match"
+	ReasonRulePartial     Reason = "nip_partial_match"
+	ReasonNoRuleMatch     Reason = "nip_no_match"
+	ReasonTierUpgrade     Reason = "tier_upgrade"
+	ReasonMercTierUpgrade Reason = "merc_tier_upgrade"
+	ReasonTierNotBetter   Reason = "tier_not_better"
+	ReasonMaxQuantity     Reason = "max_quantity"
+	ReasonRuneword        Reason = "runeword"
+	ReasonQuestItem       Reason = "quest_item"
+	ReasonProtectedItem   Reason = "protected_item"
+	ReasonLockedSlot      Reason = "locked_slot"
+	ReasonFirstRun        Reason = "first_run"
+	ReasonRecipe          Reason = "recipe"
+	ReasonLowGold         Reason = "low_gold"
+	ReasonGoldFull        Reason = "gold_full"
+	ReasonStaminaPotion   Reason = "stamina_potion"
+	ReasonPotionSurplus   Reason = "potion_surplus"
+	ReasonNoSpace         Reason = "no_space"
+	ReasonPickupFailed    Reason = "pickup_failed"
+	ReasonPreventRepickup Reason = "prevent_repickup"
+)
+
+// Item is the part of the item relevant to understand a decision
+type Item struct {
+	UnitID     int    `json:"unitId"`
+	Name       string `json:"name"`
+	Quality    string `json:"quality"`
+	Identified bool   `json:"identified"`
+	Ethereal   bool   `json:"ethereal"`
+	Sockets    int    `json:"sockets,omitempty"`
+	Location   string `json:"location"`
+}
+
+// TierComparison is the autoequip score of the item compared to the equipped one
+type TierComparison struct {
+	Merc          bool    `json:"merc"`
+	Tier          float64 `json:"tier"`
+	BodyLocation  string  `json:"bodyLocation,omitempty"`
+	ItemScore     float64 `json:"itemScore"`
+	EquippedScore float64 `json:"equippedScore"`
+}
+
+type Record struct {
+	Time       time.Time       `json:"time"`
+	Supervisor string          `json:"supervisor"`
+	Item       Item            `json:"item"`
+	Decision   Decision        `json:"decision"`
+	Reason     Reason          `json:"reason"`
+	Rule       string          `json:"rule,omitempty"`
+	RuleFile   string          `json:"ruleFile,omitempty"`
+	Tier       *TierComparison `json:"tier,omitempty"`
+	Detail     string          `json:"detail,omitempty"`
+}
+
+// NewRecord describes the decision taken on the given item, rule is the NIP rule that matched, if any
+func NewRecord(supervisor string, itm data.Item, decision Decision, reason Reason, rule nip.Rule) Record {
+	record := Record{
+		Time:       time.Now(),
+		Supervisor: supervisor,
+		Item: Item{
+			UnitID:     int(itm.UnitID),
+			Name:       string(itm.Name),
+			Quality:    itm.Quality.ToString(),
+			Identified: itm.Identified,
+			Ethereal:   itm.Ethereal,
+			Location:   string(itm.Location.LocationType),
+		},
+		Decision: decision,
+		Reason:   reason,
+	}
+	if itm.IdentifiedName != "" {
+		record.Item.Name = itm.IdentifiedName
+	}
+	if sockets, found := itm.FindStat(stat.NumSockets, 0); found {
+		record.Item.Sockets = sockets.Value
+	}
+	if rule.RawLine != "" {
+		record.Rule = rule.RawLine
+		record.RuleFile = RuleFile(rule)
+	}
+
+	return record
+}
+
+// RuleFile returns the file:line of the given NIP rule
+func RuleFile(rule nip.Rule) string {
+	return rule.Filename + ":" + strconv.Itoa(rule.LineNumber)
+}
+
+func (r Record) WithTier(tier TierComparison) Record {
+	r.Tier = &tier
+	return r
+}
+
+func (r Record) WithDetail(detail string) Record {
+	r.Detail = detail
+	return r
+}
+
+// Filter selects the records returned by Query, zero values match everything
+type Filter struct {
+	Supervisor string
+	Decision   Decision
+	// Item matches the item name, case insensitive
+	Item  string
+	Since time.Time
+	Limit int
+}
+
+func (f Filter) matches(r Record) bool {
+	if f.Decision != "" && r.Decision != f.Decision {
+		return false
+	}
+	if f.Item != "" && !strings.Contains(strings.ToLower(r.Item.Name), strings.ToLower(f.Item)) {
+		return false
+	}
+
+	return f.Since.IsZero() || !r.Time.Before(f.Since)
+}
+
+type supervisorRecords struct {
+	records []Record
+	next    int
+	full    bool
+	// last is the last decision recorded for every item, the same decision is evaluated over and over while the item
+	// is on the ground or in the inventory and is only recorded when it changes
+	last map[int]string
+}
+
+var (
+	mu      sync.RWMutex
+	buffers = make(map[string]*supervisorRecords)
+)
+
+// Add records a decision, decisions repeating the previous one for the same item are ignored
+func Add(r Record) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	buf, found := buffers[r.Supervisor]
+	if !found {
+		buf = &supervisorRecords{records: make([]Record, recordsSize), last: make(map[int]string)}
+		buffers[r.Supervisor] = buf
+	}
+
+	key := string(r.Decision) + "|" + string(r.Reason) + "|" + r.Item.Name + "|" + r.Item.Location
+	if buf.last[r.Item.UnitID] == key {
+		return
+	}
+	if len(buf.last) >= recordsSize {
+		buf.last = make(map[int]string)
+	}
+	buf.last[r.Item.UnitID] = key
+
+	buf.records[buf.next] = r
+	buf.next = (buf.next + 1) % recordsSize
+	if buf.next == 0 {
+		buf.full = true
+	}
+}
+
+// Query returns the decisions of the supervisor matching the filter, the most recent first
+func Query(f Filter) []Record {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	records := make([]Record, 0)
+	buf, found := buffers[f.Supervisor]
+	if !found {
+		return records
+	}
+
+	count := buf.next
+	if buf.full {
+		count = recordsSize
+	}
+	for i := 0; i < count; i++ {
+		r := buf.records[(buf.next-1-i+recordsSize)%recordsSize]
+		if !f.matches(r) {
+			continue
+		}
+		records = append(records, r)
+		if f.Limit > 0 && len(records) >= f.Limit {
+			break
+		}
+	}
+
+	return records
+}
@@ -0,0 +1,137 @@
+package itemlog
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// resetBuffers empties the records of every supervisor for the duration of the test
+func resetBuffers(t *testing.T) {
+	mu.Lock()
+	previous := buffers
+	buffers = make(map[string]*supervisorRecords)
+	mu.Unlock()
+
+	t.Cleanup(func() {
+		mu.Lock()
+		buffers = previous
+		mu.Unlock()
+	})
+}
+
+func record(supervisor string, unitID int, name string, decision Decision, reason Reason, at time.Time) Record {
+	return Record{
+		Time:       at,
+		Supervisor: supervisor,
+		Item:       Item{UnitID: unitID, Name: name, Location: "ground"},
+		Decision:   decision,
+		Reason:     reason,
+	}
+}
+
+func names(records []Record) []string {
+	result := make([]string, 0, len(records))
+	for _, r := range records {
+		result = append(result, r.Item.Name)
+	}
+
+	return result
+}
+
+func TestAddRingBuffer(t *testing.T) {
+	resetBuffers(t)
+
+	now := time.Now()
+	for i := 0; i < recordsSize+5; i++ {
+		Add(record("sorc", i, "item"+strconv.Itoa(i), DecisionPickup, ReasonRuleMatch, now))
+	}
+
+	records := Query(Filter{Supervisor: "sorc"})
+	if len(records) != recordsSize {
+		t.Fatalf("Expected %d records, got %d", recordsSize, len(records))
+	}
+	if newest, oldest := records[0].Item.Name, records[len(records)-1].Item.Name; newest != "item1004" || oldest != "item5" {
+		t.Errorf("Expected the oldest records to be replaced, got newest %s and oldest %s", newest, oldest)
+	}
+
+	// Supervisors don't share their records
+	if records := Query(Filter{Supervisor: "pala"}); len(records) != 0 {
+		t.Errorf("Expected no records for another supervisor, got %d", len(records))
+	}
+}
+
+func TestAddDedup(t *testing.T) {
+	resetBuffers(t)
+
+	now := time.Now()
+	Add(record("sorc", 1, "Ber", DecisionPickup, ReasonRuleMatch, now))
+	Add(record("sorc", 1, "Ber", DecisionPickup, ReasonRuleMatch, now.Add(time.Second)))
+	// The same decision for another item is recorded
+	Add(record("sorc", 2, "Ber", DecisionPickup, ReasonRuleMatch, now.Add(2*time.Second)))
+	// And so is the same item once the decision changes
+	Add(record("sorc", 1, "Ber", DecisionStash, ReasonRuleMatch, now.Add(3*time.Second)))
+	Add(record("sorc", 1, "Ber", DecisionStash, ReasonRuleMatch, now.Add(4*time.Second)))
+	// The same unit ID for another supervisor is another item
+	Add(record("pala", 1, "Ber", DecisionPickup, ReasonRuleMatch, now))
+
+	records := Query(Filter{Supervisor: "sorc"})
+	if len(records) != 3 {
+		t.Fatalf("Expected repeated decisions to be recorded once, got %d records", len(records))
+	}
+	if records[0].Decision != DecisionStash || records[1].Item.UnitID != 2 || !records[2].Time.Equal(now) {
+		t.Errorf("Expected the first record of every decision to be kept, got %+v", records)
+	}
+	if records := Query(Filter{Supervisor: "pala"}); len(records) != 1 {
+		t.Errorf("Expected 1 record, got %d", len(records))
+	}
+
+	// An item changing location is a new decision, e.g. picked up and then dropped from the inventory
+	moved := record("sorc", 1, "Ber", DecisionStash, ReasonRuleMatch, now.Add(5*time.Second))
+	moved.Item.Location = "inventory"
+	Add(moved)
+	if records := Query(Filter{Supervisor: "sorc"}); len(records) != 4 {
+		t.Errorf("Expected 4 records, got %d", len(records))
+	}
+}
+
+func TestQuery(t *testing.T) {
+	resetBuffers(t)
+
+	start := time.Date(2024, time.March, 9, 23, 15, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	Add(record("sorc", 1, "Ber", DecisionPickup, ReasonRuleMatch, at(0)))
+	Add(record("sorc", 2, "Monarch", DecisionPickup, ReasonRulePartial, at(1)))
+	Add(record("sorc", 3, "Cap", DecisionSkip, ReasonNoRuleMatch, at(2)))
+	Add(record("sorc", 1, "Ber", DecisionStash, ReasonRuleMatch, at(3)))
+	Add(record("sorc", 2, "Monarch", DecisionSell, ReasonRulePartial, at(4)))
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"most recent first", Filter{}, []string{"Monarch", "Ber", "Cap", "Monarch", "Ber"}},
+		{"decision", Filter{Decision: DecisionPickup}, []string{"Monarch", "Ber"}},
+		{"item is case insensitive", Filter{Item: "mONarc"}, []string{"Monarch", "Monarch"}},
+		{"since is inclusive", Filter{Since: at(3)}, []string{"Monarch", "Ber"}},
+		{"limit keeps the newest", Filter{Limit: 2}, []string{"Monarch", "Ber"}},
+		{"limit applies after filtering", Filter{Decision: DecisionPickup, Limit: 1}, []string{"Monarch"}},
+		{"combined", Filter{Item: "ber", Decision: DecisionStash, Since: at(1)}, []string{"Ber"}},
+		{"no match", Filter{Decision: DecisionBlacklist}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Supervisor = "sorc"
+			if got := names(Query(tt.filter)); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if records := Query(Filter{Supervisor: "unknown"}); records == nil || len(records) != 0 {
+		t.Errorf("Expected an empty list for an unknown supervisor, got %v", records)
+	}
+}
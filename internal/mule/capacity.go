@@ -0,0 +1,106 @@
+package mule
+
+import (
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+)
+
+const (
+	stashWidth      = 10
+	stashHeight     = 10
+	inventoryWidth  = 10
+	inventoryHeight = 4
+)
+
+// Capacity is the room left in a mule, as seen in its last stash snapshot
+type Capacity struct {
+	FreeStashCells     int       `json:"freeStashCells"`
+	FreeInventoryCells int       `json:"freeInventoryCells"`
+	Full               bool      `json:"full"`
+	CapturedAt         time.Time `json:"capturedAt"`
+}
+
+// SnapshotCapacity calculates the capacity of a mule from its stash snapshot
+func SnapshotCapacity(snapshot StashSnapshot, inventoryLock [][]int) Capacity {
+	capacity := StashCapacity(snapshot.Items, snapshot.Inventory, inventoryLock)
+	capacity.CapturedAt = snapshot.CapturedAt
+
+	return capacity
+}
+
+// StashCapacity calculates the free cells of the private stash and the inventory. Only the private stash matters to
+// consider a mule full, it's full when there's no room left for a 2x2 item.
+func StashCapacity(stash, inventory []data.Item, inventoryLock [][]int) Capacity {
+	stashGrid := occupiedGrid(stash, item.LocationStash, stashWidth, stashHeight)
+	inventoryGrid := occupiedGrid(inventory, item.LocationInventory, inventoryWidth, inventoryHeight)
+	for y, row := range inventoryLock {
+		for x, cell := range row {
+			// Locked cells (0) are never used to move items
+			if y < inventoryHeight && x < inventoryWidth && cell == 0 {
+				inventoryGrid[y][x] = true
+			}
+		}
+	}
+
+	return Capacity{
+		FreeStashCells:     freeCells(stashGrid),
+		FreeInventoryCells: freeCells(inventoryGrid),
+		Full:               !fits(stashGrid, 2, 2),
+	}
+}
+
+func occupiedGrid(items []data.Item, location item.LocationType, width, height int) [][]bool {
+	grid := make([][]bool, height)
+	for y := range grid {
+		grid[y] = make([]bool, width)
+	}
+
+	for _, itm := range items {
+		if itm.Location.LocationType != location {
+			continue
+		}
+		for y := 0; y < itm.Desc().InventoryHeight; y++ {
+			for x := 0; x < itm.Desc().InventoryWidth; x++ {
+				if itm.Position.Y+y < height && itm.Position.X+x < width {
+					grid[itm.Position.Y+y][itm.Position.X+x] = true
+				}
+			}
+		}
+	}
+
+	return grid
+}
+
+func freeCells(grid [][]bool) int {
+	free := 0
+	for _, row := range grid {
+		for _, occupied := range row {
+			if !occupied {
+				free++
+			}
+		}
+	}
+
+	return free
+}
+
+// fits returns true if there's a free area of the given size in the grid
+func fits(grid [][]bool, width, height int) bool {
+	for y := 0; y+height <= len(grid); y++ {
+		for x := 0; x+width <= len(grid[y]); x++ {
+			free := true
+			for j := 0; j < height && free; j++ {
+				for i := 0; i < width && free; i++ {
+					free = !grid[y+j][x+i]
+				}
+			}
+			if free {
+				return true
+			}
+		}
+	}
+
+	return false
+}
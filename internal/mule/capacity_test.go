@@ -0,0 +1,119 @@
+package mule
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+)
+
+// itemOfSize returns an item at the given position whose description has the given size
+func itemOfSize(t *testing.T, location item.LocationType, x, y, width, height int) data.Item {
+	t.Helper()
+
+	for id, desc := range item.Desc {
+		if desc.InventoryWidth == width && desc.InventoryHeight == height {
+			return data.Item{
+				ID:       id,
+				Position: data.Position{X: x, Y: y},
+				Location: item.Location{LocationType: location},
+			}
+		}
+	}
+	t.Fatalf("No item of size %dx%d", width, height)
+
+	return data.Item{}
+}
+
+func gridFromRows(rows ...string) [][]bool {
+	grid := make([][]bool, len(rows))
+	for y, row := range rows {
+		grid[y] = make([]bool, len(row))
+		for x, cell := range row {
+			grid[y][x] = cell == '#'
+		}
+	}
+	return grid
+}
+
+func TestFits(t *testing.T) {
+	tests := []struct {
+		name          string
+		grid          [][]bool
+		width, height int
+		expected      bool
+	}{
+		{"empty", gridFromRows("...", "..."), 2, 2, true},
+		{"full", gridFromRows("###", "###"), 1, 1, false},
+		{"free cells not adjacent", gridFromRows(".#.", "#.#"), 2, 2, false},
+		{"free area in the corner", gridFromRows("##.", "#..", "#.."), 2, 2, true},
+		{"larger than the grid", gridFromRows("...", "..."), 2, 3, false},
+	}
+
+	for _, tt := range tests {
+		if result := fits(tt.grid, tt.width, tt.height); result != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestOccupiedGrid(t *testing.T) {
+	items := []data.Item{
+		itemOfSize(t, item.LocationStash, 0, 0, 2, 3),
+		itemOfSize(t, item.LocationStash, 9, 9, 1, 1),
+		// Other locations and cells outside the grid are ignored
+		itemOfSize(t, item.LocationInventory, 5, 5, 1, 1),
+		itemOfSize(t, item.LocationStash, 9, 8, 2, 2),
+	}
+
+	grid := occupiedGrid(items, item.LocationStash, stashWidth, stashHeight)
+	occupied := stashWidth*stashHeight - freeCells(grid)
+	if occupied != 8 {
+		t.Errorf("Expected 8 occupied cells, got %d", occupied)
+	}
+	if !grid[2][1] || grid[3][0] || grid[0][2] {
+		t.Errorf("Expected the 2x3 item to occupy its cells only")
+	}
+	if grid[5][5] {
+		t.Errorf("Expected inventory items to be ignored")
+	}
+}
+
+func TestStashCapacity(t *testing.T) {
+	// Every column of the stash is filled except the last one, so there's no room for a 2x2 item
+	stash := make([]data.Item, 0)
+	for x := 0; x < stashWidth-1; x++ {
+		stash = append(stash,
+			itemOfSize(t, item.LocationStash, x, 0, 1, 4),
+			itemOfSize(t, item.LocationStash, x, 4, 1, 4),
+			itemOfSize(t, item.LocationStash, x, 8, 1, 2),
+		)
+	}
+	inventory := []data.Item{itemOfSize(t, item.LocationInventory, 0, 0, 2, 2)}
+	inventoryLock := [][]int{
+		{1, 1, 1, 1, 1, 1, 1, 1, 0, 0},
+		{1, 1, 1, 1, 1, 1, 1, 1, 0, 0},
+		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+	}
+
+	capacity := StashCapacity(stash, inventory, inventoryLock)
+	if capacity.FreeStashCells != stashHeight {
+		t.Errorf("Expected %d free stash cells, got %d", stashHeight, capacity.FreeStashCells)
+	}
+	if capacity.FreeInventoryCells != 40-4-4 {
+		t.Errorf("Expected %d free inventory cells, got %d", 40-4-4, capacity.FreeInventoryCells)
+	}
+	if !capacity.Full {
+		t.Errorf("Expected the stash to be full")
+	}
+
+	// Removing an item next to the free column leaves room for a 2x2 item
+	stash = slices.DeleteFunc(stash, func(itm data.Item) bool {
+		return itm.Position.X == stashWidth-2 && itm.Position.Y == 0
+	})
+	if capacity = StashCapacity(stash, nil, nil); capacity.Full {
+		t.Errorf("Expected room for a 2x2 item once an item is removed")
+	}
+}
@@ -1,11 +1,17 @@
 package mule
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
 )
 
+var ErrAllMulesFull = errors.New("all mules are full")
+
 // Manager is responsible for managing the muling process.
 type Manager struct {
 	logger *slog.Logger
@@ -18,20 +24,86 @@ func NewManager(logger *slog.Logger) *Manager {
 	}
 }
 
-// ShouldMule checks if the stash is full and muling is required.
+// ShouldMule checks if the stash is full and muling is required, returning the mule to switch to.
 func (m *Manager) ShouldMule(stashFull bool, characterName string) (bool, string) {
-	for _, char := range config.GetCharacters() {
-		// Updated logic: Check if MuleProfiles list is not empty instead of SwitchToMule
-		if char.CharacterName == characterName && char.Muling.Enabled && len(char.Muling.MuleProfiles) > 0 {
-			if stashFull {
-				firstMule := char.Muling.MuleProfiles[0]
-				m.logger.Info("Stash is full, muling is required.", "switchToMule", firstMule)
-				return true, firstMule
-			}
+	cfg, found := config.GetCharacter(characterName)
+	if !stashFull || !found || !cfg.Muling.Enabled || len(cfg.Muling.MuleProfiles) == 0 {
+		return false, ""
+	}
+
+	nextMule, err := m.NextMule(characterName)
+	if err != nil {
+		m.logger.Warn("Stash is full, but there is no mule available.", "error", err)
+		return false, ""
+	}
+
+	m.logger.Info("Stash is full, muling is required.", "switchToMule", nextMule)
+	return true, nextMule
+}
+
+// NextMule returns the next mule with room left in the rotation of the given farming character, the skipped mules
+// are never returned. The rotation goes on from the last used mule, mules without a known capacity are considered
+// to have room. ErrAllMulesFull is returned (and notified) when every mule is full.
+func (m *Manager) NextMule(farmer string, skip ...string) (string, error) {
+	cfg, found := config.GetCharacter(farmer)
+	if !found {
+		return "", fmt.Errorf("character %s not found", farmer)
+	}
+	mules := cfg.Muling.MuleProfiles
+	if len(mules) == 0 {
+		return "", fmt.Errorf("no mule profiles configured for %s", farmer)
+	}
+
+	state, err := LoadState(farmer)
+	if err != nil {
+		m.logger.Warn("Failed to load muling state, starting from the first mule", "error", err)
+	}
+
+	start := max(slices.Index(mules, state.CurrentMule), 0)
+	for i := range mules {
+		mule := mules[(start+i)%len(mules)]
+		if slices.Contains(skip, mule) {
+			continue
 		}
+
+		capacity, known := m.Capacity(mule, state)
+		if known && capacity.Full {
+			m.logger.Debug("Mule is full, skipping it", "mule", mule, "checkedAt", capacity.CapturedAt)
+			continue
+		}
+
+		state.CurrentMule = mule
+		state.AllMulesFull = false
+		if err = SaveState(farmer, state); err != nil {
+			m.logger.Warn("Failed to save muling state", "error", err)
+		}
+
+		return mule, nil
+	}
+
+	state.AllMulesFull = true
+	if err = SaveState(farmer, state); err != nil {
+		m.logger.Warn("Failed to save muling state", "error", err)
+	}
+	event.Send(event.AllMulesFull(event.Text(farmer, fmt.Sprintf("All mules of %s are full, items can not be muled anymore", farmer)), mules))
+
+	return "", ErrAllMulesFull
+}
+
+// Capacity returns the capacity of the mule according to its last stash snapshot, it's unknown when there is no
+// snapshot taken after the last reset of the rotation
+func (m *Manager) Capacity(mule string, state State) (Capacity, bool) {
+	snapshot, err := LoadStashSnapshot(mule)
+	if err != nil || snapshot.CapturedAt.Before(state.ResetAt) {
+		return Capacity{}, false
+	}
+
+	var inventoryLock [][]int
+	if muleCfg, found := config.GetCharacter(mule); found {
+		inventoryLock = muleCfg.Inventory.InventoryLock
 	}
 
-	return false, ""
+	return SnapshotCapacity(snapshot, inventoryLock), true
 }
 
 // IsMuleCharacter checks if the character is configured as a mule.
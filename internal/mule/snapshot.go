@@ -0,0 +1,57 @@
+package mule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+)
+
+const stashSnapshotFile = "stash_snapshot.json"
+
+// StashSnapshot is the last known content of the stash (personal and shared) and the inventory of a character
+type StashSnapshot struct {
+	Supervisor string      `json:"supervisor"`
+	CapturedAt time.Time   `json:"capturedAt"`
+	Items      []data.Item `json:"items"`
+	Inventory  []data.Item `json:"inventory,omitempty"`
+}
+
+// SaveStashSnapshot stores the current stash and inventory content of the given character
+func SaveStashSnapshot(supervisor string, stash, inventory []data.Item) (StashSnapshot, error) {
+	snapshot := StashSnapshot{
+		Supervisor: supervisor,
+		CapturedAt: time.Now(),
+		Items:      stash,
+		Inventory:  inventory,
+	}
+
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return snapshot, err
+	}
+	if err = os.WriteFile(filepath.Join("config", supervisor, stashSnapshotFile), content, 0644); err != nil {
+		return snapshot, fmt.Errorf("error saving stash snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// LoadStashSnapshot returns the last saved snapshot of the given character, the error wraps os.ErrNotExist when
+// there is none yet
+func LoadStashSnapshot(supervisor string) (StashSnapshot, error) {
+	content, err := os.ReadFile(filepath.Join("config", supervisor, stashSnapshotFile))
+	if err != nil {
+		return StashSnapshot{}, fmt.Errorf("error reading stash snapshot: %w", err)
+	}
+
+	var snapshot StashSnapshot
+	if err = json.Unmarshal(content, &snapshot); err != nil {
+		return StashSnapshot{}, fmt.Errorf("error parsing stash snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,59 @@
+package mule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFile = "muling_state.json"
+
+// State is the muling rotation progress of a farming character, it's kept in its own file next to config.yaml
+type State struct {
+	// CurrentMule is the last mule the character switched to, the rotation goes on from it
+	CurrentMule  string `json:"currentMule"`
+	AllMulesFull bool   `json:"allMulesFull"`
+	// ResetAt discards the mule snapshots taken before it, so mules emptied by hand are used again after a reset
+	ResetAt   time.Time `json:"resetAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LoadState returns the muling state of the given character, an empty state if it never muled
+func LoadState(supervisor string) (State, error) {
+	content, err := os.ReadFile(filepath.Join("config", supervisor, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("error reading muling state: %w", err)
+	}
+
+	var state State
+	if err = json.Unmarshal(content, &state); err != nil {
+		return State{}, fmt.Errorf("error parsing muling state: %w", err)
+	}
+
+	return state, nil
+}
+
+func SaveState(supervisor string, state State) error {
+	state.UpdatedAt = time.Now()
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(filepath.Join("config", supervisor, stateFile), content, 0644); err != nil {
+		return fmt.Errorf("error saving muling state: %w", err)
+	}
+
+	return nil
+}
+
+// ResetState restarts the rotation from the first mule, ignoring the capacity known until now
+func ResetState(supervisor string) error {
+	return SaveState(supervisor, State{ResetAt: time.Now()})
+}
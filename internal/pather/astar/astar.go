@@ -1,7 +1,6 @@
 package astar
 
 import (
-	"container/heap"
 	"math"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
@@ -23,7 +22,6 @@ type Node struct {
 	data.Position
 	Cost     int
 	Priority int
-	Index    int
 	TpStreak int
 }
 
@@ -35,34 +33,59 @@ func direction(from, to data.Position) (dx, dy int) {
 
 const MaxConsecutiveTeleportOver = 12
 
+// heuristicWeight scales the heuristic of the full search, keeping it low explores more but finds better paths
+const heuristicWeight = 0.5
+
 func CalculatePath(g *game.Grid, start, goal data.Position, canTeleport bool) ([]data.Position, int, bool) {
+	return calculatePath(g, start, goal, canTeleport, searchBounds{maxX: g.Width - 1, maxY: g.Height - 1}, nil, heuristicWeight)
+}
+
+// searchBounds is the inclusive rectangle of the grid a search can expand, the search state is only allocated for it
+type searchBounds struct {
+	minX, minY, maxX, maxY int
+}
+
+func (b searchBounds) contains(p data.Position) bool {
+	return p.X >= b.minX && p.X <= b.maxX && p.Y >= b.minY && p.Y <= b.maxY
+}
+
+func (b searchBounds) union(o searchBounds) searchBounds {
+	return searchBounds{minX: min(b.minX, o.minX), minY: min(b.minY, o.minY), maxX: max(b.maxX, o.maxX), maxY: max(b.maxY, o.maxY)}
+}
+
+func (b searchBounds) index(p data.Position) int {
+	return (p.Y-b.minY)*(b.maxX-b.minX+1) + p.X - b.minX
+}
+
+// calculatePath runs A* over the grid inside bounds, when allowed is not nil only the tiles accepted by it will be
+// expanded
+func calculatePath(g *game.Grid, start, goal data.Position, canTeleport bool, bounds searchBounds, allowed func(x, y int) bool, weight float64) ([]data.Position, int, bool) {
+	if !bounds.contains(start) || !bounds.contains(goal) {
+		return nil, 0, false
+	}
+
 	pq := make(PriorityQueue, 0)
-	heap.Init(&pq)
 
-	// Use a 2D slice to store the cost of each node
-	costSoFar := make([][]int, g.Width)
-	cameFrom := make([][]data.Position, g.Width)
+	// Cost and origin of every tile inside the bounds
+	size := (bounds.maxX - bounds.minX + 1) * (bounds.maxY - bounds.minY + 1)
+	costSoFar := make([]int, size)
+	cameFrom := make([]data.Position, size)
 	for i := range costSoFar {
-		costSoFar[i] = make([]int, g.Height)
-		cameFrom[i] = make([]data.Position, g.Height)
-		for j := range costSoFar[i] {
-			costSoFar[i][j] = math.MaxInt32
-		}
+		costSoFar[i] = math.MaxInt32
 	}
 
-	startNode := &Node{Position: start, Cost: 0, Priority: heuristic(start, goal)}
-	heap.Push(&pq, startNode)
-	costSoFar[start.X][start.Y] = 0
+	pq.Push(Node{Position: start, Cost: 0, Priority: heuristic(start, goal)})
+	costSoFar[bounds.index(start)] = 0
 
 	neighbors := make([]data.Position, 0, 8)
 
 	for pq.Len() > 0 {
-		current := heap.Pop(&pq).(*Node)
+		current := pq.Pop()
 
 		// Let's build the path if we reached the goal
 		if current.Position == goal {
 			var path []data.Position
-			for p := goal; p != start; p = cameFrom[p.X][p.Y] {
+			for p := goal; p != start; p = cameFrom[bounds.index(p)] {
 				if g.CollisionGrid[p.Y][p.X] == game.CollisionTypeTeleportOver {
 					continue
 				}
@@ -72,9 +95,13 @@ func CalculatePath(g *game.Grid, start, goal data.Position, canTeleport bool) ([
 			return path, len(path), true
 		}
 
-		updateNeighbors(g, current, &neighbors, canTeleport)
+		updateNeighbors(g, &current, &neighbors, canTeleport)
 
 		for _, neighbor := range neighbors {
+			if !bounds.contains(neighbor) || (allowed != nil && !allowed(neighbor.X, neighbor.Y)) {
+				continue
+			}
+
 			tileType := g.CollisionGrid[neighbor.Y][neighbor.X]
 
 			// Determine teleport streak
@@ -90,7 +117,7 @@ func CalculatePath(g *game.Grid, start, goal data.Position, canTeleport bool) ([
 				continue
 			}
 
-			newCost := costSoFar[current.X][current.Y] + getCost(tileType, canTeleport)
+			newCost := costSoFar[bounds.index(current.Position)] + getCost(tileType, canTeleport)
 
 			// Handicap for changing direction, this prevents zig-zagging around obstacles
 			//curDirX, curDirY := direction(cameFrom[current.X][current.Y], current.Position)
@@ -99,11 +126,11 @@ func CalculatePath(g *game.Grid, start, goal data.Position, canTeleport bool) ([
 			//	newCost++
 			//}
 
-			if newCost < costSoFar[neighbor.X][neighbor.Y] {
-				costSoFar[neighbor.X][neighbor.Y] = newCost
-				priority := newCost + int(0.5*float64(heuristic(neighbor, goal)))
-				heap.Push(&pq, &Node{Position: neighbor, Cost: newCost, Priority: priority, TpStreak: teleportStreak})
-				cameFrom[neighbor.X][neighbor.Y] = current.Position
+			if neighborIdx := bounds.index(neighbor); newCost < costSoFar[neighborIdx] {
+				costSoFar[neighborIdx] = newCost
+				priority := newCost + int(weight*float64(heuristic(neighbor, goal)))
+				pq.Push(Node{Position: neighbor, Cost: newCost, Priority: priority, TpStreak: teleportStreak})
+				cameFrom[neighborIdx] = current.Position
 			}
 		}
 	}
@@ -39,6 +39,58 @@ func TestAstar(t *testing.T) {
 	}
 }
 
+func BenchmarkHierarchical(b *testing.B) {
+	grid := loadGrid()
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+	cg := NewClusterGraph(grid, nil, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CalculateHierarchicalPath(grid, cg, start, goal, false)
+	}
+}
+
+func TestHierarchical(t *testing.T) {
+	grid := loadGrid()
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+
+	p, dist, found := CalculateHierarchicalPath(grid, NewClusterGraph(grid, nil, false), start, goal, false)
+	if !found {
+		t.Fatalf("Expected path to be found")
+	}
+	checkPath(t, grid, p, start, goal)
+
+	// Hierarchical path is not guaranteed to be optimal, but it should stay close to the full search
+	_, astarDist, _ := CalculatePath(grid, start, goal, false)
+	if float64(dist) > float64(astarDist)*1.1 {
+		t.Errorf("Expected distance to be close to %d, got %d", astarDist, dist)
+	}
+}
+
+func checkPath(t *testing.T, grid *game.Grid, p []data.Position, start, goal data.Position) {
+	t.Helper()
+
+	if len(p) == 0 {
+		t.Fatalf("Expected a path from %v to %v", start, goal)
+	}
+	if p[0] != start || p[len(p)-1] != goal {
+		t.Errorf("Expected path to go from %v to %v, got %v to %v", start, goal, p[0], p[len(p)-1])
+	}
+	for i := 1; i < len(p); i++ {
+		dx, dy := direction(p[i-1], p[i])
+		if dx < -1 || dx > 1 || dy < -1 || dy > 1 {
+			t.Fatalf("Expected consecutive path positions, got %v followed by %v", p[i-1], p[i])
+		}
+		if grid.CollisionGrid[p[i].Y][p[i].X] == game.CollisionTypeNonWalkable {
+			t.Fatalf("Expected walkable position, got %v", p[i])
+		}
+	}
+}
+
 func loadGrid() *game.Grid {
 	var grid game.Grid
 	file, err := os.Open("durance_of_hate_grid.bin")
@@ -0,0 +1,325 @@
+package astar
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// ClusterSize is the side length (in tiles) of the squares grouping the tiles outside of the area rooms
+const ClusterSize = 16
+
+// noCluster is the cluster of the tiles that can't be walked
+const noCluster = -1
+
+// refineWindow is the number of route clusters ahead of the current one each refinement search goes through
+const refineWindow = 5
+
+// refineHeuristicWeight is the heuristic weight of the refinement searches, they are small and follow the route so
+// a greedier search than the full one doesn't get trapped
+const refineHeuristicWeight = 1.0
+
+type cluster struct {
+	// bounds of the tiles of the cluster
+	bounds searchBounds
+	// center is the tile of the cluster closest to its centroid
+	center data.Position
+	// avgCost is the average tile cost of the cluster
+	avgCost float64
+	// links are the clusters reachable by crossing the border of this one
+	links []int
+}
+
+// ClusterGraph is the coarse graph of an area used by the hierarchical planner. Every room of the area is split in the
+// connected groups of passable tiles it contains, each one being a cluster, passable tiles outside the rooms are
+// grouped the same way in squares of ClusterSize. It only depends on the area layout, build it once per area and reuse
+// it for every path.
+type ClusterGraph struct {
+	width, height int
+	canTeleport   bool
+	clusters      []cluster
+	// tileCluster is the cluster of every tile, indexed by y*width+x, noCluster for tiles that can't be walked
+	tileCluster []int32
+}
+
+// NewClusterGraph builds the cluster graph of the grid from the rooms of the area, room positions are absolute like
+// the ones read from the game
+func NewClusterGraph(g *game.Grid, rooms []data.Room, canTeleport bool) *ClusterGraph {
+	cg := &ClusterGraph{
+		width:       g.Width,
+		height:      g.Height,
+		canTeleport: canTeleport,
+		tileCluster: make([]int32, g.Width*g.Height),
+	}
+
+	// Rooms first, the first room containing a tile keeps it, then the squares for the tiles no room covers
+	region := make([]int32, g.Width*g.Height)
+	for i := range region {
+		region[i] = noCluster
+		cg.tileCluster[i] = noCluster
+	}
+	for r, room := range rooms {
+		minX, minY := max(room.X-g.OffsetX, 0), max(room.Y-g.OffsetY, 0)
+		maxX, maxY := min(room.X-g.OffsetX+room.Width, g.Width)-1, min(room.Y-g.OffsetY+room.Height, g.Height)-1
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				if idx := y*g.Width + x; region[idx] == noCluster && cg.passable(g, x, y) {
+					region[idx] = int32(r)
+				}
+			}
+		}
+	}
+	squareCols := (g.Width + ClusterSize - 1) / ClusterSize
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if idx := y*g.Width + x; region[idx] == noCluster && cg.passable(g, x, y) {
+				region[idx] = int32(len(rooms) + (y/ClusterSize)*squareCols + x/ClusterSize)
+			}
+		}
+	}
+
+	// Every connected group of tiles of the same region is a cluster, so clusters can always be crossed from any of
+	// their tiles to any other without leaving them
+	var sumX, sumY, tiles []int
+	var totalCost []int
+	queue := make([]data.Position, 0, ClusterSize*ClusterSize)
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if region[y*g.Width+x] == noCluster || cg.tileCluster[y*g.Width+x] != noCluster {
+				continue
+			}
+
+			c := int32(len(cg.clusters))
+			cg.clusters = append(cg.clusters, cluster{bounds: searchBounds{minX: x, minY: y, maxX: x, maxY: y}})
+			sumX, sumY, tiles, totalCost = append(sumX, 0), append(sumY, 0), append(tiles, 0), append(totalCost, 0)
+
+			cg.tileCluster[y*g.Width+x] = c
+			queue = append(queue[:0], data.Position{X: x, Y: y})
+			for len(queue) > 0 {
+				p := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+
+				b := &cg.clusters[c].bounds
+				b.minX, b.minY, b.maxX, b.maxY = min(b.minX, p.X), min(b.minY, p.Y), max(b.maxX, p.X), max(b.maxY, p.Y)
+				sumX[c] += p.X
+				sumY[c] += p.Y
+				tiles[c]++
+				totalCost[c] += getCost(g.CollisionGrid[p.Y][p.X], canTeleport)
+
+				for _, d := range directions[:4] {
+					n := data.Position{X: p.X + d.X, Y: p.Y + d.Y}
+					if n.X < 0 || n.X >= g.Width || n.Y < 0 || n.Y >= g.Height {
+						continue
+					}
+					if idx := n.Y*g.Width + n.X; region[idx] == region[y*g.Width+x] && cg.tileCluster[idx] == noCluster {
+						cg.tileCluster[idx] = c
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+	}
+
+	centerDistance := make([]int, len(cg.clusters))
+	for c := range cg.clusters {
+		cg.clusters[c].avgCost = float64(totalCost[c]) / float64(tiles[c])
+		centerDistance[c] = math.MaxInt32
+	}
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			c := cg.tileCluster[y*g.Width+x]
+			if c == noCluster {
+				continue
+			}
+
+			// Links, diagonal moves need both orthogonal tiles to be passable, so the orthogonal neighbors find them all
+			if x+1 < g.Width {
+				cg.link(int(c), int(cg.tileCluster[y*g.Width+x+1]))
+			}
+			if y+1 < g.Height {
+				cg.link(int(c), int(cg.tileCluster[(y+1)*g.Width+x]))
+			}
+
+			// Center, the centroid itself could be outside the cluster
+			p := data.Position{X: x, Y: y}
+			centroid := data.Position{X: sumX[c] / tiles[c], Y: sumY[c] / tiles[c]}
+			if d := heuristic(p, centroid); d < centerDistance[c] {
+				centerDistance[c] = d
+				cg.clusters[c].center = p
+			}
+		}
+	}
+
+	return cg
+}
+
+func (cg *ClusterGraph) passable(g *game.Grid, x, y int) bool {
+	tileType := g.CollisionGrid[y][x]
+	return tileType != game.CollisionTypeNonWalkable && (cg.canTeleport || tileType != game.CollisionTypeTeleportOver)
+}
+
+func (cg *ClusterGraph) link(a, b int) {
+	if b == noCluster || a == b {
+		return
+	}
+	for _, l := range cg.clusters[a].links {
+		if l == b {
+			return
+		}
+	}
+	cg.clusters[a].links = append(cg.clusters[a].links, b)
+	cg.clusters[b].links = append(cg.clusters[b].links, a)
+}
+
+// Matches tells whether the graph was built for a grid of the same size and the same teleport ability
+func (cg *ClusterGraph) Matches(g *game.Grid, canTeleport bool) bool {
+	return cg != nil && cg.width == g.Width && cg.height == g.Height && cg.canTeleport == canTeleport
+}
+
+func (cg *ClusterGraph) clusterAt(p data.Position) int {
+	if p.X < 0 || p.X >= cg.width || p.Y < 0 || p.Y >= cg.height {
+		return noCluster
+	}
+	return int(cg.tileCluster[p.Y*cg.width+p.X])
+}
+
+// CalculateHierarchicalPath plans first a route over the cluster graph of the area and then refines it with A*
+// searches that only expand a few clusters of the route at a time. On big outdoor areas this is much faster than
+// CalculatePath, at the cost of not always returning the optimal path. It shares the same tile costs than
+// CalculatePath. The graph must be built from the same area as the grid, objects and monsters added to the grid
+// afterwards are fine.
+func CalculateHierarchicalPath(g *game.Grid, cg *ClusterGraph, start, goal data.Position, canTeleport bool) ([]data.Position, int, bool) {
+	if !cg.Matches(g, canTeleport) {
+		return CalculatePath(g, start, goal, canTeleport)
+	}
+
+	from, to := cg.clusterAt(start), cg.clusterAt(goal)
+	if from == noCluster || to == noCluster {
+		// Start or goal are not passable in the area layout, only the full search knows how to handle it
+		return CalculatePath(g, start, goal, canTeleport)
+	}
+
+	route, found := cg.route(from, to)
+	if !found {
+		// Every passable tile belongs to a cluster, no route means there is no path
+		return nil, 0, false
+	}
+
+	if path, found := cg.refine(g, route, start, goal, canTeleport); found {
+		return path, len(path), true
+	}
+
+	// Tiles blocked after building the graph, like the barricade towers, can cut a cluster in two
+	return CalculatePath(g, start, goal, canTeleport)
+}
+
+// refine walks the route from start to goal. Each step searches from the current position to the center of the
+// cluster refineWindow clusters ahead, expanding only the route clusters in between and the clusters next to them, and
+// moves to the point where that path enters the target cluster, so the path never has to go through the centers.
+func (cg *ClusterGraph) refine(g *game.Grid, route []int, start, goal data.Position, canTeleport bool) ([]data.Position, bool) {
+	// Position of every cluster in the route, -1 for the clusters outside of it
+	routeIdx := make([]int, len(cg.clusters))
+	for i := range routeIdx {
+		routeIdx[i] = -1
+	}
+	for i, c := range route {
+		routeIdx[c] = i
+	}
+
+	inWindow := make([]bool, len(cg.clusters))
+	allowed := func(x, y int) bool {
+		c := cg.tileCluster[y*cg.width+x]
+		return c != noCluster && inWindow[c]
+	}
+
+	path := []data.Position{start}
+	current := start
+	for i := 0; ; {
+		last := min(i+refineWindow, len(route)-1)
+		target := goal
+		if last < len(route)-1 {
+			target = cg.clusters[route[last]].center
+		}
+
+		var window []int
+		for _, c := range route[i : last+1] {
+			window = append(window, c)
+			window = append(window, cg.clusters[c].links...)
+		}
+		bounds := cg.clusters[route[i]].bounds
+		for _, c := range window {
+			inWindow[c] = true
+			bounds = bounds.union(cg.clusters[c].bounds)
+		}
+		segment, _, found := calculatePath(g, current, target, canTeleport, bounds, allowed, refineHeuristicWeight)
+		for _, c := range window {
+			inWindow[c] = false
+		}
+
+		if !found {
+			return nil, false
+		}
+		if last == len(route)-1 {
+			return append(path, segment[1:]...), true
+		}
+
+		for _, p := range segment[1:] {
+			path = append(path, p)
+			if idx := routeIdx[cg.clusterAt(p)]; idx >= last {
+				current, i = p, idx
+				break
+			}
+		}
+	}
+}
+
+// route finds the cheapest sequence of clusters from one cluster to another, moving between clusters costs the
+// distance between their centers weighted by their average tile cost
+func (cg *ClusterGraph) route(from, to int) ([]int, bool) {
+	costSoFar := make([]int, len(cg.clusters))
+	cameFrom := make([]int, len(cg.clusters))
+	for i := range costSoFar {
+		costSoFar[i] = math.MaxInt32
+		cameFrom[i] = -1
+	}
+
+	goal := cg.clusters[to].center
+	pq := make(PriorityQueue, 0)
+	pq.Push(Node{Position: data.Position{X: from}, Priority: heuristic(cg.clusters[from].center, goal)})
+	costSoFar[from] = 0
+
+	for pq.Len() > 0 {
+		current := pq.Pop()
+		// Nodes of the cluster graph store the cluster index in X
+		currentIdx := current.X
+
+		if currentIdx == to {
+			route := []int{to}
+			for idx := cameFrom[currentIdx]; idx != -1; idx = cameFrom[idx] {
+				route = append(route, idx)
+			}
+			slices.Reverse(route)
+			return route, true
+		}
+
+		// Outdated entry, a cheaper one was already processed
+		if current.Cost > costSoFar[currentIdx] {
+			continue
+		}
+
+		for _, neighborIdx := range cg.clusters[currentIdx].links {
+			a, b := cg.clusters[currentIdx], cg.clusters[neighborIdx]
+			edgeCost := max(int(math.Ceil(float64(heuristic(a.center, b.center))*(a.avgCost+b.avgCost)/2)), 1)
+			newCost := costSoFar[currentIdx] + edgeCost
+			if newCost < costSoFar[neighborIdx] {
+				costSoFar[neighborIdx] = newCost
+				cameFrom[neighborIdx] = currentIdx
+				pq.Push(Node{Position: data.Position{X: neighborIdx}, Cost: newCost, Priority: newCost + heuristic(b.center, goal)})
+			}
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,213 @@
+package astar
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// outdoorRoomSize is the size of the rooms of the outdoor areas in tiles
+const outdoorRoomSize = 40
+
+// outdoorArea is a generated outdoor level, recorded map data can't be produced without the game running, so these
+// areas are built from a fixed seed to look like the real ones: a grid of rooms, scattered obstacles and walls that
+// can only be crossed through a few passes
+type outdoorArea struct {
+	grid        *game.Grid
+	rooms       []data.Room
+	start, goal data.Position
+}
+
+// act1OutdoorArea is a wide Blood Moor like area crossed by a river with a few bridges
+func act1OutdoorArea() outdoorArea {
+	rnd := rand.New(rand.NewSource(1))
+	raw := outdoorCollisionGrid(rnd, 30, 10)
+
+	for x := 0; x < len(raw[0]); x++ {
+		for y := 180; y < 190; y++ {
+			raw[y][x] = game.CollisionTypeNonWalkable
+		}
+		if x%300 >= 150 && x%300 < 160 {
+			for y := 180; y < 190; y++ {
+				raw[y][x] = game.CollisionTypeWalkable
+			}
+		}
+	}
+
+	return newOutdoorArea(raw, data.Position{X: 20, Y: 20}, data.Position{X: len(raw[0]) - 20, Y: len(raw) - 20})
+}
+
+// act5OutdoorArea is a tall Frigid Highlands like area split by cliffs, each cliff has a single pass
+func act5OutdoorArea() outdoorArea {
+	rnd := rand.New(rand.NewSource(5))
+	raw := outdoorCollisionGrid(rnd, 16, 30)
+
+	for i, y := range []int{250, 500, 750, 1000} {
+		pass := 40 + (i%2)*500
+		for x := 0; x < len(raw[0]); x++ {
+			if x >= pass && x < pass+12 {
+				continue
+			}
+			for dy := 0; dy < 6; dy++ {
+				raw[y+dy][x] = game.CollisionTypeNonWalkable
+			}
+		}
+	}
+
+	return newOutdoorArea(raw, data.Position{X: len(raw[0]) - 20, Y: 20}, data.Position{X: 20, Y: len(raw) - 20})
+}
+
+func outdoorCollisionGrid(rnd *rand.Rand, roomsX, roomsY int) [][]game.CollisionType {
+	raw := make([][]game.CollisionType, roomsY*outdoorRoomSize)
+	for y := range raw {
+		raw[y] = make([]game.CollisionType, roomsX*outdoorRoomSize)
+		for x := range raw[y] {
+			if x > 0 && y > 0 && x < len(raw[y])-1 && y < len(raw)-1 {
+				raw[y][x] = game.CollisionTypeWalkable
+			}
+		}
+	}
+
+	// Trees, rocks and ruins, about one per room
+	for i := 0; i < roomsX*roomsY; i++ {
+		cx, cy, r := rnd.Intn(len(raw[0])), rnd.Intn(len(raw)), 2+rnd.Intn(6)
+		for y := max(cy-r, 0); y <= min(cy+r, len(raw)-1); y++ {
+			for x := max(cx-r, 0); x <= min(cx+r, len(raw[0])-1); x++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r {
+					raw[y][x] = game.CollisionTypeNonWalkable
+				}
+			}
+		}
+	}
+
+	return raw
+}
+
+func newOutdoorArea(raw [][]game.CollisionType, start, goal data.Position) outdoorArea {
+	// Clear the spots around start and goal so the obstacles can't block them
+	for _, p := range []data.Position{start, goal} {
+		for y := p.Y - 3; y <= p.Y+3; y++ {
+			for x := p.X - 3; x <= p.X+3; x++ {
+				raw[y][x] = game.CollisionTypeWalkable
+			}
+		}
+	}
+
+	// Offsets like the real ones, rooms are absolute positions
+	const offsetX, offsetY = 5000, 5600
+	var rooms []data.Room
+	for y := 0; y < len(raw); y += outdoorRoomSize {
+		for x := 0; x < len(raw[0]); x += outdoorRoomSize {
+			rooms = append(rooms, data.Room{
+				Position: data.Position{X: offsetX + x, Y: offsetY + y},
+				Width:    outdoorRoomSize,
+				Height:   outdoorRoomSize,
+			})
+		}
+	}
+
+	return outdoorArea{grid: game.NewGrid(raw, offsetX, offsetY, false), rooms: rooms, start: start, goal: goal}
+}
+
+func TestHierarchicalOutdoor(t *testing.T) {
+	for name, area := range map[string]outdoorArea{"act1": act1OutdoorArea(), "act5": act5OutdoorArea()} {
+		t.Run(name, func(t *testing.T) {
+			cg := NewClusterGraph(area.grid, area.rooms, false)
+			p, dist, found := CalculateHierarchicalPath(area.grid, cg, area.start, area.goal, false)
+			if !found {
+				t.Fatalf("Expected path to be found")
+			}
+			checkPath(t, area.grid, p, area.start, area.goal)
+
+			_, astarDist, _ := CalculatePath(area.grid, area.start, area.goal, false)
+			if float64(dist) > float64(astarDist)*1.1 {
+				t.Errorf("Expected distance to be close to %d, got %d", astarDist, dist)
+			}
+		})
+	}
+}
+
+func TestHierarchicalUnreachable(t *testing.T) {
+	area := act5OutdoorArea()
+	// Close the pass of the last cliff
+	for y := 1000; y < 1006; y++ {
+		for x := 0; x < area.grid.Width; x++ {
+			area.grid.CollisionGrid[y][x] = game.CollisionTypeNonWalkable
+		}
+	}
+
+	cg := NewClusterGraph(area.grid, area.rooms, false)
+	if _, _, found := CalculateHierarchicalPath(area.grid, cg, area.start, area.goal, false); found {
+		t.Errorf("Expected no path across the closed cliff")
+	}
+}
+
+func TestClusterGraph(t *testing.T) {
+	area := act1OutdoorArea()
+
+	for name, regionSize := range map[string]int{"rooms": outdoorRoomSize, "squares": ClusterSize} {
+		t.Run(name, func(t *testing.T) {
+			rooms := area.rooms
+			if name == "squares" {
+				rooms = nil
+			}
+			cg := NewClusterGraph(area.grid, rooms, false)
+
+			// Clusters never span more than a room or a square
+			for _, c := range cg.clusters {
+				if c.bounds.minX/regionSize != c.bounds.maxX/regionSize || c.bounds.minY/regionSize != c.bounds.maxY/regionSize {
+					t.Fatalf("Expected cluster %+v to fit in a single region of %d tiles", c.bounds, regionSize)
+				}
+				if cg.clusterAt(c.center) == noCluster {
+					t.Fatalf("Expected cluster center %v to be one of its tiles", c.center)
+				}
+			}
+
+			for y := 0; y < area.grid.Height; y++ {
+				for x := 0; x < area.grid.Width; x++ {
+					passable := area.grid.CollisionGrid[y][x] != game.CollisionTypeNonWalkable
+					if hasCluster := cg.clusterAt(data.Position{X: x, Y: y}) != noCluster; passable != hasCluster {
+						t.Fatalf("Expected passable tiles and only them to belong to a cluster, tile %d,%d", x, y)
+					}
+				}
+			}
+		})
+	}
+
+	if NewClusterGraph(area.grid, area.rooms, false).Matches(area.grid, true) {
+		t.Errorf("Expected the graph not to match a teleporting character")
+	}
+}
+
+func BenchmarkAstarOutdoor(b *testing.B) {
+	area := act1OutdoorArea()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CalculatePath(area.grid, area.start, area.goal, false)
+	}
+}
+
+func BenchmarkHierarchicalOutdoor(b *testing.B) {
+	for name, area := range map[string]outdoorArea{"act1": act1OutdoorArea(), "act5": act5OutdoorArea()} {
+		b.Run(name, func(b *testing.B) {
+			cg := NewClusterGraph(area.grid, area.rooms, false)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				CalculateHierarchicalPath(area.grid, cg, area.start, area.goal, false)
+			}
+		})
+	}
+}
+
+func BenchmarkClusterGraph(b *testing.B) {
+	area := act1OutdoorArea()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewClusterGraph(area.grid, area.rooms, false)
+	}
+}
@@ -1,25 +1,53 @@
 package astar
 
-type PriorityQueue []*Node
+// PriorityQueue is a binary min-heap of nodes ordered by priority, it follows the same ordering than container/heap
+// without boxing the nodes
+type PriorityQueue []Node
 
 func (pq PriorityQueue) Len() int           { return len(pq) }
-func (pq PriorityQueue) Less(i, j int) bool { return pq[i].Priority < pq[j].Priority }
-func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].Index = i
-	pq[j].Index = j
-}
-func (pq *PriorityQueue) Push(x interface{}) {
-	n := x.(*Node)
-	n.Index = len(*pq)
+func (pq PriorityQueue) less(i, j int) bool { return pq[i].Priority < pq[j].Priority }
+
+func (pq *PriorityQueue) Push(n Node) {
 	*pq = append(*pq, n)
+	pq.up(len(*pq) - 1)
 }
-func (pq *PriorityQueue) Pop() interface{} {
+
+func (pq *PriorityQueue) Pop() Node {
 	old := *pq
-	n := len(old)
-	node := old[n-1]
-	old[n-1] = nil
-	node.Index = -1
-	*pq = old[0 : n-1]
+	n := len(old) - 1
+	old[0], old[n] = old[n], old[0]
+	old[:n].down(0)
+	node := old[n]
+	*pq = old[:n]
 	return node
 }
+
+func (pq PriorityQueue) up(j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || !pq.less(j, i) {
+			break
+		}
+		pq[i], pq[j] = pq[j], pq[i]
+		j = i
+	}
+}
+
+func (pq PriorityQueue) down(i int) {
+	n := len(pq)
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 { // j1 < 0 after int overflow
+			break
+		}
+		j := j1 // left child
+		if j2 := j1 + 1; j2 < n && pq.less(j2, j1) {
+			j = j2 // right child
+		}
+		if !pq.less(j, i) {
+			break
+		}
+		pq[i], pq[j] = pq[j], pq[i]
+		i = j
+	}
+}
@@ -0,0 +1,270 @@
+package danger
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// Source is something dangerous at the given position, e.g. a monster. Its danger decreases linearly with the
+// distance, from Weight on its position to nothing past Radius.
+type Source struct {
+	Position data.Position
+	Weight   float64
+	Radius   int
+}
+
+// Field is the danger of every tile in a square window of the area grid, positions are absolute
+type Field struct {
+	grid    *game.Grid
+	sources []Source
+	minX    int
+	minY    int
+	size    int
+	danger  []float64
+}
+
+// NewField computes the danger around center, up to radius tiles away, from all the sources
+func NewField(grid *game.Grid, center data.Position, radius int, sources []Source) *Field {
+	f := &Field{
+		grid:    grid,
+		sources: sources,
+		minX:    center.X - radius,
+		minY:    center.Y - radius,
+		size:    radius*2 + 1,
+	}
+	f.danger = make([]float64, f.size*f.size)
+
+	for _, s := range sources {
+		if s.Radius <= 0 || s.Weight <= 0 {
+			continue
+		}
+		for y := max(s.Position.Y-s.Radius, f.minY); y <= min(s.Position.Y+s.Radius, f.minY+f.size-1); y++ {
+			for x := max(s.Position.X-s.Radius, f.minX); x <= min(s.Position.X+s.Radius, f.minX+f.size-1); x++ {
+				d := distance(s.Position, data.Position{X: x, Y: y})
+				if d > float64(s.Radius) {
+					continue
+				}
+				f.danger[(y-f.minY)*f.size+x-f.minX] += s.Weight * (1 - d/float64(s.Radius+1))
+			}
+		}
+	}
+
+	return f
+}
+
+// At returns the danger of the position, positions out of the window have no danger
+func (f *Field) At(p data.Position) float64 {
+	idx, inside := f.index(p)
+	if !inside {
+		return 0
+	}
+	return f.danger[idx]
+}
+
+// Search describes the position we are looking for
+type Search struct {
+	From data.Position
+	// Target must be between MinRange and MaxRange tiles from the position, and in line of sight when LineOfSight
+	Target      data.Position
+	MinRange    int
+	MaxRange    int
+	LineOfSight bool
+	// MaxDistance is how far from From the position can be, walking steps or teleport distance
+	MaxDistance int
+	// MinSourceDistance keeps the position at least that far from every source
+	MinSourceDistance int
+	// Teleport only takes into account the danger of the destination, walking adds the danger of every tile crossed
+	Teleport bool
+	// DestinationWeight is how much the danger of the position counts compared to the danger crossed to reach it,
+	// MoveCost is the cost of every tile moved
+	DestinationWeight float64
+	MoveCost          float64
+}
+
+// SafePosition returns the position with the lowest accumulated danger matching the search, along with its cost.
+// Results are deterministic, ties go to the closest position and then to the top-left one.
+func (f *Field) SafePosition(s Search) (data.Position, float64, bool) {
+	steps, crossed := f.reach(s)
+
+	best := -1
+	bestCost := math.Inf(1)
+	for idx := range f.danger {
+		if steps[idx] < 0 {
+			continue
+		}
+		p := data.Position{X: f.minX + idx%f.size, Y: f.minY + idx/f.size}
+		if !f.candidate(p, s) {
+			continue
+		}
+
+		cost := s.DestinationWeight*f.danger[idx] + crossed[idx] + s.MoveCost*float64(steps[idx])
+		if cost < bestCost || (cost == bestCost && steps[idx] < steps[best]) {
+			best, bestCost = idx, cost
+		}
+	}
+
+	if best < 0 {
+		return data.Position{}, 0, false
+	}
+
+	return data.Position{X: f.minX + best%f.size, Y: f.minY + best/f.size}, bestCost, true
+}
+
+func (f *Field) candidate(p data.Position, s Search) bool {
+	if !f.grid.IsWalkable(p) {
+		return false
+	}
+	if d := int(distance(p, s.Target)); d < s.MinRange || d > s.MaxRange {
+		return false
+	}
+	for _, src := range f.sources {
+		if distance(p, src.Position) < float64(s.MinSourceDistance) {
+			return false
+		}
+	}
+
+	return !s.LineOfSight || f.lineOfSight(p, s.Target)
+}
+
+// reach returns, for every tile of the window, the steps to reach it from s.From (-1 when it can't be reached) and the
+// danger crossed on the way. Teleporting reaches any tile within MaxDistance without crossing danger, walking follows
+// the path crossing the least danger.
+func (f *Field) reach(s Search) ([]int, []float64) {
+	steps := make([]int, len(f.danger))
+	crossed := make([]float64, len(f.danger))
+	for i := range steps {
+		steps[i] = -1
+	}
+
+	if s.Teleport {
+		for idx := range steps {
+			p := data.Position{X: f.minX + idx%f.size, Y: f.minY + idx/f.size}
+			if d := distance(s.From, p); d <= float64(s.MaxDistance) {
+				steps[idx] = int(d)
+			}
+		}
+		return steps, crossed
+	}
+
+	start, inside := f.index(s.From)
+	if !inside {
+		return steps, crossed
+	}
+	for i := range crossed {
+		crossed[i] = math.Inf(1)
+	}
+	steps[start] = 0
+	crossed[start] = 0
+
+	pq := &tileQueue{{idx: start}}
+	for pq.Len() > 0 {
+		current := heap.Pop(pq).(tile)
+		if current.crossed > crossed[current.idx] || current.steps >= s.MaxDistance {
+			continue
+		}
+		x, y := current.idx%f.size, current.idx/f.size
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := x+dx, y+dy
+				if (dx == 0 && dy == 0) || nx < 0 || ny < 0 || nx >= f.size || ny >= f.size {
+					continue
+				}
+				if !f.grid.IsWalkable(data.Position{X: f.minX + nx, Y: f.minY + ny}) {
+					continue
+				}
+				next := ny*f.size + nx
+				c := current.crossed + f.danger[next]
+				if c < crossed[next] || (c == crossed[next] && current.steps+1 < steps[next]) {
+					crossed[next] = c
+					steps[next] = current.steps + 1
+					heap.Push(pq, tile{idx: next, crossed: c, steps: current.steps + 1})
+				}
+			}
+		}
+	}
+
+	return steps, crossed
+}
+
+// lineOfSight walks the line between both positions, every tile must be walkable
+func (f *Field) lineOfSight(from, to data.Position) bool {
+	dx, dy := abs(to.X-from.X), abs(to.Y-from.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+
+	err := dx - dy
+	x, y := from.X, from.Y
+	for {
+		if !f.grid.IsWalkable(data.Position{X: x, Y: y}) {
+			return false
+		}
+		if x == to.X && y == to.Y {
+			return true
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x += sx
+		}
+		if e2 < dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+func (f *Field) index(p data.Position) (int, bool) {
+	x, y := p.X-f.minX, p.Y-f.minY
+	if x < 0 || y < 0 || x >= f.size || y >= f.size {
+		return 0, false
+	}
+	return y*f.size + x, true
+}
+
+type tile struct {
+	idx     int
+	crossed float64
+	steps   int
+}
+
+// tileQueue pops the tile with the least danger crossed, ties are broken by steps and index to stay deterministic
+type tileQueue []tile
+
+func (q tileQueue) Len() int { return len(q) }
+func (q tileQueue) Less(i, j int) bool {
+	if q[i].crossed != q[j].crossed {
+		return q[i].crossed < q[j].crossed
+	}
+	if q[i].steps != q[j].steps {
+		return q[i].steps < q[j].steps
+	}
+	return q[i].idx < q[j].idx
+}
+func (q tileQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *tileQueue) Push(x interface{}) { *q = append(*q, x.(tile)) }
+func (q *tileQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+func distance(a, b data.Position) float64 {
+	return math.Sqrt(float64((a.X-b.X)*(a.X-b.X) + (a.Y-b.Y)*(a.Y-b.Y)))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
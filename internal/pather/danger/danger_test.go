@@ -0,0 +1,122 @@
+package danger
+
+import (
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+func TestFieldFalloff(t *testing.T) {
+	grid := openGrid(41, 41)
+	monster := data.Position{X: 20, Y: 20}
+	f := NewField(grid, monster, 15, []Source{{Position: monster, Weight: 10, Radius: 6}})
+
+	if f.At(monster) != 10 {
+		t.Errorf("Expected the full weight on the source, got %v", f.At(monster))
+	}
+	if near, far := f.At(data.Position{X: 22, Y: 20}), f.At(data.Position{X: 25, Y: 20}); near <= far || far <= 0 {
+		t.Errorf("Expected the danger to decrease with the distance, got %v and %v", near, far)
+	}
+	if d := f.At(data.Position{X: 27, Y: 20}); d != 0 {
+		t.Errorf("Expected no danger past the radius, got %v", d)
+	}
+}
+
+func TestSafePositionWithinRange(t *testing.T) {
+	grid := openGrid(41, 41)
+	player := data.Position{X: 20, Y: 20}
+	monster := data.Position{X: 24, Y: 20}
+	f := NewField(grid, player, 15, []Source{{Position: monster, Weight: 10, Radius: 6}})
+
+	search := Search{From: player, Target: monster, MinRange: 6, MaxRange: 12, LineOfSight: true, MaxDistance: 10, DestinationWeight: 10, MoveCost: 0.5}
+	pos, _, found := f.SafePosition(search)
+	if !found {
+		t.Fatalf("Expected a safe position to be found")
+	}
+	if f.At(pos) != 0 {
+		t.Errorf("Expected a position without danger, got %v with danger %v", pos, f.At(pos))
+	}
+	if d := int(distance(pos, monster)); d < 6 || d > 12 {
+		t.Errorf("Expected the position to be in attack range, got distance %d", d)
+	}
+
+	again, _, _ := f.SafePosition(search)
+	if again != pos {
+		t.Errorf("Expected the same position for the same field, got %v and %v", pos, again)
+	}
+}
+
+func TestSafePositionAvoidsStrongerThreat(t *testing.T) {
+	grid := openGrid(41, 41)
+	player := data.Position{X: 20, Y: 20}
+	weak := data.Position{X: 20, Y: 14}
+	aura := data.Position{X: 20, Y: 26}
+	f := NewField(grid, player, 20, []Source{
+		{Position: weak, Weight: 10, Radius: 6},
+		{Position: aura, Weight: 40, Radius: 12},
+	})
+
+	pos, _, found := f.SafePosition(Search{From: player, Target: weak, MinRange: 1, MaxRange: 15, MaxDistance: 12, DestinationWeight: 10, MoveCost: 0.5})
+	if !found {
+		t.Fatalf("Expected a safe position to be found")
+	}
+	if distance(pos, aura) <= distance(pos, weak) {
+		t.Errorf("Expected the position to be closer to the weak monster than to the aura, got %v", pos)
+	}
+}
+
+func TestSafePositionWalkingAvoidsDanger(t *testing.T) {
+	grid := openGrid(41, 41)
+	player := data.Position{X: 10, Y: 20}
+	monster := data.Position{X: 20, Y: 20}
+	target := data.Position{X: 30, Y: 20}
+	f := NewField(grid, player, 20, []Source{{Position: monster, Weight: 50, Radius: 5}})
+
+	// Only the tiles around the target are valid, walking must go around the monster instead of through it
+	search := Search{From: player, Target: target, MinRange: 0, MaxRange: 1, MaxDistance: 40, DestinationWeight: 10, MoveCost: 0.1}
+	_, walkCost, found := f.SafePosition(search)
+	if !found {
+		t.Fatalf("Expected a position to be found")
+	}
+	if walkCost >= 50 {
+		t.Errorf("Expected the walk to avoid the danger around the monster, got cost %v", walkCost)
+	}
+}
+
+func TestSafePositionTeleportOverWalls(t *testing.T) {
+	// 30x9 area with a wall at x=10 that can only be teleported over
+	grid := openGrid(30, 9)
+	for y := 0; y < grid.Height; y++ {
+		grid.CollisionGrid[y][10] = game.CollisionTypeTeleportOver
+	}
+	player := data.Position{X: 4, Y: 4}
+	monster := data.Position{X: 6, Y: 4}
+	f := NewField(grid, player, 15, []Source{{Position: monster, Weight: 10, Radius: 6}})
+
+	search := Search{From: player, Target: monster, MinRange: 0, MaxRange: 20, MaxDistance: 15, MinSourceDistance: 8, DestinationWeight: 10, MoveCost: 0.5}
+	if _, _, found := f.SafePosition(search); found {
+		t.Errorf("Expected no position reachable walking")
+	}
+
+	search.Teleport = true
+	pos, _, found := f.SafePosition(search)
+	if !found {
+		t.Fatalf("Expected a position to be found teleporting")
+	}
+	if pos.X <= 10 {
+		t.Errorf("Expected the position to be past the wall, got %v", pos)
+	}
+}
+
+func openGrid(width, height int) *game.Grid {
+	cg := make([][]game.CollisionType, height)
+	for y := range cg {
+		cg[y] = make([]game.CollisionType, width)
+		for x := range cg[y] {
+			cg[y][x] = game.CollisionTypeWalkable
+		}
+	}
+
+	return &game.Grid{Width: width, Height: height, CollisionGrid: cg}
+}
@@ -3,6 +3,7 @@ package pather
 import (
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/area"
@@ -12,11 +13,26 @@ import (
 	"github.com/hectorgimenez/koolo/internal/pather/astar"
 )
 
+// Planner selects the algorithm used to calculate a path
+type Planner int
+
+const (
+	// PlannerDefault uses the hierarchical planner for walking characters when enabled in the character config, A* otherwise
+	PlannerDefault Planner = iota
+	PlannerAStar
+	PlannerHierarchical
+)
+
 type PathFinder struct {
 	gr   *game.MemoryReader
 	data *game.Data
 	hid  *game.HID
 	cfg  *config.CharacterCfg
+
+	// Cluster graph of the current area used by the hierarchical planner, it only changes when the area grid does
+	clusterGraphMu   sync.Mutex
+	clusterGraph     *astar.ClusterGraph
+	clusterGraphGrid *game.Grid
 }
 
 func NewPathFinder(gr *game.MemoryReader, data *game.Data, hid *game.HID, cfg *config.CharacterCfg) *PathFinder {
@@ -48,6 +64,10 @@ func (pf *PathFinder) GetPath(to data.Position) (Path, int, bool) {
 }
 
 func (pf *PathFinder) GetPathFrom(from, to data.Position) (Path, int, bool) {
+	return pf.GetPathFromWithPlanner(from, to, PlannerDefault)
+}
+
+func (pf *PathFinder) GetPathFromWithPlanner(from, to data.Position, planner Planner) (Path, int, bool) {
 	a := pf.data.AreaData
 	canTeleport := pf.data.CanTeleport()
 
@@ -70,12 +90,14 @@ func (pf *PathFinder) GetPathFrom(from, to data.Position) (Path, int, bool) {
 		a.CollisionGrid[13][210] = game.CollisionTypeNonWalkable
 	}
 
+	merged := false
 	if !a.IsInside(to) {
 		expandedGrid, err := pf.mergeGrids(to, canTeleport)
 		if err != nil {
 			return nil, 0, false
 		}
 		grid = expandedGrid
+		merged = true
 	}
 
 	if !grid.IsWalkable(to) {
@@ -150,7 +172,15 @@ func (pf *PathFinder) GetPathFrom(from, to data.Position) (Path, int, bool) {
 		}
 	}
 
-	path, distance, found := astar.CalculatePath(grid, from, to, canTeleport)
+	var path []data.Position
+	var distance int
+	var found bool
+	// The cluster graph only covers the current area, paths into an adjacent area use the full search
+	if pf.resolvePlanner(planner, canTeleport) == PlannerHierarchical && !merged {
+		path, distance, found = astar.CalculateHierarchicalPath(grid, pf.areaClusterGraph(a, canTeleport), from, to, canTeleport)
+	} else {
+		path, distance, found = astar.CalculatePath(grid, from, to, canTeleport)
+	}
 
 	if config.Koolo.Debug.RenderMap {
 		pf.renderMap(grid, from, to, path)
@@ -159,6 +189,32 @@ func (pf *PathFinder) GetPathFrom(from, to data.Position) (Path, int, bool) {
 	return path, distance, found
 }
 
+func (pf *PathFinder) resolvePlanner(planner Planner, canTeleport bool) Planner {
+	if planner != PlannerDefault {
+		return planner
+	}
+
+	// Teleport paths are short enough already, hierarchical planning only pays off on long walks
+	if !canTeleport && pf.cfg.Character.UseHierarchicalPathing {
+		return PlannerHierarchical
+	}
+
+	return PlannerAStar
+}
+
+// areaClusterGraph returns the cluster graph of the area, building it only the first time the area grid is used
+func (pf *PathFinder) areaClusterGraph(a game.AreaData, canTeleport bool) *astar.ClusterGraph {
+	pf.clusterGraphMu.Lock()
+	defer pf.clusterGraphMu.Unlock()
+
+	if pf.clusterGraphGrid != a.Grid || !pf.clusterGraph.Matches(a.Grid, canTeleport) {
+		pf.clusterGraph = astar.NewClusterGraph(a.Grid, a.Rooms, canTeleport)
+		pf.clusterGraphGrid = a.Grid
+	}
+
+	return pf.clusterGraph
+}
+
 func (pf *PathFinder) mergeGrids(to data.Position, canTeleport bool) (*game.Grid, error) {
 	for _, a := range pf.data.AreaData.AdjacentLevels {
 		destination := pf.data.Areas[a.Area]
@@ -0,0 +1,199 @@
+//go:build ignore
+
+// generate writes the area fixtures used by the traverse tests. The areas are generated, not recorded from the game:
+// they follow the layout of the real caves (a grid of rooms joined by tunnels, chambers in every room) from a fixed
+// seed, so running it again writes the same files.
+//
+//	go run testdata/generate.go
+package main
+
+import (
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// fixture must match the one decoded by the tests
+type fixture struct {
+	Grid  game.Grid
+	Rooms []data.Room
+	Start data.Position
+}
+
+type cave struct {
+	file           string
+	seed           int64
+	roomsX, roomsY int
+	roomSize       int
+	// chamber radius range and tunnel width, in tiles
+	minChamber, maxChamber int
+	tunnelWidth            int
+	// loops is the chance of joining two rooms that are not joined by the maze, more loops means more ways around
+	loops            float64
+	offsetX, offsetY int
+}
+
+func main() {
+	caves := []cave{
+		// Long narrow tunnels with small chambers and few loops
+		{file: "maggot_lair_level_1.bin", seed: 62, roomsX: 12, roomsY: 8, roomSize: 24, minChamber: 3, maxChamber: 6, tunnelWidth: 3, loops: 0.05, offsetX: 11000, offsetY: 8000},
+		// Wide chambers and tunnels with some loops
+		{file: "pit_level_1.bin", seed: 12, roomsX: 8, roomsY: 8, roomSize: 40, minChamber: 8, maxChamber: 15, tunnelWidth: 5, loops: 0.2, offsetX: 20000, offsetY: 4800},
+	}
+
+	for _, c := range caves {
+		if err := write(filepath.Join("testdata", c.file), c.generate()); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (c cave) generate() fixture {
+	rnd := rand.New(rand.NewSource(c.seed))
+	width, height := c.roomsX*c.roomSize, c.roomsY*c.roomSize
+	raw := make([][]game.CollisionType, height)
+	for y := range raw {
+		raw[y] = make([]game.CollisionType, width)
+	}
+
+	// Room centers are moved a bit so tunnels are not straight lines
+	centers := make([]data.Position, c.roomsX*c.roomsY)
+	for i := range centers {
+		jitter := c.roomSize / 5
+		centers[i] = data.Position{
+			X: (i%c.roomsX)*c.roomSize + c.roomSize/2 + rnd.Intn(2*jitter+1) - jitter,
+			Y: (i/c.roomsX)*c.roomSize + c.roomSize/2 + rnd.Intn(2*jitter+1) - jitter,
+		}
+	}
+
+	// Randomized depth first maze over the rooms, the entrance is the top left one
+	visited := make([]bool, len(centers))
+	stack := []int{0}
+	visited[0] = true
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		next := make([]int, 0, 4)
+		for _, n := range c.neighbors(current) {
+			if !visited[n] {
+				next = append(next, n)
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[rnd.Intn(len(next))]
+		visited[n] = true
+		c.tunnel(raw, rnd, centers[current], centers[n])
+		stack = append(stack, n)
+	}
+	for room := range centers {
+		for _, n := range c.neighbors(room) {
+			if n > room && rnd.Float64() < c.loops {
+				c.tunnel(raw, rnd, centers[room], centers[n])
+			}
+		}
+	}
+
+	for _, center := range centers {
+		c.chamber(raw, rnd, center)
+	}
+
+	rooms := make([]data.Room, 0, len(centers))
+	for i := range centers {
+		rooms = append(rooms, data.Room{
+			Position: data.Position{X: c.offsetX + (i%c.roomsX)*c.roomSize, Y: c.offsetY + (i/c.roomsX)*c.roomSize},
+			Width:    c.roomSize,
+			Height:   c.roomSize,
+		})
+	}
+
+	grid := game.NewGrid(raw, c.offsetX, c.offsetY, false)
+
+	return fixture{
+		Grid:  *grid,
+		Rooms: rooms,
+		Start: data.Position{X: c.offsetX + centers[0].X, Y: c.offsetY + centers[0].Y},
+	}
+}
+
+func (c cave) neighbors(room int) []int {
+	x, y := room%c.roomsX, room/c.roomsX
+	neighbors := make([]int, 0, 4)
+	if x > 0 {
+		neighbors = append(neighbors, room-1)
+	}
+	if x < c.roomsX-1 {
+		neighbors = append(neighbors, room+1)
+	}
+	if y > 0 {
+		neighbors = append(neighbors, room-c.roomsX)
+	}
+	if y < c.roomsY-1 {
+		neighbors = append(neighbors, room+c.roomsX)
+	}
+
+	return neighbors
+}
+
+// tunnel carves a winding tunnel between two room centers
+func (c cave) tunnel(raw [][]game.CollisionType, rnd *rand.Rand, from, to data.Position) {
+	steps := max(abs(to.X-from.X), abs(to.Y-from.Y))
+	// The tunnel bends towards a random point between both rooms
+	bendX := float64(rnd.Intn(c.roomSize/2+1) - c.roomSize/4)
+	bendY := float64(rnd.Intn(c.roomSize/2+1) - c.roomSize/4)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		bend := math.Sin(t * math.Pi)
+		x := int(math.Round(float64(from.X) + t*float64(to.X-from.X) + bend*bendX))
+		y := int(math.Round(float64(from.Y) + t*float64(to.Y-from.Y) + bend*bendY))
+		c.carve(raw, x, y, float64(c.tunnelWidth)/2)
+	}
+}
+
+// chamber carves an irregular chamber around the room center
+func (c cave) chamber(raw [][]game.CollisionType, rnd *rand.Rand, center data.Position) {
+	radius := c.minChamber + rnd.Intn(c.maxChamber-c.minChamber+1)
+	for blob := 0; blob < 4; blob++ {
+		x := center.X + rnd.Intn(radius+1) - radius/2
+		y := center.Y + rnd.Intn(radius+1) - radius/2
+		c.carve(raw, x, y, float64(radius)*(0.5+rnd.Float64()/2))
+	}
+}
+
+func (c cave) carve(raw [][]game.CollisionType, cx, cy int, radius float64) {
+	r := int(math.Ceil(radius))
+	for y := cy - r; y <= cy+r; y++ {
+		for x := cx - r; x <= cx+r; x++ {
+			// The border of the area is always a wall
+			if x < 1 || y < 1 || y >= len(raw)-1 || x >= len(raw[y])-1 {
+				continue
+			}
+			if math.Hypot(float64(x-cx), float64(y-cy)) <= radius {
+				raw[y][x] = game.CollisionTypeWalkable
+			}
+		}
+	}
+}
+
+func write(path string, f fixture) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gob.NewEncoder(file).Encode(f)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,341 @@
+package traverse
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+const (
+	// anchorSearchRadius is how far from the room center we look for a walkable tile
+	anchorSearchRadius = 20
+	defaultMaxPasses   = 50
+	unreachable        = math.MaxInt32
+)
+
+type Options struct {
+	// CanTeleport makes the tiles that can only be teleported over passable
+	CanTeleport bool
+	// Priority are the indexes of the rooms to visit early, e.g. rooms with known elites or chests
+	Priority map[int]bool
+	// PriorityWeight is how much the distance traveled before reaching a priority room counts compared to the total
+	// distance, 0 ignores the priorities
+	PriorityWeight float64
+	// MaxPasses limits the 2-opt improvement passes, 0 uses the default
+	MaxPasses int
+}
+
+// PlanRooms returns the order to visit the rooms with the given centers, starting from the given position. Distances
+// are walkable path lengths on the grid: a nearest neighbor tour is built and then improved with 2-opt. Rooms that
+// can't be reached from the start are visited last, by straight-line distance.
+func PlanRooms(grid *game.Grid, start data.Position, centers []data.Position, opts Options) []int {
+	if len(centers) == 0 {
+		return []int{}
+	}
+
+	// Node 0 is the start, room i is node i+1
+	points := make([]data.Position, 0, len(centers)+1)
+	points = append(points, start)
+	points = append(points, centers...)
+
+	dist := PathDistances(grid, points, opts.CanTeleport)
+
+	reachable := make([]int, 0, len(centers))
+	isolated := make([]int, 0)
+	for i := 1; i < len(points); i++ {
+		if dist[0][i] < unreachable {
+			reachable = append(reachable, i)
+		} else {
+			isolated = append(isolated, i)
+		}
+	}
+
+	tour := nearestNeighborTour(dist, reachable)
+	maxPasses := opts.MaxPasses
+	if maxPasses <= 0 {
+		maxPasses = defaultMaxPasses
+	}
+	tour = improveTour(tour, dist, opts, maxPasses)
+
+	order := make([]int, 0, len(centers))
+	for _, node := range tour[1:] {
+		order = append(order, node-1)
+	}
+
+	// Isolated rooms, by straight-line distance from the last room visited
+	last := points[tour[len(tour)-1]]
+	for len(isolated) > 0 {
+		closest := 0
+		for i, node := range isolated {
+			if straightDistance(last, points[node]) < straightDistance(last, points[isolated[closest]]) {
+				closest = i
+			}
+		}
+		node := isolated[closest]
+		order = append(order, node-1)
+		last = points[node]
+		isolated = append(isolated[:closest], isolated[closest+1:]...)
+	}
+
+	return order
+}
+
+// PathDistances returns the walkable path length between every pair of points, unreachable pairs are math.MaxInt32.
+// A single multi-source BFS splits the grid in regions around every point, the distances between neighbor regions
+// are then propagated with Dijkstra over the region graph. Paths are forced to cross the regions in between, so the
+// distances can be a bit longer than the shortest walk, but the cost doesn't grow with the number of rooms.
+func PathDistances(grid *game.Grid, points []data.Position, canTeleport bool) [][]int {
+	n := len(points)
+	dist := make([][]int, n)
+	for i := range dist {
+		dist[i] = make([]int, n)
+		for j := range dist[i] {
+			if i != j {
+				dist[i][j] = unreachable
+			}
+		}
+	}
+
+	passable := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= grid.Width || y >= grid.Height {
+			return false
+		}
+		t := grid.CollisionGrid[y][x]
+		return t != game.CollisionTypeNonWalkable && (canTeleport || t != game.CollisionTypeTeleportOver)
+	}
+
+	owner := make([]int32, grid.Width*grid.Height)
+	cellDist := make([]int32, grid.Width*grid.Height)
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	queue := make([]int, 0, 1024)
+	// Points sharing the anchor tile are joined with a zero cost edge
+	edges := make(map[[2]int]int)
+	for node, p := range points {
+		x, y, found := anchor(grid, p, passable)
+		if !found {
+			continue
+		}
+		cell := y*grid.Width + x
+		if owner[cell] >= 0 {
+			edges[edgeKey(int(owner[cell]), node)] = 0
+			continue
+		}
+		owner[cell] = int32(node)
+		queue = append(queue, cell)
+	}
+
+	for head := 0; head < len(queue); head++ {
+		cell := queue[head]
+		x, y := cell%grid.Width, cell/grid.Width
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if (dx == 0 && dy == 0) || !passable(x+dx, y+dy) {
+					continue
+				}
+				next := (y+dy)*grid.Width + x + dx
+				if owner[next] >= 0 {
+					if owner[next] != owner[cell] {
+						key := edgeKey(int(owner[cell]), int(owner[next]))
+						cost := int(cellDist[cell]) + 1 + int(cellDist[next])
+						if current, found := edges[key]; !found || cost < current {
+							edges[key] = cost
+						}
+					}
+					continue
+				}
+				owner[next] = owner[cell]
+				cellDist[next] = cellDist[cell] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	neighbors := make([][]regionEdge, n)
+	for key, cost := range edges {
+		neighbors[key[0]] = append(neighbors[key[0]], regionEdge{to: key[1], cost: cost})
+		neighbors[key[1]] = append(neighbors[key[1]], regionEdge{to: key[0], cost: cost})
+	}
+	for from := 0; from < n; from++ {
+		dijkstra(from, neighbors, dist[from])
+	}
+
+	return dist
+}
+
+// anchor returns the walkable tile closest to the point, relative to the grid
+func anchor(grid *game.Grid, p data.Position, passable func(x, y int) bool) (int, int, bool) {
+	rel := grid.RelativePosition(p)
+	for radius := 0; radius <= anchorSearchRadius; radius++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if max(abs(dx), abs(dy)) != radius {
+					continue
+				}
+				if passable(rel.X+dx, rel.Y+dy) {
+					return rel.X + dx, rel.Y + dy, true
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+func nearestNeighborTour(dist [][]int, nodes []int) []int {
+	tour := make([]int, 0, len(nodes)+1)
+	tour = append(tour, 0)
+	visited := make(map[int]bool, len(nodes))
+	current := 0
+	for len(tour) <= len(nodes) {
+		next := -1
+		for _, node := range nodes {
+			if !visited[node] && (next < 0 || dist[current][node] < dist[current][next]) {
+				next = node
+			}
+		}
+		tour = append(tour, next)
+		visited[next] = true
+		current = next
+	}
+
+	return tour
+}
+
+// improveTour applies 2-opt moves (reversing a segment of the tour) while they lower the cost, the start is fixed
+func improveTour(tour []int, dist [][]int, opts Options, maxPasses int) []int {
+	if opts.PriorityWeight == 0 {
+		return improveTourDistance(tour, dist, maxPasses)
+	}
+
+	// With priorities the cost of a move depends on where every priority room ends up, so the whole tour is evaluated
+	best := tourCost(tour, dist, opts)
+	for pass := 0; pass < maxPasses; pass++ {
+		improved := false
+		for i := 1; i < len(tour)-1; i++ {
+			for j := i + 1; j < len(tour); j++ {
+				reverse(tour, i, j)
+				if cost := tourCost(tour, dist, opts); cost < best {
+					best = cost
+					improved = true
+				} else {
+					reverse(tour, i, j)
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return tour
+}
+
+// improveTourDistance is improveTour for the total distance alone. Distances are symmetric, so reversing a segment
+// only changes the edges at both of its ends and every move is evaluated without walking the tour.
+func improveTourDistance(tour []int, dist [][]int, maxPasses int) []int {
+	for pass := 0; pass < maxPasses; pass++ {
+		improved := false
+		for i := 1; i < len(tour)-1; i++ {
+			for j := i + 1; j < len(tour); j++ {
+				// The tour ends at the last room, reversing a segment that reaches it leaves no edge after it
+				delta := dist[tour[i-1]][tour[j]] - dist[tour[i-1]][tour[i]]
+				if j+1 < len(tour) {
+					delta += dist[tour[i]][tour[j+1]] - dist[tour[j]][tour[j+1]]
+				}
+				if delta < 0 {
+					reverse(tour, i, j)
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return tour
+}
+
+// tourCost is the total distance of the tour plus the weighted distance traveled before reaching every priority room
+func tourCost(tour []int, dist [][]int, opts Options) float64 {
+	traveled := 0
+	cost := 0.0
+	for i := 1; i < len(tour); i++ {
+		traveled += dist[tour[i-1]][tour[i]]
+		if opts.PriorityWeight > 0 && opts.Priority[tour[i]-1] {
+			cost += opts.PriorityWeight * float64(traveled)
+		}
+	}
+
+	return cost + float64(traveled)
+}
+
+func reverse(tour []int, i, j int) {
+	for ; i < j; i, j = i+1, j-1 {
+		tour[i], tour[j] = tour[j], tour[i]
+	}
+}
+
+type regionEdge struct {
+	to   int
+	cost int
+}
+
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+func dijkstra(from int, neighbors [][]regionEdge, dist []int) {
+	dist[from] = 0
+	pq := &nodeQueue{{node: from, dist: 0}}
+	for pq.Len() > 0 {
+		current := heap.Pop(pq).(queuedNode)
+		if current.dist > dist[current.node] {
+			continue
+		}
+		for _, e := range neighbors[current.node] {
+			if d := current.dist + e.cost; d < dist[e.to] {
+				dist[e.to] = d
+				heap.Push(pq, queuedNode{node: e.to, dist: d})
+			}
+		}
+	}
+}
+
+type queuedNode struct {
+	node int
+	dist int
+}
+
+type nodeQueue []queuedNode
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(queuedNode)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+func straightDistance(a, b data.Position) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
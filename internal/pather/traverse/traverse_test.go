@@ -0,0 +1,251 @@
+package traverse
+
+import (
+	"encoding/gob"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// fixture is an area written by testdata/generate.go. The areas are generated from a fixed seed following the layout
+// of the real caves, they are not recorded from the game.
+type fixture struct {
+	Grid  game.Grid
+	Rooms []data.Room
+	Start data.Position
+}
+
+var fixtureFiles = []string{"maggot_lair_level_1.bin", "pit_level_1.bin"}
+
+func BenchmarkPlanRooms(b *testing.B) {
+	for _, file := range fixtureFiles {
+		b.Run(file, func(b *testing.B) {
+			area := loadFixture(b, file)
+			centers := area.centers()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				PlanRooms(&area.Grid, area.Start, centers, Options{})
+			}
+		})
+	}
+}
+
+func TestPlanRoomsVisitsEveryRoom(t *testing.T) {
+	for _, file := range fixtureFiles {
+		t.Run(file, func(t *testing.T) {
+			area := loadFixture(t, file)
+			centers := area.centers()
+
+			order := PlanRooms(&area.Grid, area.Start, centers, Options{})
+			if len(order) != len(centers) {
+				t.Fatalf("Expected %d rooms, got %d", len(centers), len(order))
+			}
+			seen := make(map[int]bool)
+			for _, room := range order {
+				if room < 0 || room >= len(centers) || seen[room] {
+					t.Fatalf("Expected every room to be visited once, got %v", order)
+				}
+				seen[room] = true
+			}
+		})
+	}
+}
+
+func TestPlanRoomsShorterThanStraightLine(t *testing.T) {
+	for _, file := range fixtureFiles {
+		t.Run(file, func(t *testing.T) {
+			area := loadFixture(t, file)
+			centers := area.centers()
+
+			points := append([]data.Position{area.Start}, centers...)
+			dist := PathDistances(&area.Grid, points, false)
+			for i := 1; i < len(points); i++ {
+				if dist[0][i] == math.MaxInt32 {
+					t.Fatalf("Expected every room of the cave to be reachable, room %d is not", i-1)
+				}
+			}
+
+			planned := pathCost(dist, PlanRooms(&area.Grid, area.Start, centers, Options{}))
+			greedy := pathCost(dist, straightLineOrder(area.Start, centers))
+			if planned > greedy {
+				t.Errorf("Expected the planned tour (%d) to be at most as long as the straight-line one (%d)", planned, greedy)
+			}
+		})
+	}
+}
+
+func TestImproveTourTwoOptOptimal(t *testing.T) {
+	for _, file := range fixtureFiles {
+		t.Run(file, func(t *testing.T) {
+			area := loadFixture(t, file)
+			points := append([]data.Position{area.Start}, area.centers()...)
+			dist := PathDistances(&area.Grid, points, false)
+
+			nodes := make([]int, 0, len(points)-1)
+			for i := 1; i < len(points); i++ {
+				nodes = append(nodes, i)
+			}
+			tour := nearestNeighborTour(dist, nodes)
+			initial := tourCost(tour, dist, Options{})
+			tour = improveTour(tour, dist, Options{}, math.MaxInt32)
+
+			best := tourCost(tour, dist, Options{})
+			if best > initial {
+				t.Fatalf("Expected the improved tour (%v) to be at most as long as the initial one (%v)", best, initial)
+			}
+
+			// No single segment reversal is left that makes the tour shorter
+			for i := 1; i < len(tour)-1; i++ {
+				for j := i + 1; j < len(tour); j++ {
+					reverse(tour, i, j)
+					if cost := tourCost(tour, dist, Options{}); cost < best {
+						t.Fatalf("Expected no improving move, reversing %d..%d gives %v instead of %v", i, j, cost, best)
+					}
+					reverse(tour, i, j)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRoomsFollowsWalls(t *testing.T) {
+	grid := wallGrid()
+	start := data.Position{X: 1, Y: 1}
+	centers := []data.Position{
+		{X: 1, Y: 11},  // Right below the wall, only reachable going around it
+		{X: 18, Y: 1},  // Next to the gap
+		{X: 19, Y: 19}, // Below the gap
+	}
+
+	// The straight-line nearest room is the one below the wall, the shortest walk goes through the gap first
+	if order := straightLineOrder(start, centers); order[0] != 0 {
+		t.Fatalf("Expected the straight-line tour to start with the room below the wall, got %v", order)
+	}
+
+	order := PlanRooms(grid, start, centers, Options{})
+	expected := []int{1, 2, 0}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestPlanRoomsPriority(t *testing.T) {
+	grid := wallGrid()
+	start := data.Position{X: 10, Y: 5}
+	centers := []data.Position{{X: 12, Y: 5}, {X: 19, Y: 5}, {X: 1, Y: 5}}
+
+	if order := PlanRooms(grid, start, centers, Options{}); order[0] != 0 {
+		t.Fatalf("Expected the closest room to be visited first without priorities, got %v", order)
+	}
+
+	order := PlanRooms(grid, start, centers, Options{Priority: map[int]bool{2: true}, PriorityWeight: 10})
+	if order[0] != 2 {
+		t.Errorf("Expected the priority room to be visited first, got %v", order)
+	}
+}
+
+func TestPlanRoomsUnreachableLast(t *testing.T) {
+	grid := wallGrid()
+	// Close the gap, rooms below the wall can't be reached anymore
+	for x := 0; x < grid.Width; x++ {
+		grid.CollisionGrid[10][x] = game.CollisionTypeNonWalkable
+	}
+	start := data.Position{X: 1, Y: 1}
+	centers := []data.Position{{X: 1, Y: 11}, {X: 18, Y: 1}, {X: 19, Y: 19}}
+
+	order := PlanRooms(grid, start, centers, Options{})
+	expected := []int{1, 2, 0}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+// wallGrid is a 22x21 open area split by a wall at y=10, with a gap on the right side
+func wallGrid() *game.Grid {
+	width, height := 22, 21
+	cg := make([][]game.CollisionType, height)
+	for y := range cg {
+		cg[y] = make([]game.CollisionType, width)
+		for x := range cg[y] {
+			if y == 10 && x < 20 {
+				cg[y][x] = game.CollisionTypeNonWalkable
+			} else {
+				cg[y][x] = game.CollisionTypeWalkable
+			}
+		}
+	}
+
+	return &game.Grid{Width: width, Height: height, CollisionGrid: cg}
+}
+
+// straightLineOrder is the greedy nearest neighbor tour by straight-line distance
+func straightLineOrder(start data.Position, centers []data.Position) []int {
+	visited := make([]bool, len(centers))
+	order := make([]int, 0, len(centers))
+	current := start
+	for len(order) < len(centers) {
+		next := -1
+		for i, c := range centers {
+			if !visited[i] && (next < 0 || straightDistance(current, c) < straightDistance(current, centers[next])) {
+				next = i
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = centers[next]
+	}
+
+	return order
+}
+
+// pathCost is the walkable length of the tour, rooms that can't be reached are ignored
+func pathCost(dist [][]int, order []int) int {
+	cost := 0
+	current := 0
+	for _, room := range order {
+		node := room + 1
+		if dist[0][node] == math.MaxInt32 {
+			continue
+		}
+		cost += dist[current][node]
+		current = node
+	}
+
+	return cost
+}
+
+func loadFixture(tb testing.TB, file string) fixture {
+	tb.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", file))
+	if err != nil {
+		tb.Fatalf("Expected fixture %s to be readable, got %v", file, err)
+	}
+	defer f.Close()
+
+	var area fixture
+	if err := gob.NewDecoder(f).Decode(&area); err != nil {
+		tb.Fatalf("Expected fixture %s to be decoded, got %v", file, err)
+	}
+
+	return area
+}
+
+// centers returns the room centers like the path finder does
+func (f fixture) centers() []data.Position {
+	centers := make([]data.Position, 0, len(f.Rooms))
+	for _, r := range f.Rooms {
+		centers = append(centers, r.GetCenter())
+	}
+
+	return centers
+}
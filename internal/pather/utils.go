@@ -9,6 +9,7 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/data/area"
 	"github.com/hectorgimenez/d2go/pkg/data/object"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/pather/traverse"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
@@ -26,7 +27,43 @@ func (pf *PathFinder) DistanceFromMe(p data.Position) int {
 	return DistanceFromPoint(pf.data.PlayerUnit.Position, p)
 }
 
+// roomPriorityWeight is how much the distance walked before reaching a priority room weighs against the total distance
+const roomPriorityWeight = 2
+
 func (pf *PathFinder) OptimizeRoomsTraverseOrder() []data.Room {
+	return pf.OptimizeRoomsTraverseOrderWithPriority(nil)
+}
+
+// OptimizeRoomsTraverseOrderWithPriority returns the order to visit the rooms of the current area, using the walkable
+// distances on the area grid. Rooms matching priority (e.g. rooms with elites or chests) are visited earlier when it
+// doesn't make the tour much longer, priority can be nil.
+func (pf *PathFinder) OptimizeRoomsTraverseOrderWithPriority(priority func(data.Room) bool) []data.Room {
+	grid := pf.data.AreaData.Grid
+	if grid == nil || len(pf.data.Rooms) == 0 {
+		return pf.straightLineRoomsOrder()
+	}
+
+	centers := make([]data.Position, 0, len(pf.data.Rooms))
+	opts := traverse.Options{CanTeleport: pf.data.CanTeleport(), Priority: make(map[int]bool)}
+	for i, r := range pf.data.Rooms {
+		centers = append(centers, r.GetCenter())
+		if priority != nil && priority(r) {
+			opts.Priority[i] = true
+			opts.PriorityWeight = roomPriorityWeight
+		}
+	}
+
+	order := make([]data.Room, 0, len(pf.data.Rooms))
+	for _, idx := range traverse.PlanRooms(grid, pf.data.PlayerUnit.Position, centers, opts) {
+		order = append(order, pf.data.Rooms[idx])
+	}
+
+	return order
+}
+
+// straightLineRoomsOrder is the nearest neighbor order by straight-line distance between room centers, used when the
+// area grid is not available
+func (pf *PathFinder) straightLineRoomsOrder() []data.Room {
 	distanceMatrix := make(map[data.Room]map[data.Room]int)
 
 	for _, room1 := range pf.data.Rooms {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"image/jpeg"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/hectorgimenez/koolo/internal/config"
@@ -31,6 +32,18 @@ func (b *Bot) Handle(_ context.Context, e event.Event) error {
 			message := fmt.Sprintf("**[%s]** finished run: **%s** (%s)", evt.Supervisor(), evt.RunName, evt.Reason)
 			_, err := b.discordSession.ChannelMessageSend(b.channelID, message)
 			return err
+		case event.SupervisorStateChangedEvent:
+			message := fmt.Sprintf("**[%s]** supervisor state changed: %s -> **%s**", evt.Supervisor(), evt.From, evt.To)
+			_, err := b.discordSession.ChannelMessageSend(b.channelID, message)
+			return err
+		case event.AllMulesFullEvent:
+			message := fmt.Sprintf("**[%s]** all mules are full: %s", evt.Supervisor(), strings.Join(evt.Mules, ", "))
+			_, err := b.discordSession.ChannelMessageSend(b.channelID, message)
+			return err
+		case event.CharacterSwitchFailedEvent:
+			message := fmt.Sprintf("**[%s]** failed to switch to **%s** (%s): %s", evt.Supervisor(), evt.NextCharacter, evt.Reason, evt.Error)
+			_, err := b.discordSession.ChannelMessageSend(b.channelID, message)
+			return err
 		default:
 			break
 		}
@@ -75,6 +88,11 @@ func (b *Bot) shouldPublish(e event.Event) bool {
 		return config.Koolo.Discord.EnableNewRunMessages
 	case event.RunFinishedEvent:
 		return config.Koolo.Discord.EnableRunFinishMessages
+	case event.SupervisorStateChangedEvent:
+		// Only crashes are worth a notification, the rest of transitions are triggered by the user or the scheduler
+		return evt.To == "Crashed" && config.Koolo.Discord.EnableDiscordErrorMessages
+	case event.CharacterSwitchFailedEvent, event.AllMulesFullEvent:
+		return config.Koolo.Discord.EnableDiscordErrorMessages
 	default:
 		break
 	}
@@ -126,7 +126,9 @@ func (a Ancients) killAncients() error {
 		}
 
 		a.ctx.Char.KillMonsterSequence(func(d game.Data) (data.UnitID, bool) {
-			for _, m := range d.Monsters.Enemies(data.MonsterEliteFilter()) {
+			enemies := d.Monsters.Enemies(data.MonsterEliteFilter())
+			action.SortEnemiesByPriority(&enemies)
+			for _, m := range enemies {
 				return m.UnitID, true
 			}
 			return 0, false
@@ -0,0 +1,86 @@
+package run
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/mule"
+	"github.com/hectorgimenez/koolo/internal/runcond"
+)
+
+// selectRuns filters and orders the runs by their conditions, evaluated with the current game facts
+func selectRuns(cfg *config.CharacterCfg, runs []string, gameNumber int) []string {
+	if len(cfg.Game.RunConditions) == 0 {
+		return runs
+	}
+
+	ctx := context.Get()
+	return selectRunsWithFacts(ctx.Logger, cfg.Game.RunConditions, runs, gameFacts(ctx, gameNumber))
+}
+
+func selectRunsWithFacts(logger *slog.Logger, conditions map[config.Run]runcond.Rule, runs []string, facts runcond.Facts) []string {
+	rules := make(map[string]runcond.Rule, len(conditions))
+	for r, rule := range conditions {
+		rules[string(r)] = rule
+	}
+
+	selected, err := runcond.Select(runs, rules, facts)
+	if err != nil {
+		logger.Warn("Skipping runs with invalid conditions", "error", err)
+	}
+	for _, r := range runs {
+		if !slices.Contains(selected, r) {
+			logger.Debug(fmt.Sprintf("Skipping run %s, condition not met: %s", r, rules[r].When))
+		}
+	}
+
+	return selected
+}
+
+// gameFacts returns the facts the run conditions are evaluated with
+func gameFacts(ctx *context.Status, gameNumber int) runcond.Facts {
+	lvl, _ := ctx.Data.PlayerUnit.FindStat(stat.Level, 0)
+
+	// The shared stash is only loaded once the stash has been opened in the game, at the start of the game the keys
+	// are counted from the stash content saved the last time the stash routines ran
+	stash := ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash)
+	if snapshot, err := mule.LoadStashSnapshot(ctx.Name); err == nil {
+		stash = snapshot.Items
+	}
+	items := append(ctx.Data.Inventory.ByLocation(item.LocationInventory), stash...)
+
+	return newGameFacts(lvl.Value, ctx.Data.PlayerUnit.TotalPlayerGold(), len(ctx.Data.TerrorZones) > 0, time.Now(), gameNumber, items)
+}
+
+func newGameFacts(level, gold int, terrorZone bool, now time.Time, gameNumber int, items []data.Item) runcond.Facts {
+	facts := runcond.Facts{
+		Level:      level,
+		Gold:       gold,
+		TerrorZone: terrorZone,
+		Hour:       now.Hour(),
+		Minute:     now.Minute(),
+		Weekday:    int(now.Weekday()),
+		Game:       gameNumber,
+	}
+
+	for _, itm := range items {
+		switch {
+		case strings.EqualFold(string(itm.Name), "KeyOfTerror"):
+			facts.KeysTerror++
+		case strings.EqualFold(string(itm.Name), "KeyOfHate"):
+			facts.KeysHate++
+		case strings.EqualFold(string(itm.Name), "KeyOfDestruction"):
+			facts.KeysDestruction++
+		}
+	}
+
+	return facts
+}
@@ -0,0 +1,54 @@
+package run
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/runcond"
+)
+
+func TestNewGameFacts(t *testing.T) {
+	now := time.Date(2024, time.March, 9, 23, 15, 0, 0, time.UTC)
+	items := []data.Item{{Name: "KeyOfTerror"}, {Name: "KeyOfDestruction"}, {Name: "keyofdestruction"}, {Name: "Ber"}}
+
+	facts := newGameFacts(85, 300000, true, now, 7, items)
+	expected := runcond.Facts{
+		Level:           85,
+		Gold:            300000,
+		TerrorZone:      true,
+		Hour:            23,
+		Minute:          15,
+		Weekday:         int(time.Saturday),
+		Game:            7,
+		KeysTerror:      1,
+		KeysDestruction: 2,
+	}
+	if facts != expected {
+		t.Errorf("Expected %+v, got %+v", expected, facts)
+	}
+}
+
+func TestSelectRunsWithFacts(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	runs := []string{string(config.PindleskinRun), string(config.NihlathakRun), string(config.BaalRun)}
+	conditions := map[config.Run]runcond.Rule{
+		config.NihlathakRun: {When: "keysDestruction < 3"},
+		config.BaalRun:      {When: "level >= 80", Priority: 10},
+	}
+
+	selected := selectRunsWithFacts(logger, conditions, runs, runcond.Facts{Level: 85, KeysDestruction: 3})
+	if expected := []string{string(config.BaalRun), string(config.PindleskinRun)}; !slices.Equal(selected, expected) {
+		t.Errorf("Expected %v, got %v", expected, selected)
+	}
+
+	conditions[config.PindleskinRun] = runcond.Rule{When: "level >"}
+	selected = selectRunsWithFacts(logger, conditions, runs, runcond.Facts{Level: 20})
+	if expected := []string{string(config.NihlathakRun)}; !slices.Equal(selected, expected) {
+		t.Errorf("Expected runs with invalid conditions to be skipped, got %v", selected)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/hectorgimenez/koolo/internal/action"
 	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/mule"
 	"github.com/hectorgimenez/koolo/internal/ui"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
@@ -56,15 +58,13 @@ func (m Mule) initialSetup(ctx *context.Status) error {
 func (m Mule) Run(parameters *RunParameters) error {
 	ctx := context.Get()
 
-	returnToChar := ctx.CharacterCfg.Muling.ReturnTo
-	muleProfiles := ctx.CharacterCfg.Muling.MuleProfiles
+	// The character that started this mule takes precedence, so chains of mules always return to the original one
+	returnToChar := ctx.ReturnToCharacter
+	if returnToChar == "" {
+		returnToChar = ctx.CharacterCfg.Muling.ReturnTo
+	}
 	ctx.Logger.Info("Starting mule run", "muleCharacter", ctx.Name)
 
-	//	if len(muleProfiles) == 0 {
-	//		ctx.Logger.Error("Mule run started, but no 'muleProfiles' are configured in settings. Stopping.")
-	//		return nil // Stop cleanly
-	//	}
-
 	if returnToChar == "" {
 		ctx.Logger.Error("Mule run started, but 'ReturnTo' is not configured in settings. Stopping.")
 		return nil // Stop cleanly
@@ -74,27 +74,13 @@ func (m Mule) Run(parameters *RunParameters) error {
 	if err := m.initialSetup(ctx); err != nil {
 		ctx.Logger.Error("Mule initial setup failed, switching back to original character.", "error", err)
 		// Even if setup fails, we should try to switch back
-		ctx.CurrentGame.SwitchToCharacter = returnToChar
-		ctx.RestartWithCharacter = returnToChar
-		ctx.CleanStopRequested = true
-		ctx.StopSupervisor()
+		ctx.SwitchCharacter(returnToChar, "", event.SwitchReasonMulingFailed)
 		return err
 	}
 
 	// Check if the current mule's private stash is already full
 	if isPrivateStashFull(ctx) {
 		ctx.Logger.Info("Current mule's stash is full, checking for the next one.")
-		ctx.CurrentGame.CurrentMuleIndex++
-		if ctx.CurrentGame.CurrentMuleIndex < len(muleProfiles) {
-			// We have another mule to switch to
-			nextMule := muleProfiles[ctx.CurrentGame.CurrentMuleIndex]
-			ctx.Logger.Info("Switching to next mule", "mule", nextMule)
-			ctx.CurrentGame.SwitchToCharacter = nextMule
-		} else {
-			// No more mules, return to the farming character
-			ctx.Logger.Info("All available mules are full, returning to farming character.")
-			ctx.CurrentGame.SwitchToCharacter = returnToChar
-		}
 	} else {
 		// Stash is not full, proceed with muling logic
 		for {
@@ -149,43 +135,52 @@ func (m Mule) Run(parameters *RunParameters) error {
 				break
 			}
 		}
-
-		// After muling, decide who to switch to
-		if isPrivateStashFull(ctx) {
-			ctx.Logger.Info("Mule is now full, checking for the next mule.")
-			ctx.CurrentGame.CurrentMuleIndex++
-			if ctx.CurrentGame.CurrentMuleIndex < len(muleProfiles) {
-				// We have another mule to switch to
-				nextMule := muleProfiles[ctx.CurrentGame.CurrentMuleIndex]
-				ctx.Logger.Info("Switching to next mule", "mule", nextMule)
-				ctx.CurrentGame.SwitchToCharacter = nextMule
-			} else {
-				ctx.Logger.Info("All available mules are now full, returning to farming character.")
-				ctx.CurrentGame.SwitchToCharacter = returnToChar
-			}
-		} else {
-			// Muling is done and the current mule is not full, return to farmer
-			ctx.Logger.Info("Muling finished, returning to farming character.")
-			ctx.CurrentGame.SwitchToCharacter = returnToChar
-		}
 	}
 
+	nextCharacter, reason := m.nextCharacter(ctx, returnToChar)
+
 	ctx.Logger.Info("Preparing to switch character",
 		"from", ctx.Name,
-		"to", ctx.CurrentGame.SwitchToCharacter)
-
-	ctx.RestartWithCharacter = ctx.CurrentGame.SwitchToCharacter
-	ctx.CleanStopRequested = true
+		"to", nextCharacter)
 
 	if err := ctx.Manager.ExitGame(); err != nil {
 		ctx.Logger.Error("Failed to exit game before character switch", "error", err)
 	}
 	utils.Sleep(2000)
 
-	ctx.StopSupervisor()
+	// Another mule has to come back to the same character than us, the farming character ends the chain
+	nextReturnTo := ""
+	if nextCharacter != returnToChar {
+		nextReturnTo = returnToChar
+	}
+	ctx.SwitchCharacter(nextCharacter, nextReturnTo, reason)
 	return nil
 }
 
+// nextCharacter saves the stash snapshot of the mule and picks the character to switch to: the next mule with room
+// left when this one is full, or the farming character otherwise.
+func (m Mule) nextCharacter(ctx *context.Status, returnToChar string) (string, event.CharacterSwitchReason) {
+	ctx.RefreshGameData()
+	snapshot, err := mule.SaveStashSnapshot(ctx.Name, ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash), ctx.Data.Inventory.ByLocation(item.LocationInventory))
+	if err != nil {
+		ctx.Logger.Warn("Failed to save mule stash snapshot", "error", err)
+	}
+
+	if !mule.SnapshotCapacity(snapshot, ctx.CharacterCfg.Inventory.InventoryLock).Full {
+		ctx.Logger.Info("Muling finished, returning to farming character.")
+		return returnToChar, event.SwitchReasonMulingDone
+	}
+
+	nextMule, err := mule.NewManager(ctx.Logger).NextMule(returnToChar, ctx.Name)
+	if err != nil {
+		ctx.Logger.Info("Mule is full and there is no other mule available, returning to farming character.", "error", err)
+		return returnToChar, event.SwitchReasonMuleFull
+	}
+
+	ctx.Logger.Info("Mule is full, switching to next mule", "mule", nextMule)
+	return nextMule, event.SwitchReasonMuleFull
+}
+
 // findStashSpace finds the top-left grid coordinates for a free spot in the personal stash.
 func findStashSpace(ctx *context.Status, itm data.Item) (data.Position, bool) {
 	stash := ctx.Data.Inventory.ByLocation(item.LocationStash)
@@ -273,26 +268,5 @@ func findInventorySpace(ctx *context.Status, itm data.Item) (data.Position, bool
 // isPrivateStashFull checks if the personal stash has any 2x2 free space.
 // This is a simple heuristic to determine if the stash is "full".
 func isPrivateStashFull(ctx *context.Status) bool {
-	stash := ctx.Data.Inventory.ByLocation(item.LocationStash)
-	occupied := [10][10]bool{}
-	for _, i := range stash {
-		for y := 0; y < i.Desc().InventoryHeight; y++ {
-			for x := 0; x < i.Desc().InventoryWidth; x++ {
-				if i.Position.Y+y < 10 && i.Position.X+x < 10 {
-					occupied[i.Position.Y+y][i.Position.X+x] = true
-				}
-			}
-		}
-	}
-
-	// Check for a 2x2 free space
-	for y := 0; y <= 8; y++ {
-		for x := 0; x <= 8; x++ {
-			if !occupied[y][x] && !occupied[y+1][x] && !occupied[y][x+1] && !occupied[y+1][x+1] {
-				return false // Found a 2x2 space, so not full
-			}
-		}
-	}
-
-	return true // No 2x2 space found
+	return mule.StashCapacity(ctx.Data.Inventory.ByLocation(item.LocationStash), nil, nil).Full
 }
@@ -94,7 +94,9 @@ func (n Nihlathak) Run(parameters *RunParameters) error {
 		n.ctx.Logger.Debug("Clearing monsters around Nihlathak position")
 
 		n.ctx.Char.KillMonsterSequence(func(d game.Data) (data.UnitID, bool) {
-			for _, m := range d.Monsters.Enemies() {
+			enemies := d.Monsters.Enemies()
+			action.SortEnemiesByPriority(&enemies)
+			for _, m := range enemies {
 				if d := pather.DistanceFromPoint(nihlaObject.Position, m.Position); d < 15 {
 					return m.UnitID, true
 				}
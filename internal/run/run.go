@@ -29,14 +29,18 @@ type TownRoutineSkipper interface {
 	SkipTownRoutines() bool
 }
 
-func BuildRuns(cfg *config.CharacterCfg, runs []string) (builtRuns []Run) {
+// BuildRuns returns the runs of the game, gameNumber is the number of the game in the session used by the run
+// conditions
+func BuildRuns(cfg *config.CharacterCfg, runs []string, gameNumber int) (builtRuns []Run) {
 	//if cfg.Companion.Enabled && !cfg.Companion.Leader {
 	//	return []Run{Companion{baseRun: baseRun}}
 	//}
 
-	for _, run := range cfg.Game.Runs {
+	runs = selectRuns(cfg, runs, gameNumber)
+
+	for _, run := range runs {
 		// Prepend terror zone runs, we want to run it always first
-		if run == config.TerrorZoneRun {
+		if run == string(config.TerrorZoneRun) {
 			tz := NewTerrorZone()
 
 			if len(tz.AvailableTZs()) > 0 {
@@ -58,6 +62,19 @@ func BuildRuns(cfg *config.CharacterCfg, runs []string) (builtRuns []Run) {
 	return builtRuns
 }
 
+// ID returns the run of the settings the run was built from, the name of some runs changes during the game, e.g. the
+// terror zone run is named after the active zones
+func ID(r Run) config.Run {
+	switch r.(type) {
+	case *TerrorZone:
+		return config.TerrorZoneRun
+	case *FireEye:
+		return config.FireEyeRun
+	}
+
+	return config.Run(r.Name())
+}
+
 func BuildRun(run string) Run {
 	switch run {
 	case string(config.CountessRun):
@@ -0,0 +1,488 @@
+package runcond
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Facts are the values of the game a run condition can use, read at the start of every game:
+//
+//	level             character level
+//	gold              gold in inventory and stash
+//	terrorZone        true when a terror zone is active
+//	hour, minute      local time when the game starts, 0-23 and 0-59
+//	weekday           0 for sunday to 6 for saturday
+//	game              number of the game in the session, starting at 1, e.g. "game % 3 == 0" for every 3rd game
+//	keysTerror        keys of terror, hate and destruction in inventory and stash
+//	keysHate
+//	keysDestruction
+//
+// Conditions support integers, true/false, ( ), + - * / %, == != < <= > >=, && || and !, e.g.
+// "level >= 80 && (hour >= 22 || hour < 6)".
+type Facts struct {
+	Level           int
+	Gold            int
+	TerrorZone      bool
+	Hour            int
+	Minute          int
+	Weekday         int
+	Game            int
+	KeysTerror      int
+	KeysHate        int
+	KeysDestruction int
+}
+
+func (f Facts) lookup(name string) (value, bool) {
+	switch name {
+	case "level":
+		return intValue(f.Level), true
+	case "gold":
+		return intValue(f.Gold), true
+	case "terrorZone":
+		return boolValue(f.TerrorZone), true
+	case "hour":
+		return intValue(f.Hour), true
+	case "minute":
+		return intValue(f.Minute), true
+	case "weekday":
+		return intValue(f.Weekday), true
+	case "game":
+		return intValue(f.Game), true
+	case "keysTerror":
+		return intValue(f.KeysTerror), true
+	case "keysHate":
+		return intValue(f.KeysHate), true
+	case "keysDestruction":
+		return intValue(f.KeysDestruction), true
+	}
+
+	return value{}, false
+}
+
+// Condition is a parsed boolean expression over the facts
+type Condition struct {
+	src  string
+	root node
+}
+
+// Parse parses the condition and checks its types against the facts, an empty condition is always true
+func Parse(src string) (*Condition, error) {
+	if strings.TrimSpace(src) == "" {
+		return &Condition{src: src}, nil
+	}
+
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].pos)
+	}
+
+	kind, err := root.check()
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindBool {
+		return nil, fmt.Errorf("condition must be true or false, got a number")
+	}
+
+	return &Condition{src: src, root: root}, nil
+}
+
+func (c *Condition) String() string {
+	return c.src
+}
+
+// Eval evaluates the condition with the given facts
+func (c *Condition) Eval(f Facts) (bool, error) {
+	if c.root == nil {
+		return true, nil
+	}
+
+	v, err := c.root.eval(f)
+	if err != nil {
+		return false, err
+	}
+
+	return v.b, nil
+}
+
+type kind int
+
+const (
+	kindInt kind = iota
+	kindBool
+)
+
+func (k kind) String() string {
+	if k == kindBool {
+		return "true or false"
+	}
+	return "a number"
+}
+
+type value struct {
+	kind kind
+	n    int
+	b    bool
+}
+
+func intValue(n int) value   { return value{kind: kindInt, n: n} }
+func boolValue(b bool) value { return value{kind: kindBool, b: b} }
+
+type node interface {
+	check() (kind, error)
+	eval(f Facts) (value, error)
+}
+
+type literal struct {
+	v value
+}
+
+func (l literal) check() (kind, error)      { return l.v.kind, nil }
+func (l literal) eval(Facts) (value, error) { return l.v, nil }
+
+type fact struct {
+	name string
+}
+
+func (n fact) check() (kind, error) {
+	v, found := Facts{}.lookup(n.name)
+	if !found {
+		return 0, fmt.Errorf("unknown fact %q", n.name)
+	}
+	return v.kind, nil
+}
+
+func (n fact) eval(f Facts) (value, error) {
+	v, _ := f.lookup(n.name)
+	return v, nil
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (u unary) check() (kind, error) {
+	k, err := u.operand.check()
+	if err != nil {
+		return 0, err
+	}
+	want := kindInt
+	if u.op == "!" {
+		want = kindBool
+	}
+	if k != want {
+		return 0, fmt.Errorf("%q needs %s, got %s", u.op, want, k)
+	}
+	return k, nil
+}
+
+func (u unary) eval(f Facts) (value, error) {
+	v, err := u.operand.eval(f)
+	if err != nil {
+		return value{}, err
+	}
+	if u.op == "!" {
+		return boolValue(!v.b), nil
+	}
+	return intValue(-v.n), nil
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (b binary) check() (kind, error) {
+	left, err := b.left.check()
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.right.check()
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case "&&", "||":
+		if left != kindBool || right != kindBool {
+			return 0, fmt.Errorf("%q needs true or false on both sides", b.op)
+		}
+		return kindBool, nil
+	case "==", "!=":
+		if left != right {
+			return 0, fmt.Errorf("%q can not compare %s with %s", b.op, left, right)
+		}
+		return kindBool, nil
+	case "<", "<=", ">", ">=":
+		if left != kindInt || right != kindInt {
+			return 0, fmt.Errorf("%q needs numbers on both sides", b.op)
+		}
+		return kindBool, nil
+	}
+
+	if left != kindInt || right != kindInt {
+		return 0, fmt.Errorf("%q needs numbers on both sides", b.op)
+	}
+	return kindInt, nil
+}
+
+func (b binary) eval(f Facts) (value, error) {
+	left, err := b.left.eval(f)
+	if err != nil {
+		return value{}, err
+	}
+
+	// Short circuit, the right side is not evaluated when the result is already known
+	switch {
+	case b.op == "&&" && !left.b:
+		return boolValue(false), nil
+	case b.op == "||" && left.b:
+		return boolValue(true), nil
+	}
+
+	right, err := b.right.eval(f)
+	if err != nil {
+		return value{}, err
+	}
+
+	switch b.op {
+	case "&&", "||":
+		return boolValue(right.b), nil
+	case "==":
+		return boolValue(left == right), nil
+	case "!=":
+		return boolValue(left != right), nil
+	case "<":
+		return boolValue(left.n < right.n), nil
+	case "<=":
+		return boolValue(left.n <= right.n), nil
+	case ">":
+		return boolValue(left.n > right.n), nil
+	case ">=":
+		return boolValue(left.n >= right.n), nil
+	case "+":
+		return intValue(left.n + right.n), nil
+	case "-":
+		return intValue(left.n - right.n), nil
+	case "*":
+		return intValue(left.n * right.n), nil
+	}
+
+	if right.n == 0 {
+		return value{}, fmt.Errorf("division by zero in %q", b.op)
+	}
+	if b.op == "/" {
+		return intValue(left.n / right.n), nil
+	}
+	return intValue(left.n % right.n), nil
+}
+
+type token struct {
+	text string
+	pos  int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(src) && unicode.IsDigit(rune(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: src[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{text: src[start:i], pos: start})
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{text: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	for _, op := range ops {
+		if p.peek() == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// binaryLevel parses operands separated by any of ops, left associative
+func (p *parser) binaryLevel(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, found := p.accept(ops...)
+		if !found {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binaryLevel(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, found := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !found {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	return binary{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.binaryLevel(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() (node, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, found := p.accept("!", "-"); found {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: op, operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case t.text == "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, found := p.accept(")"); !found {
+			return nil, fmt.Errorf("missing ) for ( at position %d", t.pos)
+		}
+		return n, nil
+	case t.text == "true" || t.text == "false":
+		return literal{v: boolValue(t.text == "true")}, nil
+	case unicode.IsDigit(rune(t.text[0])):
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return literal{v: intValue(n)}, nil
+	case unicode.IsLetter(rune(t.text[0])) || t.text[0] == '_':
+		return fact{name: t.text}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+// Rule is the condition and priority of a run, runs with a higher priority are played first
+type Rule struct {
+	When     string `yaml:"when,omitempty"`
+	Priority int    `yaml:"priority,omitempty"`
+}
+
+// Select returns the runs whose condition holds with the given facts, by priority and then in the given order. Runs
+// with an invalid condition are left out and their errors returned.
+func Select(runs []string, rules map[string]Rule, f Facts) ([]string, error) {
+	var errs []error
+	selected := make([]string, 0, len(runs))
+	for _, run := range runs {
+		c, err := Parse(rules[run].When)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("run %s: %w", run, err))
+			continue
+		}
+		ok, err := c.Eval(f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("run %s: %w", run, err))
+			continue
+		}
+		if ok {
+			selected = append(selected, run)
+		}
+	}
+
+	slices.SortStableFunc(selected, func(a, b string) int {
+		return rules[b].Priority - rules[a].Priority
+	})
+
+	return selected, errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+package runcond
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	f := Facts{Level: 85, Gold: 300000, TerrorZone: true, Hour: 23, Weekday: 6, Game: 9, KeysDestruction: 2}
+
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{"", true},
+		{"terrorZone", true},
+		{"!terrorZone", false},
+		{"level >= 80 && level < 90", true},
+		{"gold < 500000", true},
+		{"gold > 1000000 || keysDestruction >= 3", false},
+		{"hour >= 22 || hour < 6", true},
+		{"game % 3 == 0", true},
+		{"game % 2 == 0", false},
+		{"(weekday == 0 || weekday == 6) && level - 5 * 2 == 75", true},
+		{"terrorZone == false", false},
+		{"-level < 0", true},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.condition)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.condition, err)
+			continue
+		}
+		result, err := c.Eval(f)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.condition, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.condition, tt.expected, result)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, condition := range []string{
+		"level >",
+		"level >= 80 &&",
+		"(level > 1",
+		"level > 1)",
+		"mana > 10",
+		"level",
+		"level && terrorZone",
+		"terrorZone > 1",
+		"!level",
+		"level = 80",
+		"level > 'a'",
+	} {
+		if _, err := Parse(condition); err == nil {
+			t.Errorf("%q: expected an error", condition)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	c, err := Parse("game % level == 0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.Eval(Facts{Game: 3}); err == nil {
+		t.Errorf("Expected an error dividing by zero")
+	}
+	if ok, err := c.Eval(Facts{Game: 4, Level: 2}); err != nil || !ok {
+		t.Errorf("Expected true, got %v %v", ok, err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	runs := []string{"pindleskin", "nihlathak", "countess", "cows", "terror_zone"}
+	rules := map[string]Rule{
+		"nihlathak":   {When: "keysDestruction < 3"},
+		"countess":    {When: "keysTerror < 3 && game % 2 == 0"},
+		"cows":        {When: "level >"},
+		"terror_zone": {When: "terrorZone", Priority: 10},
+	}
+
+	selected, err := Select(runs, rules, Facts{TerrorZone: true, Game: 3, KeysDestruction: 1})
+	if err == nil {
+		t.Errorf("Expected the invalid cows condition to be reported")
+	}
+	if expected := []string{"terror_zone", "pindleskin", "nihlathak"}; !slices.Equal(selected, expected) {
+		t.Errorf("Expected %v, got %v", expected, selected)
+	}
+
+	selected, _ = Select(runs, rules, Facts{Game: 4, KeysDestruction: 3})
+	if expected := []string{"pindleskin", "countess"}; !slices.Equal(selected, expected) {
+		t.Errorf("Expected %v, got %v", expected, selected)
+	}
+}
@@ -0,0 +1,177 @@
+package runpolicy
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// OnFailureEndGame leaves the game when the run fails, it's the default
+	OnFailureEndGame = "endGame"
+	// OnFailureContinue goes on with the next run of the game
+	OnFailureContinue = "continue"
+)
+
+// Reason is why a run or game finished, the events use it as event.FinishReason. It's defined here because the
+// event package can't be imported by the settings.
+type Reason string
+
+const (
+	ReasonOK          Reason = "ok"
+	ReasonDied        Reason = "death"
+	ReasonChicken     Reason = "chicken"
+	ReasonMercChicken Reason = "merc chicken"
+	ReasonError       Reason = "error"
+)
+
+// FailureReasons are the finish reasons a policy can count as failures
+var FailureReasons = []Reason{ReasonDied, ReasonChicken, ReasonMercChicken, ReasonError}
+
+// Policy describes what to do when a run fails
+type Policy struct {
+	// MaxRetries is how many times the run is retried in the same game after failing
+	MaxRetries int `yaml:"maxRetries"`
+	// SkipAfter skips the run for the rest of the session after that many consecutive failures, 0 never skips it
+	SkipAfter int `yaml:"skipAfter"`
+	// Cooldown is how long, in seconds, the run is skipped after failing
+	Cooldown int `yaml:"cooldown"`
+	// OnFailure is OnFailureEndGame or OnFailureContinue, once the retries are exhausted
+	OnFailure string `yaml:"onFailure,omitempty"`
+	// FailOn are the finish reasons counted as failures, only errors when empty
+	FailOn []Reason `yaml:"failOn,omitempty"`
+}
+
+// Decision is what to do once a run finished
+type Decision int
+
+const (
+	// Continue with the next run
+	Continue Decision = iota
+	// Retry the same run
+	Retry
+	// EndGame leaves the game
+	EndGame
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Retry:
+		return "retry"
+	case EndGame:
+		return "end game"
+	}
+	return "continue"
+}
+
+func (p Policy) failsOn(reason Reason) bool {
+	if len(p.FailOn) == 0 {
+		return reason == ReasonError
+	}
+	return slices.Contains(p.FailOn, reason)
+}
+
+type runState struct {
+	consecutiveFailures int
+	skipped             bool
+	cooldownUntil       time.Time
+	retries             int
+}
+
+// Engine keeps the results of the runs during the session and applies their policies, it's safe for concurrent use
+type Engine struct {
+	mu       sync.Mutex
+	policies map[string]Policy
+	runs     map[string]*runState
+}
+
+// NewEngine returns an engine applying the given policies, keyed by run name. Runs without policy end the game on
+// errors.
+func NewEngine(policies map[string]Policy) *Engine {
+	return &Engine{
+		policies: policies,
+		runs:     make(map[string]*runState),
+	}
+}
+
+// SetPolicies replaces the policies, e.g. after the settings are reloaded, keeping the results of the session
+func (e *Engine) SetPolicies(policies map[string]Policy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.policies = policies
+}
+
+// NewGame resets the retries, they are counted per game
+func (e *Engine) NewGame() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range e.runs {
+		s.retries = 0
+	}
+}
+
+// ShouldPlay tells whether the run can be played now, when not the reason is returned
+func (e *Engine) ShouldPlay(run string, now time.Time) (bool, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, found := e.runs[run]
+	if !found {
+		return true, ""
+	}
+	if s.skipped {
+		return false, fmt.Sprintf("skipped for the session after %d consecutive failures", s.consecutiveFailures)
+	}
+	if now.Before(s.cooldownUntil) {
+		return false, fmt.Sprintf("on cooldown for %s after failing", s.cooldownUntil.Sub(now).Round(time.Second))
+	}
+
+	return true, ""
+}
+
+// Finished records the result of the run and returns what to do next. Deaths and chickens always end the game, they
+// are only counted as failures when the policy says so. Reasons not counted as failures keep the default behavior:
+// errors end the game.
+func (e *Engine) Finished(run string, reason Reason, now time.Time) Decision {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	policy := e.policies[run]
+	s, found := e.runs[run]
+	if !found {
+		s = &runState{}
+		e.runs[run] = s
+	}
+
+	if reason == ReasonOK {
+		s.consecutiveFailures = 0
+		return Continue
+	}
+
+	gameLost := reason == ReasonDied || reason == ReasonChicken || reason == ReasonMercChicken
+	if !policy.failsOn(reason) {
+		return EndGame
+	}
+
+	s.consecutiveFailures++
+	if policy.SkipAfter > 0 && s.consecutiveFailures >= policy.SkipAfter {
+		s.skipped = true
+	}
+	if !gameLost && !s.skipped && s.retries < policy.MaxRetries {
+		s.retries++
+		return Retry
+	}
+
+	// The cooldown starts once the retries are exhausted
+	if policy.Cooldown > 0 {
+		s.cooldownUntil = now.Add(time.Duration(policy.Cooldown) * time.Second)
+	}
+	if !gameLost && policy.OnFailure == OnFailureContinue {
+		return Continue
+	}
+
+	return EndGame
+}
@@ -0,0 +1,111 @@
+package runpolicy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	e := NewEngine(nil)
+	now := time.Unix(0, 0)
+
+	if d := e.Finished("nihlathak", ReasonOK, now); d != Continue {
+		t.Errorf("Expected to continue after a successful run, got %s", d)
+	}
+	if d := e.Finished("nihlathak", ReasonError, now); d != EndGame {
+		t.Errorf("Expected errors to end the game without policy, got %s", d)
+	}
+	if ok, _ := e.ShouldPlay("nihlathak", now); !ok {
+		t.Errorf("Expected runs without policy to never be skipped")
+	}
+}
+
+func TestRetries(t *testing.T) {
+	e := NewEngine(map[string]Policy{"nihlathak": {MaxRetries: 2}})
+	now := time.Unix(0, 0)
+
+	for i := 0; i < 2; i++ {
+		if d := e.Finished("nihlathak", ReasonError, now); d != Retry {
+			t.Fatalf("Expected retry %d, got %s", i+1, d)
+		}
+	}
+	if d := e.Finished("nihlathak", ReasonError, now); d != EndGame {
+		t.Errorf("Expected the game to end once the retries are exhausted, got %s", d)
+	}
+
+	e.NewGame()
+	if d := e.Finished("nihlathak", ReasonError, now); d != Retry {
+		t.Errorf("Expected retries to be reset on a new game, got %s", d)
+	}
+}
+
+func TestSkipAfterConsecutiveFailures(t *testing.T) {
+	e := NewEngine(map[string]Policy{"nihlathak": {SkipAfter: 3, OnFailure: OnFailureContinue}})
+	now := time.Unix(0, 0)
+
+	e.Finished("nihlathak", ReasonError, now)
+	e.Finished("nihlathak", ReasonOK, now)
+	e.Finished("nihlathak", ReasonError, now)
+	e.Finished("nihlathak", ReasonError, now)
+	if ok, _ := e.ShouldPlay("nihlathak", now); !ok {
+		t.Errorf("Expected a success to reset the consecutive failures")
+	}
+
+	if d := e.Finished("nihlathak", ReasonError, now); d != Continue {
+		t.Errorf("Expected to continue with the next run, got %s", d)
+	}
+	if ok, reason := e.ShouldPlay("nihlathak", now.Add(24*time.Hour)); ok || reason == "" {
+		t.Errorf("Expected the run to be skipped for the rest of the session")
+	}
+	if ok, _ := e.ShouldPlay("pindleskin", now); !ok {
+		t.Errorf("Expected other runs to be played")
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	e := NewEngine(map[string]Policy{"cows": {MaxRetries: 1, Cooldown: 600}})
+	now := time.Unix(0, 0)
+
+	if d := e.Finished("cows", ReasonError, now); d != Retry {
+		t.Fatalf("Expected a retry, got %s", d)
+	}
+	if ok, _ := e.ShouldPlay("cows", now); !ok {
+		t.Errorf("Expected no cooldown while retrying")
+	}
+
+	e.Finished("cows", ReasonError, now)
+	if ok, _ := e.ShouldPlay("cows", now.Add(5*time.Minute)); ok {
+		t.Errorf("Expected the run to be on cooldown")
+	}
+	if ok, _ := e.ShouldPlay("cows", now.Add(10*time.Minute)); !ok {
+		t.Errorf("Expected the run to be played once the cooldown is over")
+	}
+}
+
+func TestFailOn(t *testing.T) {
+	e := NewEngine(map[string]Policy{"diablo": {SkipAfter: 2, MaxRetries: 5, OnFailure: OnFailureContinue, FailOn: []Reason{ReasonChicken, ReasonDied}}})
+	now := time.Unix(0, 0)
+
+	if d := e.Finished("diablo", ReasonError, now); d != EndGame {
+		t.Errorf("Expected errors not counted as failures to end the game, got %s", d)
+	}
+	if d := e.Finished("diablo", ReasonChicken, now); d != EndGame {
+		t.Errorf("Expected a chicken to always end the game, got %s", d)
+	}
+	e.Finished("diablo", ReasonDied, now)
+	if ok, _ := e.ShouldPlay("diablo", now); ok {
+		t.Errorf("Expected chickens and deaths to be counted as failures")
+	}
+}
+
+func TestSetPoliciesKeepsResults(t *testing.T) {
+	e := NewEngine(map[string]Policy{"nihlathak": {SkipAfter: 2}})
+	now := time.Unix(0, 0)
+
+	e.Finished("nihlathak", ReasonError, now)
+	e.SetPolicies(map[string]Policy{"nihlathak": {SkipAfter: 2}})
+	e.Finished("nihlathak", ReasonError, now)
+	if ok, _ := e.ShouldPlay("nihlathak", now); ok {
+		t.Errorf("Expected the failures before the reload to be counted")
+	}
+}
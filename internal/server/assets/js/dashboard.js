@@ -117,6 +117,12 @@ function createCharacterCard(key) {
                           <button class="btn btn-outline reset-muling-btn" data-character-name="${key}" title="Reset Muling Progress">
                               <i class="bi bi-arrow-counterclockwise"></i>
                           </button>
+                      <button class="btn btn-outline" onclick="showLogsPopup('${key}')" title="Logs">
+                          <i class="bi bi-journal-text"></i>
+                      </button>
+                      <button class="btn btn-outline" onclick="showItemDecisionsPopup('${key}')" title="Item decisions">
+                          <i class="bi bi-bag-check"></i>
+                      </button>
                       <button class="btn btn-outline" onclick="location.href='/supervisorSettings?supervisor=${key}'" title="Settings">
                           <i class="bi bi-gear"></i>
                       </button>
@@ -1101,6 +1107,8 @@ async function reloadConfig() {
     if (!response.ok) {
       throw new Error("Failed to reload config");
     }
+    const result = await response.json();
+    showConfigDiff(result.diffs || []);
   } catch (error) {
     console.error("Error reloading config:", error);
   } finally {
@@ -1110,6 +1118,212 @@ async function reloadConfig() {
   }
 }
 
+const configScopeLabels = {
+  now: "Applied between runs",
+  next_game: "Applied on next game",
+  restart: "Requires restart",
+};
+
+function showConfigDiff(diffs) {
+  if (diffs.length === 0) {
+    return;
+  }
+
+  const formatValue = (value) =>
+    typeof value === "object" ? JSON.stringify(value) : String(value);
+  const appendElement = (parent, tag, text) => {
+    const element = document.createElement(tag);
+    if (text !== undefined) {
+      element.textContent = text;
+    }
+    parent.appendChild(element);
+    return element;
+  };
+
+  const popup = document.createElement("div");
+  popup.className = "attach-popup";
+  appendElement(popup, "h3", "Config Reloaded");
+  diffs.forEach((diff) => {
+    appendElement(popup, "h4", diff.supervisor);
+    const table = appendElement(popup, "table");
+    const headerRow = appendElement(appendElement(table, "thead"), "tr");
+    ["Setting", "Old", "New", "When"].forEach((header) =>
+      appendElement(headerRow, "th", header)
+    );
+    const body = appendElement(table, "tbody");
+    diff.changes.forEach((change) => {
+      const row = appendElement(body, "tr");
+      appendElement(row, "td", change.path);
+      appendElement(row, "td", formatValue(change.old));
+      appendElement(row, "td", formatValue(change.new));
+      appendElement(row, "td", configScopeLabels[change.scope] || change.scope);
+    });
+  });
+
+  const closeButton = appendElement(popup, "button", "Close");
+  closeButton.className = "btn btn-primary";
+  closeButton.addEventListener("click", closeAttachPopup);
+  document.body.appendChild(popup);
+}
+
+function showLogsPopup(supervisor) {
+  const popup = document.createElement("div");
+  popup.className = "attach-popup"; // Reuse the attach popup styling
+  popup.innerHTML = `
+            <h3>${supervisor} logs</h3>
+            <div class="popup-content">
+                <div class="form-group">
+                    <label for="logs-level">Level:</label>
+                    <select id="logs-level">
+                        <option value="debug">Debug</option>
+                        <option value="info" selected>Info</option>
+                        <option value="warn">Warning</option>
+                        <option value="error">Error</option>
+                    </select>
+                    <label for="logs-since">Since:</label>
+                    <select id="logs-since">
+                        <option value="">Any time</option>
+                        <option value="15m">Last 15 minutes</option>
+                        <option value="1h">Last hour</option>
+                        <option value="6h">Last 6 hours</option>
+                    </select>
+                </div>
+                <div class="logs-entries"></div>
+                <div class="popup-buttons">
+                    <button id="logs-refresh" class="btn btn-primary">Refresh</button>
+                    <button onclick="closeAttachPopup()" class="btn">Close</button>
+                </div>
+            </div>
+        `;
+  document.body.appendChild(popup);
+
+  const refresh = () => loadLogs(supervisor, popup);
+  popup.querySelector("#logs-refresh").addEventListener("click", refresh);
+  popup.querySelector("#logs-level").addEventListener("change", refresh);
+  popup.querySelector("#logs-since").addEventListener("change", refresh);
+  refresh();
+}
+
+async function loadLogs(supervisor, popup) {
+  const params = new URLSearchParams({
+    supervisor: supervisor,
+    level: popup.querySelector("#logs-level").value,
+  });
+  const since = popup.querySelector("#logs-since").value;
+  if (since) {
+    params.set("since", since);
+  }
+
+  const container = popup.querySelector(".logs-entries");
+  try {
+    const response = await fetch(`/api/logs?${params}`);
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    const entries = await response.json();
+    if (entries.length === 0) {
+      container.textContent = "No log entries";
+      return;
+    }
+
+    const table = document.createElement("table");
+    entries.forEach((entry) => {
+      const attrs = Object.entries(entry.attrs || {})
+        .map(([key, value]) => `${key}=${value}`)
+        .join(" ");
+      const row = table.insertRow();
+      row.insertCell().textContent = new Date(entry.time).toLocaleTimeString();
+      row.insertCell().textContent = entry.level;
+      row.insertCell().textContent = `${entry.message} ${attrs}`;
+    });
+    container.replaceChildren(table);
+  } catch (error) {
+    container.textContent = `Error loading logs: ${error.message}`;
+  }
+}
+
+function showItemDecisionsPopup(supervisor) {
+  const popup = document.createElement("div");
+  popup.className = "attach-popup"; // Reuse the attach popup styling
+  popup.innerHTML = `
+            <h3>${supervisor} item decisions</h3>
+            <div class="popup-content">
+                <div class="form-group">
+                    <label for="item-decisions-decision">Decision:</label>
+                    <select id="item-decisions-decision">
+                        <option value="">Any</option>
+                        <option value="pickup">Pickup</option>
+                        <option value="skip">Skip</option>
+                        <option value="stash">Stash</option>
+                        <option value="keep">Keep</option>
+                        <option value="drop">Drop</option>
+                        <option value="sell">Sell</option>
+                        <option value="blacklist">Blacklist</option>
+                    </select>
+                    <label for="item-decisions-item">Item:</label>
+                    <input type="text" id="item-decisions-item" placeholder="Item name">
+                </div>
+                <div class="logs-entries"></div>
+                <div class="popup-buttons">
+                    <button id="item-decisions-refresh" class="btn btn-primary">Refresh</button>
+                    <button onclick="closeAttachPopup()" class="btn">Close</button>
+                </div>
+            </div>
+        `;
+  document.body.appendChild(popup);
+
+  const refresh = () => loadItemDecisions(supervisor, popup);
+  popup.querySelector("#item-decisions-refresh").addEventListener("click", refresh);
+  popup.querySelector("#item-decisions-decision").addEventListener("change", refresh);
+  popup.querySelector("#item-decisions-item").addEventListener("change", refresh);
+  refresh();
+}
+
+async function loadItemDecisions(supervisor, popup) {
+  const params = new URLSearchParams({
+    supervisor: supervisor,
+    decision: popup.querySelector("#item-decisions-decision").value,
+    item: popup.querySelector("#item-decisions-item").value,
+  });
+
+  const container = popup.querySelector(".logs-entries");
+  try {
+    const response = await fetch(`/api/item-decisions?${params}`);
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    const records = await response.json();
+    if (records.length === 0) {
+      container.textContent = "No item decisions";
+      return;
+    }
+
+    const table = document.createElement("table");
+    records.forEach((record) => {
+      const details = [record.reason];
+      if (record.ruleFile) {
+        details.push(`${record.ruleFile} ${record.rule}`);
+      }
+      if (record.tier) {
+        details.push(
+          `${record.tier.merc ? "merc " : ""}tier ${record.tier.tier} ${record.tier.bodyLocation}: ${record.tier.itemScore.toFixed(1)} vs equipped ${record.tier.equippedScore.toFixed(1)}`
+        );
+      }
+      if (record.detail) {
+        details.push(record.detail);
+      }
+      const row = table.insertRow();
+      row.insertCell().textContent = new Date(record.time).toLocaleTimeString();
+      row.insertCell().textContent = record.decision;
+      row.insertCell().textContent = `${record.item.name} [${record.item.quality}]`;
+      row.insertCell().textContent = details.join(" | ");
+    });
+    container.replaceChildren(table);
+  } catch (error) {
+    container.textContent = `Error loading item decisions: ${error.message}`;
+  }
+}
+
 function closeAttachPopup() {
   const popup = document.querySelector(".attach-popup");
   if (popup) {
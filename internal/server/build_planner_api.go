@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+type buildPlanRequest struct {
+	Class string `json:"class"`
+	// Build is used as is when provided, otherwise BuildName (or the class name) is read from builds_leveling, the
+	// builds of Profile take precedence over the template ones, only the template builds are read without profile
+	Build     *config.LevelingBuildConfig `json:"build,omitempty"`
+	BuildName string                      `json:"buildName,omitempty"`
+	Profile   string                      `json:"profile,omitempty"`
+	// Sequence is optional, when set the difficulty transition levels are taken from it
+	Sequence       string                       `json:"sequence,omitempty"`
+	NightmareLevel int                          `json:"nightmareLevel,omitempty"`
+	HellLevel      int                          `json:"hellLevel,omitempty"`
+	QuestRewards   []config.LevelingQuestReward `json:"questRewards,omitempty"`
+}
+
+type buildPlanResponse struct {
+	config.LevelingSimulation
+	QuestRewards []config.LevelingQuestReward `json:"questRewards"`
+}
+
+// handleBuildPlan simulates the stat/skill allocation of a leveling build from level 1 to 99
+func (api *SequenceAPI) handleBuildPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var req buildPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	build := req.Build
+	if build == nil {
+		buildName := req.BuildName
+		if buildName == "" {
+			buildName = req.Class
+		}
+		name, err := api.extractSequenceName(buildName)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid build name: %s", err), http.StatusBadRequest)
+			return
+		}
+		if req.Profile != "" {
+			if _, found := config.GetCharacter(req.Profile); !found {
+				http.Error(w, "profile not found", http.StatusNotFound)
+				return
+			}
+		}
+		build, err = config.ReadLevelingBuild(req.Profile, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if build == nil {
+			http.Error(w, "build file not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	if req.Sequence != "" {
+		if err := api.applySequenceLevels(&req); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				http.Error(w, "sequence file not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rewards := req.QuestRewards
+	if len(rewards) == 0 {
+		rewards = config.DefaultLevelingQuestRewards(req.NightmareLevel, req.HellLevel)
+	}
+
+	simulation, err := config.SimulateLevelingBuild(req.Class, build, rewards)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	api.writeJSON(w, http.StatusOK, buildPlanResponse{
+		LevelingSimulation: simulation,
+		QuestRewards:       rewards,
+	})
+}
+
+// applySequenceLevels takes the level conditions to move to the next difficulty from the given sequence file,
+// explicit levels in the request have precedence
+func (api *SequenceAPI) applySequenceLevels(req *buildPlanRequest) error {
+	name, err := api.extractSequenceName(req.Sequence)
+	if err != nil {
+		return err
+	}
+
+	dir, err := api.baseDir()
+	if err != nil {
+		return err
+	}
+
+	settings, err := api.readSequenceFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return err
+	}
+
+	if req.NightmareLevel == 0 && settings.Normal.NextDifficultyConditions != nil && settings.Normal.NextDifficultyConditions.Level != nil {
+		req.NightmareLevel = *settings.Normal.NextDifficultyConditions.Level
+	}
+	if req.HellLevel == 0 && settings.Nightmare.NextDifficultyConditions != nil && settings.Nightmare.NextDifficultyConditions.Level != nil {
+		req.HellLevel = *settings.Nightmare.NextDifficultyConditions.Level
+	}
+
+	return nil
+}
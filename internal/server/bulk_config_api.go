@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+type bulkConfigResponse struct {
+	Operation *config.BulkOperation      `json:"operation,omitempty"`
+	Profiles  []config.BulkProfileResult `json:"profiles,omitempty"`
+	// Diffs are the changes queued to the running supervisors after reloading the configs
+	Diffs []config.ConfigDiff `json:"diffs,omitempty"`
+	Error string              `json:"error,omitempty"`
+}
+
+// bulkConfigPreview returns the changes a bulk patch would make to every selected profile, without saving anything
+func (s *HttpServer) bulkConfigPreview(w http.ResponseWriter, r *http.Request) {
+	patch, ok := decodeBulkPatch(w, r)
+	if !ok {
+		return
+	}
+
+	profiles, err := config.PreviewBulkPatch(patch)
+	if err != nil {
+		writeBulkConfigResponse(w, http.StatusBadRequest, bulkConfigResponse{Error: err.Error()})
+		return
+	}
+
+	writeBulkConfigResponse(w, http.StatusOK, bulkConfigResponse{Profiles: profiles})
+}
+
+// bulkConfigApply saves a bulk patch to every selected profile and reloads the configs
+func (s *HttpServer) bulkConfigApply(w http.ResponseWriter, r *http.Request) {
+	patch, ok := decodeBulkPatch(w, r)
+	if !ok {
+		return
+	}
+
+	op, err := config.ApplyBulkPatch(patch)
+	if errors.Is(err, config.ErrInvalidBulkPatch) {
+		writeBulkConfigResponse(w, http.StatusUnprocessableEntity, bulkConfigResponse{Profiles: op.Profiles, Error: err.Error()})
+		return
+	}
+	if err != nil {
+		response, status := bulkConfigResponse{Error: err.Error()}, http.StatusBadRequest
+		if op.ID != "" {
+			// Some profiles may be saved already, return the operation so it can be rolled back
+			response.Operation, status = &op, http.StatusInternalServerError
+		}
+		writeBulkConfigResponse(w, status, response)
+		return
+	}
+
+	s.logger.Info("Bulk config patch applied", "operation", op.ID, "profiles", len(op.Profiles))
+	s.writeReloadedBulkConfig(w, &op)
+}
+
+func (s *HttpServer) bulkConfigOperations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ops, err := config.BulkOperations()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ops)
+}
+
+// bulkConfigRollback restores the configs changed by a bulk operation and reloads them
+func (s *HttpServer) bulkConfigRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	op, err := config.RollbackBulkOperation(r.URL.Query().Get("id"))
+	if err != nil {
+		writeBulkConfigResponse(w, http.StatusBadRequest, bulkConfigResponse{Error: err.Error()})
+		return
+	}
+
+	s.logger.Info("Bulk config operation rolled back", "operation", op.ID, "profiles", len(op.Profiles))
+	s.writeReloadedBulkConfig(w, &op)
+}
+
+func (s *HttpServer) writeReloadedBulkConfig(w http.ResponseWriter, op *config.BulkOperation) {
+	diffs, err := s.manager.ReloadConfig()
+	if err != nil {
+		writeBulkConfigResponse(w, http.StatusInternalServerError, bulkConfigResponse{Operation: op, Error: err.Error()})
+		return
+	}
+
+	writeBulkConfigResponse(w, http.StatusOK, bulkConfigResponse{Operation: op, Profiles: op.Profiles, Diffs: diffs})
+}
+
+func decodeBulkPatch(w http.ResponseWriter, r *http.Request) (config.BulkPatch, bool) {
+	patch := config.BulkPatch{}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return patch, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeBulkConfigResponse(w, http.StatusBadRequest, bulkConfigResponse{Error: "invalid patch: " + err.Error()})
+		return patch, false
+	}
+
+	return patch, true
+}
+
+func writeBulkConfigResponse(w http.ResponseWriter, status int, response bulkConfigResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/action"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/mule"
+)
+
+type craftingReportResponse struct {
+	Supervisor string    `json:"supervisor"`
+	CapturedAt time.Time `json:"capturedAt"`
+	action.CraftingReport
+}
+
+// craftingReport reports the cube recipes and runewords that can be made with the stash of the given supervisor. It
+// uses the last stash snapshot, saved by the bot every time the stash is used, so it never touches the running game.
+func (s *HttpServer) craftingReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	supervisor := r.URL.Query().Get("supervisor")
+	cfg, found := config.GetCharacter(supervisor)
+	if supervisor == "" || !found {
+		http.Error(w, "unknown supervisor", http.StatusNotFound)
+		return
+	}
+
+	snapshot, err := mule.LoadStashSnapshot(supervisor)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "no stash snapshot available for "+supervisor+", start the supervisor and open the stash first", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(craftingReportResponse{
+		Supervisor:     supervisor,
+		CapturedAt:     snapshot.CapturedAt,
+		CraftingReport: action.BuildCraftingReport(cfg, snapshot.Items),
+	})
+}
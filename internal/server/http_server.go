@@ -35,6 +35,7 @@ import (
 	ctx "github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/mule"
 	"github.com/hectorgimenez/koolo/internal/remote/droplog"
 	"github.com/hectorgimenez/koolo/internal/utils"
 	"github.com/hectorgimenez/koolo/internal/utils/winproc"
@@ -50,6 +51,8 @@ type HttpServer struct {
 	wsServer    *WebSocketServer
 	pickitAPI   *PickitAPI
 	sequenceAPI *SequenceAPI
+	// statusRefresh triggers a status broadcast without waiting for the next tick
+	statusRefresh chan struct{}
 }
 
 var (
@@ -182,8 +185,23 @@ func (s *HttpServer) BroadcastStatus() {
 		}
 
 		s.wsServer.broadcast <- jsonData
-		time.Sleep(1 * time.Second)
+		select {
+		case <-s.statusRefresh:
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
+// Handle refreshes the dashboard as soon as a supervisor changes its lifecycle state
+func (s *HttpServer) Handle(_ context.Context, e event.Event) error {
+	if _, ok := e.(event.SupervisorStateChangedEvent); ok {
+		select {
+		case s.statusRefresh <- struct{}{}:
+		default:
+		}
 	}
+
+	return nil
 }
 
 func New(logger *slog.Logger, manager *bot.SupervisorManager) (*HttpServer, error) {
@@ -208,6 +226,7 @@ func New(logger *slog.Logger, manager *bot.SupervisorManager) (*HttpServer, erro
 		"qualityClass": qualityClass,
 		"statIDToText": statIDToText,
 		"contains":     containss,
+		"join":         strings.Join,
 		"seq": func(start, end int) []int {
 			var result []int
 			for i := start; i <= end; i++ {
@@ -233,6 +252,8 @@ func New(logger *slog.Logger, manager *bot.SupervisorManager) (*HttpServer, erro
 		templates:   templates,
 		pickitAPI:   NewPickitAPI(),
 		sequenceAPI: NewSequenceAPI(logger),
+		// Buffered so a pending refresh is never lost and senders never block
+		statusRefresh: make(chan struct{}, 1),
 	}, nil
 }
 
@@ -596,6 +617,15 @@ func (s *HttpServer) Listen(port int) error {
 	http.HandleFunc("/api/reload-config", s.reloadConfig)   // New handler
 	http.HandleFunc("/api/companion-join", s.companionJoin) // Companion join handler
 	http.HandleFunc("/reset-muling", s.resetMuling)
+	http.HandleFunc("/api/crafting-report", s.craftingReport)
+	http.HandleFunc("/api/muling-status", s.mulingStatus)
+	http.HandleFunc("/api/config/bulk/preview", s.bulkConfigPreview)
+	http.HandleFunc("/api/config/bulk/apply", s.bulkConfigApply)
+	http.HandleFunc("/api/config/bulk/operations", s.bulkConfigOperations)
+	http.HandleFunc("/api/config/bulk/rollback", s.bulkConfigRollback)
+	http.HandleFunc("/api/logs", s.logs)
+	http.HandleFunc("/api/item-decisions", s.itemDecisions)
+	http.HandleFunc("/api/supervisor-health", s.supervisorHealth)
 
 	// Pickit Editor routes
 	http.HandleFunc("/pickit-editor", s.pickitEditorPage)
@@ -626,6 +656,7 @@ func (s *HttpServer) Listen(port int) error {
 	http.HandleFunc("/api/sequence-editor/save", s.sequenceAPI.handleSaveSequence)
 	http.HandleFunc("/api/sequence-editor/delete", s.sequenceAPI.handleDeleteSequence)
 	http.HandleFunc("/api/sequence-editor/files", s.sequenceAPI.handleListSequenceFiles)
+	http.HandleFunc("/api/sequence-editor/build-plan", s.sequenceAPI.handleBuildPlan)
 
 	assets, _ := fs.Sub(assetsFS, "assets")
 	http.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(assets))))
@@ -642,14 +673,15 @@ func (s *HttpServer) Listen(port int) error {
 }
 
 func (s *HttpServer) reloadConfig(w http.ResponseWriter, r *http.Request) {
-	result := s.manager.ReloadConfig()
-	if result != nil {
-		http.Error(w, result.Error(), http.StatusInternalServerError)
+	diffs, err := s.manager.ReloadConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	s.logger.Info("Config reloaded")
-	w.WriteHeader(http.StatusOK)
+	s.logger.Info("Config reloaded", "supervisorsChanged", len(diffs))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"diffs": diffs})
 }
 
 func (s *HttpServer) Stop() error {
@@ -1018,7 +1050,6 @@ func (s *HttpServer) config(w http.ResponseWriter, r *http.Request) {
 		newConfig.Discord.EnableRunFinishMessages = r.Form.Has("enable_run_finish_messages")
 		newConfig.Discord.EnableDiscordChickenMessages = r.Form.Has("enable_discord_chicken_messages")
 		newConfig.Discord.EnableDiscordErrorMessages = r.Form.Has("enable_discord_error_messages")
-		newConfig.Discord.Token = r.Form.Get("discord_token")
 		newConfig.Discord.ChannelID = r.Form.Get("discord_channel_id")
 
 		// Discord admins who can use bot commands
@@ -1030,11 +1061,12 @@ func (s *HttpServer) config(w http.ResponseWriter, r *http.Request) {
 			return -1
 		}, discordAdmins)
 		newConfig.Discord.BotAdmins = strings.Split(cleanedAdmins, ",")
-		newConfig.Discord.Token = r.Form.Get("discord_token")
+		// Secrets are never sent to the page, an empty field keeps the stored one unless it's cleared explicitly
+		newConfig.Discord.Token = formSecret(r, "discord_token", newConfig.Discord.Token)
 		newConfig.Discord.ChannelID = r.Form.Get("discord_channel_id")
 		// Telegram
 		newConfig.Telegram.Enabled = r.Form.Get("telegram_enabled") == "true"
-		newConfig.Telegram.Token = r.Form.Get("telegram_token")
+		newConfig.Telegram.Token = formSecret(r, "telegram_token", newConfig.Telegram.Token)
 		telegramChatId, err := strconv.ParseInt(r.Form.Get("telegram_chat_id"), 10, 64)
 		if err != nil {
 			s.templates.ExecuteTemplate(w, "config.gohtml", ConfigData{KooloCfg: &newConfig, ErrorMessage: "Invalid Telegram Chat ID"})
@@ -1108,12 +1140,37 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 		cfg.CloseMiniPanel = r.Form.Has("close_mini_panel")
 		cfg.HidePortraits = r.Form.Has("hide_portraits")
 
+		// Config inheritance, only the settings different from the inherited ones are saved
+		cfg.Extends = strings.TrimSpace(r.Form.Get("extends"))
+		cfg.Include = nil
+		for _, fragment := range strings.Split(r.Form.Get("include"), ",") {
+			if fragment = strings.TrimSpace(fragment); fragment != "" {
+				cfg.Include = append(cfg.Include, fragment)
+			}
+		}
+		if err = config.ValidateConfigLayers(supervisorName, cfg.Extends, cfg.Include); err != nil {
+			s.templates.ExecuteTemplate(w, "character_settings.gohtml", CharacterSettings{
+				Version:               config.Version,
+				ErrorMessage:          err.Error(),
+				Supervisor:            supervisorName,
+				LevelingSequenceFiles: sequenceFiles,
+			})
+			return
+		}
+		cfg.Tags = nil
+		for _, tag := range strings.Split(r.Form.Get("tags"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				cfg.Tags = append(cfg.Tags, tag)
+			}
+		}
+
 		// Bnet config
 		cfg.Username = r.Form.Get("username")
-		cfg.Password = r.Form.Get("password")
+		// Secrets are never sent to the page, an empty field keeps the stored one unless it's cleared explicitly
+		cfg.Password = formSecret(r, "password", cfg.Password)
 		cfg.Realm = r.Form.Get("realm")
 		cfg.AuthMethod = r.Form.Get("authmethod")
-		cfg.AuthToken = r.Form.Get("AuthToken")
+		cfg.AuthToken = formSecret(r, "AuthToken", cfg.AuthToken)
 
 		// Scheduler config
 		cfg.Scheduler.Enabled = r.Form.Has("schedulerEnabled")
@@ -1186,6 +1243,11 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 		cfg.Character.UseExtraBuffs = r.Form.Has("characterUseExtraBuffs")
 		cfg.Character.BuffOnNewArea = r.Form.Has("characterBuffOnNewArea")
 		cfg.Character.BuffAfterWP = r.Form.Has("characterBuffAfterWP")
+		cfg.Character.UseHierarchicalPathing = r.Form.Has("characterUseHierarchicalPathing")
+		cfg.Character.DamageTypes = []stat.Resist{}
+		for _, damageType := range r.Form["characterDamageTypes[]"] {
+			cfg.Character.DamageTypes = append(cfg.Character.DamageTypes, stat.Resist(damageType))
+		}
 
 		// Process ClearPathDist - only relevant when teleport is disabled
 		if !cfg.Character.UseTeleport {
@@ -1370,6 +1432,7 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 		cfg.Game.Leveling.HellRequiredFireRes = s.getIntFromForm(r, "gameLevelingHellRequiredFireRes", -100, 75, 15)
 		cfg.Game.Leveling.HellRequiredLightRes = s.getIntFromForm(r, "gameLevelingHellRequiredLightRes", -100, 75, -10)
 
+		cfg.Game.Leveling.Build = strings.TrimSpace(r.Form.Get("gameLevelingBuild"))
 		cfg.Game.LevelingSequence.SequenceFile = r.Form.Get("gameLevelingSequenceFile")
 
 		// Socket Recipes
@@ -1425,6 +1488,7 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 		cfg.CubeRecipes.EnabledRecipes = enabledRecipes
 		cfg.CubeRecipes.SkipPerfectAmethysts = r.Form.Has("skipPerfectAmethysts")
 		cfg.CubeRecipes.SkipPerfectRubies = r.Form.Has("skipPerfectRubies")
+		cfg.CubeRecipes.KeepQuotas = parseKeepQuotas(r.Form.Get("cubeKeepQuotas"))
 
 		// Companion config
 		cfg.Companion.Enabled = r.Form.Has("companionEnabled")
@@ -1455,6 +1519,15 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 
 		cfg.Muling.ReturnTo = r.FormValue("mulingReturnTo")
 
+		if errs := cfg.Check(); len(errs) > 0 {
+			// The form was applied to the loaded config, reload it to discard the invalid settings
+			if err = config.Load(); err != nil {
+				s.logger.Error("Failed to reload config after validation errors", slog.Any("error", err))
+			}
+			s.renderCharacterSettings(w, supervisorName, cfg, sequenceFiles, "Some settings are not valid, please check the highlighted fields")
+			return
+		}
+
 		config.SaveSupervisorConfig(supervisorName, cfg)
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
@@ -1466,6 +1539,11 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 		cfg, _ = config.GetCharacter(supervisor)
 	}
 
+	s.renderCharacterSettings(w, supervisor, cfg, sequenceFiles, "")
+}
+
+// renderCharacterSettings shows the settings form for the given config, invalid settings are highlighted
+func (s *HttpServer) renderCharacterSettings(w http.ResponseWriter, supervisor string, cfg *config.CharacterCfg, sequenceFiles []string, errorMessage string) {
 	enabledRuns := make([]string, 0)
 	// Let's iterate cfg.Game.Runs to preserve current order
 	for _, run := range cfg.Game.Runs {
@@ -1522,6 +1600,8 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 
 	s.templates.ExecuteTemplate(w, "character_settings.gohtml", CharacterSettings{
 		Version:               config.Version,
+		ErrorMessage:          errorMessage,
+		FieldErrors:           cfg.Check().ByField(),
 		Supervisor:            supervisor,
 		Config:                cfg,
 		DayNames:              dayNames,
@@ -1533,6 +1613,7 @@ func (s *HttpServer) characterSettings(w http.ResponseWriter, r *http.Request) {
 		AvailableProfiles:     muleProfiles,
 		FarmerProfiles:        farmerProfiles,
 		LevelingSequenceFiles: sequenceFiles,
+		LevelingBuilds:        config.ListLevelingBuilds(supervisor),
 	})
 }
 
@@ -1607,12 +1688,9 @@ func (s *HttpServer) resetMuling(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.logger.Info("Resetting muling index for character", "character", characterName)
-	cfg.MulingState.CurrentMuleIndex = 0
-
-	err := config.SaveSupervisorConfig(characterName, cfg)
-	if err != nil {
-		http.Error(w, "Failed to save updated config", http.StatusInternalServerError)
+	s.logger.Info("Resetting muling rotation for character", "character", characterName, "mules", cfg.Muling.MuleProfiles)
+	if err := mule.ResetState(characterName); err != nil {
+		http.Error(w, "Failed to reset muling state", http.StatusInternalServerError)
 		return
 	}
 
@@ -1691,3 +1769,34 @@ func (s *HttpServer) getIntFromForm(r *http.Request, param string, min int, max
 	}
 	return result
 }
+
+// parseKeepQuotas parses "RalRune:2 PerfectAmethyst:3" (spaces or commas as separator), invalid entries are ignored
+func parseKeepQuotas(value string) map[string]int {
+	quotas := make(map[string]int)
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+		name, amount, found := strings.Cut(entry, ":")
+		if !found {
+			continue
+		}
+		qty, err := strconv.Atoi(strings.TrimSpace(amount))
+		if err != nil || qty <= 0 || strings.TrimSpace(name) == "" {
+			continue
+		}
+		quotas[strings.TrimSpace(name)] = qty
+	}
+
+	return quotas
+}
+
+// formSecret returns the secret submitted in the form field, the current one when the field is left empty, or an
+// empty value when its "<field>_clear" checkbox is set, which removes it from the secret store on save
+func formSecret(r *http.Request, field, current string) string {
+	if r.Form.Has(field + "_clear") {
+		return ""
+	}
+	if value := r.Form.Get(field); value != "" {
+		return value
+	}
+
+	return current
+}
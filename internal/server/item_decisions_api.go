@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/hectorgimenez/koolo/internal/itemlog"
+)
+
+const (
+	defaultItemDecisionsLimit = 200
+	maxItemDecisionsLimit     = 1000
+)
+
+// itemDecisions returns the most recent pickup, stash, drop, sell and blacklist decisions of a supervisor, filtered by
+// decision, item name and time (same format as the logs)
+func (s *HttpServer) itemDecisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := itemlog.Filter{
+		Supervisor: query.Get("supervisor"),
+		Decision:   itemlog.Decision(query.Get("decision")),
+		Item:       query.Get("item"),
+		Limit:      defaultItemDecisionsLimit,
+	}
+	if filter.Supervisor == "" {
+		http.Error(w, "supervisor is required", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if filter.Since, err = parseLogsTime(query.Get("since")); err != nil {
+		http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(filter.Limit, maxItemDecisionsLimit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(itemlog.Query(filter))
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hectorgimenez/koolo/cmd/koolo/log"
+)
+
+const (
+	defaultLogsLimit = 200
+	maxLogsLimit     = 2000
+)
+
+// logs returns the most recent log entries, filtered by supervisor (empty for Koolo itself, missing for every
+// logger), minimum level and time. Times are RFC3339 or durations relative to now, e.g. since=15m.
+func (s *HttpServer) logs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := log.Filter{Limit: defaultLogsLimit}
+	if query.Has("supervisor") {
+		supervisor := query.Get("supervisor")
+		filter.Supervisor = &supervisor
+	}
+
+	if levelName := query.Get("level"); levelName != "" {
+		level, err := log.ParseLevel(levelName)
+		if err != nil {
+			http.Error(w, "invalid level: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.MinLevel = &level
+	}
+
+	var err error
+	if filter.Since, err = parseLogsTime(query.Get("since")); err != nil {
+		http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseLogsTime(query.Get("until")); err != nil {
+		http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(filter.Limit, maxLogsLimit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(log.Tail(filter))
+}
+
+func parseLogsTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a RFC3339 time nor a duration", value)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/mule"
+)
+
+type muleStatus struct {
+	Name string `json:"name"`
+	// Known is false when the mule has no stash snapshot since the last reset, it's considered to have room
+	Known bool `json:"known"`
+	mule.Capacity
+}
+
+type mulingStatusResponse struct {
+	Supervisor string       `json:"supervisor"`
+	State      mule.State   `json:"state"`
+	Mules      []muleStatus `json:"mules"`
+}
+
+// mulingStatus reports the muling rotation of a farming character and the known capacity of each of its mules
+func (s *HttpServer) mulingStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	supervisor := r.URL.Query().Get("supervisor")
+	cfg, found := config.GetCharacter(supervisor)
+	if supervisor == "" || !found {
+		http.Error(w, "unknown supervisor", http.StatusNotFound)
+		return
+	}
+
+	state, err := mule.LoadState(supervisor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	muleManager := mule.NewManager(s.logger)
+	response := mulingStatusResponse{Supervisor: supervisor, State: state, Mules: make([]muleStatus, 0, len(cfg.Muling.MuleProfiles))}
+	for _, name := range cfg.Muling.MuleProfiles {
+		capacity, known := muleManager.Capacity(name, state)
+		response.Mules = append(response.Mules, muleStatus{Name: name, Known: known, Capacity: capacity})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
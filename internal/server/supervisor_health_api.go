@@ -0,0 +1,26 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+// supervisorHealth returns the restart history of a supervisor, whether it was disabled by the circuit breaker and
+// its recent incidents
+func (s *HttpServer) supervisorHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	supervisor := r.URL.Query().Get("supervisor")
+	if _, found := config.GetCharacter(supervisor); supervisor == "" || !found {
+		http.Error(w, "unknown supervisor", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.manager.Health(supervisor))
+}
@@ -38,6 +38,7 @@ type AllDropRecord struct {
 type CharacterSettings struct {
 	Version               string
 	ErrorMessage          string
+	FieldErrors           map[string]string // Validation error of every invalid setting, by yaml path
 	Supervisor            string
 	Config                *config.CharacterCfg
 	DayNames              []string
@@ -49,6 +50,7 @@ type CharacterSettings struct {
 	AvailableProfiles     []string
 	FarmerProfiles        []string
 	LevelingSequenceFiles []string
+	LevelingBuilds        []string
 }
 
 type ConfigData struct {
@@ -0,0 +1,225 @@
+package threat
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// defaultModel is the threat model shipped with the bot, config/threat_model.json replaces it if present
+//
+//go:embed threat_model.json
+var defaultModel []byte
+
+// supportedEnchantments are the enchantments the bot can read from a monster, the elemental ones leave a damage stat
+// on the monster. Multishot, cursed, extra fast and the rest leave nothing we can read, so they can't be scored.
+var supportedEnchantments = []string{"lightning_enchanted", "cold_enchanted", "fire_enchanted"}
+
+var (
+	mu sync.RWMutex
+	// current is empty until Load is called, every monster scores the same and only the distance matters
+	current Model
+)
+
+// StateThreat scores monsters having the given state, auras like conviction or fanaticism set a state on the monster.
+// Radius is how far the monster is dangerous with the state, e.g. the aura radius, 0 keeps Model.DangerRadius.
+type StateThreat struct {
+	Name   string  `json:"name"`
+	State  int     `json:"state"`
+	Score  float64 `json:"score"`
+	Radius int     `json:"radius,omitempty"`
+}
+
+// Model describes how dangerous a monster is, the monsters with the highest score are attacked first
+type Model struct {
+	// Kinds score the monster type: normal, minion, champion, unique or superunique
+	Kinds map[string]float64 `json:"kinds"`
+	// Tags score groups of monsters, e.g. shamans or monsters raising others
+	Tags map[string]float64 `json:"tags"`
+	// Monsters score specific monsters by npc ID, added to the other scores
+	Monsters map[string]float64 `json:"monsters"`
+	// Enchantments score elite monsters by enchantment, only lightning_enchanted, cold_enchanted and fire_enchanted
+	// are supported
+	Enchantments map[string]float64 `json:"enchantments"`
+	States       []StateThreat      `json:"states"`
+	// ImmuneToAllPenalty is subtracted when the monster is immune to every damage type of the character,
+	// ImmunityPenalty for every immunity to one of them otherwise
+	ImmuneToAllPenalty float64 `json:"immuneToAllPenalty"`
+	ImmunityPenalty    float64 `json:"immunityPenalty"`
+	// DistancePenalty is subtracted for every tile between the player and the monster
+	DistancePenalty float64 `json:"distancePenalty"`
+	// CloseBonus is added to monsters within CloseRange, the ones already hitting us
+	CloseRange int     `json:"closeRange"`
+	CloseBonus float64 `json:"closeBonus"`
+	// DensityBonus is added for every other monster within DensityRadius, attacking packs first
+	DensityRadius int     `json:"densityRadius"`
+	DensityBonus  float64 `json:"densityBonus"`
+	// DangerBase and DangerRadius describe the danger around any monster, used to find safe positions
+	DangerBase   float64 `json:"dangerBase"`
+	DangerRadius int     `json:"dangerRadius"`
+}
+
+// Target is a monster as seen by the threat model
+type Target struct {
+	ID           int
+	Kind         string
+	Tags         []string
+	Enchantments []string
+	States       []int
+	Immunities   []string
+	// Distance to the player and number of other monsters close to this one
+	Distance int
+	Nearby   int
+}
+
+// Load reads the threat model from the given file when it exists, otherwise the embedded model is used
+func Load(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		m, err := parseModel(defaultModel)
+		if err != nil {
+			return fmt.Errorf("error loading embedded threat model: %w", err)
+		}
+		set(m)
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading threat model %s: %w", path, err)
+	}
+	m, err := parseModel(content)
+	if err != nil {
+		return fmt.Errorf("error loading threat model %s: %w", path, err)
+	}
+	set(m)
+
+	return nil
+}
+
+// Current returns the loaded threat model
+func Current() Model {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return current
+}
+
+func set(m Model) {
+	mu.Lock()
+	current = m
+	mu.Unlock()
+}
+
+func parseModel(content []byte) (Model, error) {
+	m := Model{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return m, err
+	}
+
+	for id := range m.Monsters {
+		if _, err := strconv.Atoi(id); err != nil {
+			return m, fmt.Errorf("monster %q must be an npc ID", id)
+		}
+	}
+	for enchantment := range m.Enchantments {
+		if !slices.Contains(supportedEnchantments, enchantment) {
+			return m, fmt.Errorf("enchantment %q is not supported, only %v can be read from the monsters", enchantment, supportedEnchantments)
+		}
+	}
+	if m.CloseRange < 0 || m.DensityRadius < 0 || m.DangerRadius < 0 {
+		return m, errors.New("closeRange, densityRadius and dangerRadius can not be negative")
+	}
+
+	return m, nil
+}
+
+// Score returns how dangerous the target is, damageTypes are the elements the character deals damage with (fire,
+// cold, light, poison...), no immunity penalty is applied when empty
+func (m Model) Score(t Target, damageTypes []string) float64 {
+	score := m.intrinsic(t)
+
+	if len(damageTypes) > 0 {
+		immune := 0
+		for _, damageType := range damageTypes {
+			if slices.Contains(t.Immunities, damageType) {
+				immune++
+			}
+		}
+		if immune == len(damageTypes) {
+			score -= m.ImmuneToAllPenalty
+		} else {
+			score -= m.ImmunityPenalty * float64(immune)
+		}
+	}
+
+	if t.Distance <= m.CloseRange {
+		score += m.CloseBonus
+	}
+	score -= m.DistancePenalty * float64(t.Distance)
+	score += m.DensityBonus * float64(t.Nearby)
+
+	return score
+}
+
+// Danger returns how dangerous it is to stand close to the target, only what the monster is is taken into account
+func (m Model) Danger(t Target) float64 {
+	return max(m.DangerBase+m.intrinsic(t), 0)
+}
+
+// Radius returns how far from the target its danger reaches, auras reach further than the monster itself
+func (m Model) Radius(t Target) int {
+	radius := m.DangerRadius
+	for _, s := range m.States {
+		if slices.Contains(t.States, s.State) {
+			radius = max(radius, s.Radius)
+		}
+	}
+
+	return radius
+}
+
+// intrinsic is the score of the monster type, tags, enchantments and states
+func (m Model) intrinsic(t Target) float64 {
+	score := m.Kinds[t.Kind] + m.Monsters[strconv.Itoa(t.ID)]
+	for _, tag := range t.Tags {
+		score += m.Tags[tag]
+	}
+	for _, enchantment := range t.Enchantments {
+		score += m.Enchantments[enchantment]
+	}
+	for _, s := range m.States {
+		if slices.Contains(t.States, s.State) {
+			score += s.Score
+		}
+	}
+
+	return score
+}
+
+// Order returns the indexes of the targets from the most to the least dangerous, ties go to the closest target and
+// then to the input order
+func (m Model) Order(targets []Target, damageTypes []string) []int {
+	scores := make([]float64, len(targets))
+	order := make([]int, len(targets))
+	for i, t := range targets {
+		scores[i] = m.Score(t, damageTypes)
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case scores[a] > scores[b]:
+			return -1
+		case scores[a] < scores[b]:
+			return 1
+		}
+		return targets[a].Distance - targets[b].Distance
+	})
+
+	return order
+}
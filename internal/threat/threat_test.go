@@ -0,0 +1,157 @@
+package threat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// embeddedModel returns the threat model shipped with the bot
+func embeddedModel(t *testing.T) Model {
+	t.Helper()
+
+	m, err := parseModel(defaultModel)
+	if err != nil {
+		t.Fatalf("Expected the embedded threat model to be valid, got %v", err)
+	}
+
+	return m
+}
+
+func TestShamanFirst(t *testing.T) {
+	m := embeddedModel(t)
+	targets := []Target{
+		{ID: 19, Kind: "normal", Distance: 6},
+		{ID: 58, Kind: "normal", Tags: []string{"shaman"}, Distance: 9},
+	}
+
+	if order := m.Order(targets, nil); order[0] != 1 {
+		t.Errorf("Expected the shaman to be attacked first, got %v", order)
+	}
+}
+
+func TestCloseMonstersFirst(t *testing.T) {
+	m := embeddedModel(t)
+	targets := []Target{
+		{ID: 58, Kind: "normal", Tags: []string{"shaman"}, Distance: 12},
+		{ID: 19, Kind: "normal", Distance: 1},
+	}
+
+	if order := m.Order(targets, nil); order[0] != 1 {
+		t.Errorf("Expected the monster hitting us to be attacked before a far shaman, got %v", order)
+	}
+}
+
+func TestAurasAndEnchantments(t *testing.T) {
+	m := embeddedModel(t)
+	targets := []Target{
+		{Kind: "champion", Distance: 8},
+		{Kind: "minion", States: []int{28}, Distance: 8},
+		{Kind: "champion", Enchantments: []string{"lightning_enchanted", "cold_enchanted"}, Distance: 10},
+	}
+
+	order := m.Order(targets, nil)
+	if order[0] != 2 || order[1] != 1 || order[2] != 0 {
+		t.Errorf("Expected order [2 1 0], got %v", order)
+	}
+}
+
+func TestImmunities(t *testing.T) {
+	m := embeddedModel(t)
+	damageTypes := []string{"fire", "cold"}
+	base := Target{Kind: "champion", Distance: 5}
+
+	immuneToOne := base
+	immuneToOne.Immunities = []string{"fire"}
+	immuneToAll := base
+	immuneToAll.Immunities = []string{"fire", "cold"}
+	otherImmunity := base
+	otherImmunity.Immunities = []string{"light"}
+
+	if m.Score(otherImmunity, damageTypes) != m.Score(base, damageTypes) {
+		t.Errorf("Expected immunities to other damage types to be ignored")
+	}
+	if m.Score(immuneToOne, damageTypes) >= m.Score(base, damageTypes) {
+		t.Errorf("Expected a partial immunity to lower the score")
+	}
+	if m.Score(immuneToAll, damageTypes) >= m.Score(immuneToOne, damageTypes) {
+		t.Errorf("Expected monsters immune to every damage type to be attacked last")
+	}
+	if m.Score(immuneToAll, nil) != m.Score(base, nil) {
+		t.Errorf("Expected immunities to be ignored without damage types")
+	}
+}
+
+func TestDensity(t *testing.T) {
+	m := embeddedModel(t)
+	targets := []Target{
+		{Kind: "normal", Distance: 7},
+		{Kind: "normal", Distance: 7, Nearby: 4},
+	}
+
+	if order := m.Order(targets, nil); order[0] != 1 {
+		t.Errorf("Expected the monster in a pack to be attacked first, got %v", order)
+	}
+}
+
+func TestOrderTies(t *testing.T) {
+	m := Model{}
+	targets := []Target{{Distance: 5}, {Distance: 3}, {Distance: 3}}
+
+	order := m.Order(targets, nil)
+	if order[0] != 1 || order[1] != 2 || order[2] != 0 {
+		t.Errorf("Expected ties to go to the closest target and then the input order, got %v", order)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "threat_model.json")
+
+	if err := os.WriteFile(path, []byte(`{"monsters": {"shaman": 10}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path); err == nil {
+		t.Errorf("Expected monsters not keyed by npc ID to be rejected")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"enchantments": {"multishot": 20}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path); err == nil {
+		t.Errorf("Expected enchantments that can't be read from the monsters to be rejected")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"monsters": {"58": 10}, "distancePenalty": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if score := Current().Score(Target{ID: 58, Distance: 1}, nil); score != 8 {
+		t.Errorf("Expected the loaded model to be used, got score %v", score)
+	}
+
+	if err := Load(filepath.Join(dir, "missing.json")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if Current().Tags["shaman"] == 0 {
+		t.Errorf("Expected the embedded model to be used when the file is missing")
+	}
+}
+
+func TestDanger(t *testing.T) {
+	m := embeddedModel(t)
+	normal := Target{Kind: "normal", Distance: 1}
+	conviction := Target{Kind: "champion", States: []int{28}, Distance: 20}
+
+	if m.Danger(normal) != m.DangerBase {
+		t.Errorf("Expected a normal monster to have the base danger, got %v", m.Danger(normal))
+	}
+	if m.Danger(conviction) <= m.Danger(normal) {
+		t.Errorf("Expected a champion with conviction to be more dangerous than a normal monster")
+	}
+	if m.Radius(normal) != m.DangerRadius || m.Radius(conviction) <= m.DangerRadius {
+		t.Errorf("Expected auras to extend the danger radius, got %d and %d", m.Radius(normal), m.Radius(conviction))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/nip"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/itemlog"
 	"github.com/hectorgimenez/koolo/internal/ui"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
@@ -275,7 +276,8 @@ func SellJunk(lockConfig ...[][]int) {
 
 	// Existing logic to sell other junk items, now with lockConfig support
 	for _, i := range ItemsToBeSold(lockConfig...) {
-		SellItem(i)
+		itemlog.Add(itemlog.NewRecord(ctx.Name, i.Item, itemlog.DecisionSell, i.Reason, i.Rule))
+		SellItem(i.Item)
 	}
 }
 
@@ -349,7 +351,15 @@ func buyFullStack(i data.Item, currentKeysInInventory int) {
 	ctx.Logger.Debug(fmt.Sprintf("Finished full stack purchase attempt for %s", i.Desc().Name))
 }
 
-func ItemsToBeSold(lockConfig ...[][]int) (items []data.Item) {
+// ItemToBeSold is an inventory item that is going to be sold and why
+type ItemToBeSold struct {
+	data.Item
+	Reason itemlog.Reason
+	// Rule is the NIP rule the item matched, empty when it matched none
+	Rule nip.Rule
+}
+
+func ItemsToBeSold(lockConfig ...[][]int) (items []ItemToBeSold) {
 	ctx := context.Get()
 	_, portalTomeFound := ctx.Data.Inventory.Find(item.TomeOfTownPortal, item.LocationInventory)
 	healingPotionCountToKeep := ctx.Data.ConfiguredInventoryPotionCount(data.HealingPotion)
@@ -389,7 +399,8 @@ func ItemsToBeSold(lockConfig ...[][]int) (items []data.Item) {
 			continue
 		}
 
-		if _, result := ctx.Data.CharacterCfg.Runtime.Rules.EvaluateAllIgnoreTiers(itm); result == nip.RuleResultFullMatch && !itm.IsPotion() {
+		rule, result := ctx.Data.CharacterCfg.Runtime.Rules.EvaluateAllIgnoreTiers(itm)
+		if result == nip.RuleResultFullMatch && !itm.IsPotion() {
 			continue
 		}
 
@@ -418,7 +429,18 @@ func ItemsToBeSold(lockConfig ...[][]int) (items []data.Item) {
 			continue
 		}
 
-		items = append(items, itm)
+		if result == nip.RuleResultNoMatch {
+			rule = nip.Rule{}
+		}
+		reason := itemlog.ReasonNoRuleMatch
+		switch {
+		case itm.IsPotion():
+			reason = itemlog.ReasonPotionSurplus
+		case result == nip.RuleResultPartial:
+			// Unidentified items the rules could still want, e.g. when identifying them is disabled
+			reason = itemlog.ReasonRulePartial
+		}
+		items = append(items, ItemToBeSold{Item: itm, Reason: reason, Rule: rule})
 	}
 
 	return
@@ -20,4 +20,5 @@ var (
     GetWindowTextLength     = USER32.NewProc("GetWindowTextLengthW")
     RedrawWindow            = USER32.NewProc("RedrawWindow")
     UpdateWindow            = USER32.NewProc("UpdateWindow")
+    IsHungAppWindow         = USER32.NewProc("IsHungAppWindow")
 )
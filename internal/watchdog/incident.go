@@ -0,0 +1,53 @@
+package watchdog
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIncidents is the number of incidents kept, for all the supervisors
+const maxIncidents = 200
+
+// Incident is a failed health check and what was done about it
+type Incident struct {
+	Supervisor string    `json:"supervisor"`
+	Probe      string    `json:"probe"`
+	Reason     string    `json:"reason"`
+	At         time.Time `json:"at"`
+	Decision   Decision  `json:"decision"`
+}
+
+// IncidentLog keeps the most recent incidents in memory for the dashboard
+type IncidentLog struct {
+	mu        sync.RWMutex
+	incidents []Incident
+}
+
+func NewIncidentLog() *IncidentLog {
+	return &IncidentLog{incidents: make([]Incident, 0)}
+}
+
+func (l *IncidentLog) Add(incident Incident) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.incidents = append(l.incidents, incident)
+	if len(l.incidents) > maxIncidents {
+		l.incidents = l.incidents[len(l.incidents)-maxIncidents:]
+	}
+}
+
+// List returns the incidents of the given supervisor (all of them when empty), the most recent first
+func (l *IncidentLog) List(supervisor string) []Incident {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	incidents := make([]Incident, 0)
+	for i := len(l.incidents) - 1; i >= 0; i-- {
+		if supervisor == "" || l.incidents[i].Supervisor == supervisor {
+			incidents = append(incidents, l.incidents[i])
+		}
+	}
+
+	return incidents
+}
@@ -0,0 +1,87 @@
+package watchdog
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const DefaultCheckInterval = 5 * time.Second
+
+// Failure is the first failed probe of a health check
+type Failure struct {
+	Probe  string
+	Reason string
+	At     time.Time
+}
+
+// Monitor runs the probes of a supervisor periodically, it calls onFailure once with the first failure and stops
+type Monitor struct {
+	supervisor string
+	probes     []Probe
+	tracker    *RestartTracker
+	interval   time.Duration
+	logger     *slog.Logger
+	onFailure  func(Failure)
+	stopChan   chan struct{}
+	stopOnce   sync.Once
+}
+
+func NewMonitor(supervisor string, logger *slog.Logger, tracker *RestartTracker, onFailure func(Failure), probes ...Probe) *Monitor {
+	return &Monitor{
+		supervisor: supervisor,
+		probes:     probes,
+		tracker:    tracker,
+		interval:   DefaultCheckInterval,
+		logger:     logger,
+		onFailure:  onFailure,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+func (m *Monitor) Start() {
+	m.logger.Info("Starting health monitor", slog.String("supervisor", m.supervisor), slog.Int("probes", len(m.probes)))
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			m.logger.Info("Health monitor stopped", slog.String("supervisor", m.supervisor))
+			return
+		case now := <-ticker.C:
+			failure, failed := m.Check(now)
+			if !failed {
+				continue
+			}
+
+			m.logger.Error("Supervisor health check failed", slog.String("supervisor", m.supervisor), slog.String("probe", failure.Probe), slog.String("reason", failure.Reason))
+			if m.onFailure != nil {
+				m.onFailure(failure)
+			}
+			return
+		}
+	}
+}
+
+// Check runs every probe in order and returns the first failure. Healthy checks are recorded in the restart tracker.
+func (m *Monitor) Check(now time.Time) (Failure, bool) {
+	for _, probe := range m.probes {
+		if healthy, reason := probe.Check(now); !healthy {
+			return Failure{Probe: probe.Name(), Reason: reason, At: now}, true
+		}
+	}
+
+	if m.tracker != nil {
+		m.tracker.RecordHealthy(now)
+	}
+
+	return Failure{}, false
+}
+
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() {
+		m.logger.Info("Stopping health monitor", slog.String("supervisor", m.supervisor))
+		close(m.stopChan)
+	})
+}
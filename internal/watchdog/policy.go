@@ -0,0 +1,163 @@
+package watchdog
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionRestart Action = "restart"
+	// ActionDisable means the circuit breaker is open, the supervisor is not restarted until started again by hand
+	ActionDisable Action = "disable"
+)
+
+// RestartPolicy decides how supervisors are restarted after a failure
+type RestartPolicy struct {
+	// InitialBackoff is the delay before the first restart, every consecutive failure multiplies it by Multiplier
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// MaxRestartsPerHour delays the restart until the oldest restart of the last hour is over an hour old
+	MaxRestartsPerHour int
+	// BreakerThreshold is the number of consecutive failures that disables the supervisor
+	BreakerThreshold int
+	// StableAfter is how long a supervisor has to run healthy for its consecutive failures to be forgotten
+	StableAfter time.Duration
+}
+
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		InitialBackoff:     5 * time.Second,
+		MaxBackoff:         10 * time.Minute,
+		Multiplier:         2,
+		MaxRestartsPerHour: 6,
+		BreakerThreshold:   5,
+		StableAfter:        15 * time.Minute,
+	}
+}
+
+type Decision struct {
+	Action Action        `json:"action"`
+	Delay  time.Duration `json:"delay"`
+	Reason string        `json:"reason"`
+}
+
+// RestartStatus is the restart history of a supervisor as shown in the dashboard
+type RestartStatus struct {
+	RestartsLastHour    int        `json:"restartsLastHour"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Disabled            bool       `json:"disabled"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+}
+
+// RestartTracker keeps the restart history of a supervisor, it lives across restarts
+type RestartTracker struct {
+	mu          sync.Mutex
+	policy      RestartPolicy
+	restarts    []time.Time
+	consecutive int
+	lastStart   time.Time
+	disabledAt  time.Time
+}
+
+func NewRestartTracker(policy RestartPolicy) *RestartTracker {
+	return &RestartTracker{policy: policy}
+}
+
+// Decide returns what to do after a failure happening at the given time
+func (t *RestartTracker) Decide(now time.Time) Decision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.disabledAt.IsZero() {
+		return Decision{Action: ActionDisable, Reason: "supervisor is disabled by the circuit breaker"}
+	}
+
+	failures := t.consecutive + 1
+	if failures >= t.policy.BreakerThreshold {
+		t.disabledAt = now
+		return Decision{Action: ActionDisable, Reason: fmt.Sprintf("%d consecutive failures, circuit breaker open", failures)}
+	}
+
+	delay := time.Duration(float64(t.policy.InitialBackoff) * math.Pow(t.policy.Multiplier, float64(t.consecutive)))
+	if delay > t.policy.MaxBackoff || delay < 0 {
+		delay = t.policy.MaxBackoff
+	}
+	reason := fmt.Sprintf("failure %d, backoff %s", failures, delay)
+
+	restarts := t.recentRestarts(now)
+	if len(restarts) >= t.policy.MaxRestartsPerHour {
+		// Wait until enough restarts are older than an hour to be under the cap
+		capDelay := restarts[len(restarts)-t.policy.MaxRestartsPerHour].Add(time.Hour).Sub(now)
+		if capDelay > delay {
+			delay = capDelay
+			reason = fmt.Sprintf("%d restarts in the last hour, waiting %s", len(restarts), delay.Round(time.Second))
+		}
+	}
+
+	return Decision{Action: ActionRestart, Delay: delay, Reason: reason}
+}
+
+// RecordRestart is called when the supervisor is restarted after a failure
+func (t *RestartTracker) RecordRestart(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.restarts = append(t.recentRestarts(now), now)
+	t.consecutive++
+	t.lastStart = now
+}
+
+// RecordHealthy is called on every healthy check, the consecutive failures are forgotten once the supervisor has run
+// healthy long enough since its last restart
+func (t *RestartTracker) RecordHealthy(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.consecutive > 0 && now.Sub(t.lastStart) >= t.policy.StableAfter {
+		t.consecutive = 0
+	}
+}
+
+// Reset closes the circuit breaker and forgets the failures, it's called when the supervisor is started by hand
+func (t *RestartTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.restarts = nil
+	t.consecutive = 0
+	t.disabledAt = time.Time{}
+}
+
+func (t *RestartTracker) Status(now time.Time) RestartStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := RestartStatus{
+		RestartsLastHour:    len(t.recentRestarts(now)),
+		ConsecutiveFailures: t.consecutive,
+		Disabled:            !t.disabledAt.IsZero(),
+	}
+	if status.Disabled {
+		disabledAt := t.disabledAt
+		status.DisabledAt = &disabledAt
+	}
+
+	return status
+}
+
+// recentRestarts returns the restarts of the last hour, it must be called holding the lock
+func (t *RestartTracker) recentRestarts(now time.Time) []time.Time {
+	recent := make([]time.Time, 0, len(t.restarts))
+	for _, restart := range t.restarts {
+		if now.Sub(restart) < time.Hour {
+			recent = append(recent, restart)
+		}
+	}
+
+	return recent
+}
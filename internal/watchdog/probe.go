@@ -0,0 +1,113 @@
+package watchdog
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ProbeProcessAlive     = "process_alive"
+	ProbeWindowResponding = "window_responding"
+	ProbeDataFreshness    = "data_freshness"
+	ProbeMenuStuck        = "menu_stuck"
+)
+
+// Probe checks one aspect of the health of a supervisor, it's called periodically by the Monitor
+type Probe interface {
+	Name() string
+	// Check returns false along with the reason when the supervisor is not healthy
+	Check(now time.Time) (bool, string)
+}
+
+// ProcessAlive fails as soon as the game client process is gone
+type ProcessAlive struct {
+	IsRunning func() bool
+}
+
+func (p *ProcessAlive) Name() string {
+	return ProbeProcessAlive
+}
+
+func (p *ProcessAlive) Check(_ time.Time) (bool, string) {
+	if !p.IsRunning() {
+		return false, "game client process is not running"
+	}
+
+	return true, ""
+}
+
+// WindowResponding fails when the game window doesn't process messages for longer than Grace
+type WindowResponding struct {
+	IsHung func() bool
+	Grace  time.Duration
+
+	hungSince time.Time
+}
+
+func (p *WindowResponding) Name() string {
+	return ProbeWindowResponding
+}
+
+func (p *WindowResponding) Check(now time.Time) (bool, string) {
+	if !p.IsHung() {
+		p.hungSince = time.Time{}
+		return true, ""
+	}
+
+	if p.hungSince.IsZero() {
+		p.hungSince = now
+	}
+	if hung := now.Sub(p.hungSince); hung > p.Grace {
+		return false, fmt.Sprintf("game window not responding for %s", hung.Round(time.Second))
+	}
+
+	return true, ""
+}
+
+// DataFreshness fails when the game data was not refreshed for longer than MaxAge. LastRefresh returns false when the
+// data is not expected to be refreshed, e.g. out of game or paused.
+type DataFreshness struct {
+	LastRefresh func() (time.Time, bool)
+	MaxAge      time.Duration
+}
+
+func (p *DataFreshness) Name() string {
+	return ProbeDataFreshness
+}
+
+func (p *DataFreshness) Check(now time.Time) (bool, string) {
+	lastRefresh, expected := p.LastRefresh()
+	if !expected || lastRefresh.IsZero() {
+		return true, ""
+	}
+
+	if age := now.Sub(lastRefresh); age > p.MaxAge {
+		return false, fmt.Sprintf("game data not refreshed for %s", age.Round(time.Second))
+	}
+
+	return true, ""
+}
+
+// MenuStuck fails when the supervisor stays out of game for longer than MaxDuration. OutOfGameSince returns false
+// while in game.
+type MenuStuck struct {
+	OutOfGameSince func() (time.Time, bool)
+	MaxDuration    time.Duration
+}
+
+func (p *MenuStuck) Name() string {
+	return ProbeMenuStuck
+}
+
+func (p *MenuStuck) Check(now time.Time) (bool, string) {
+	since, outOfGame := p.OutOfGameSince()
+	if !outOfGame {
+		return true, ""
+	}
+
+	if stuck := now.Sub(since); stuck > p.MaxDuration {
+		return false, fmt.Sprintf("out of game for %s", stuck.Round(time.Second))
+	}
+
+	return true, ""
+}
@@ -0,0 +1,195 @@
+package watchdog
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeProbe struct {
+	name    string
+	healthy bool
+	checks  int
+}
+
+func (p *fakeProbe) Name() string {
+	return p.name
+}
+
+func (p *fakeProbe) Check(_ time.Time) (bool, string) {
+	p.checks++
+	if !p.healthy {
+		return false, p.name + " failed"
+	}
+
+	return true, ""
+}
+
+func testPolicy() RestartPolicy {
+	return RestartPolicy{
+		InitialBackoff:     10 * time.Second,
+		MaxBackoff:         time.Minute,
+		Multiplier:         2,
+		MaxRestartsPerHour: 3,
+		BreakerThreshold:   4,
+		StableAfter:        10 * time.Minute,
+	}
+}
+
+func TestMonitorReturnsFirstFailure(t *testing.T) {
+	process := &fakeProbe{name: "process", healthy: true}
+	window := &fakeProbe{name: "window", healthy: false}
+	data := &fakeProbe{name: "data", healthy: false}
+	m := NewMonitor("test", nil, nil, nil, process, window, data)
+
+	failure, failed := m.Check(time.Now())
+	if !failed {
+		t.Fatalf("Expected the check to fail")
+	}
+	if failure.Probe != "window" {
+		t.Errorf("Expected the window probe to fail first, got %s", failure.Probe)
+	}
+	if data.checks != 0 {
+		t.Errorf("Expected the probes after the first failure to be skipped, data probe was checked %d times", data.checks)
+	}
+}
+
+func TestMonitorHealthyCheckForgetsFailures(t *testing.T) {
+	start := time.Now()
+	tracker := NewRestartTracker(testPolicy())
+	tracker.RecordRestart(start)
+	m := NewMonitor("test", nil, tracker, nil, &fakeProbe{name: "process", healthy: true})
+
+	if _, failed := m.Check(start.Add(5 * time.Minute)); failed {
+		t.Fatalf("Expected the check to pass")
+	}
+	if failures := tracker.Status(start.Add(5 * time.Minute)).ConsecutiveFailures; failures != 1 {
+		t.Errorf("Expected the failure to be kept before the supervisor is stable, got %d failures", failures)
+	}
+
+	m.Check(start.Add(11 * time.Minute))
+	if failures := tracker.Status(start.Add(11 * time.Minute)).ConsecutiveFailures; failures != 0 {
+		t.Errorf("Expected the failures to be forgotten once stable, got %d failures", failures)
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	now := time.Now()
+	tracker := NewRestartTracker(testPolicy())
+
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second}
+	for i, delay := range expected {
+		decision := tracker.Decide(now)
+		if decision.Action != ActionRestart {
+			t.Fatalf("Failure %d: expected a restart, got %s", i+1, decision.Action)
+		}
+		if decision.Delay != delay {
+			t.Errorf("Failure %d: expected a %s backoff, got %s", i+1, delay, decision.Delay)
+		}
+		now = now.Add(decision.Delay + 30*time.Minute)
+		tracker.RecordRestart(now)
+	}
+}
+
+func TestRestartBackoffIsCapped(t *testing.T) {
+	policy := testPolicy()
+	policy.BreakerThreshold = 100
+	policy.MaxRestartsPerHour = 100
+	tracker := NewRestartTracker(policy)
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		tracker.RecordRestart(now)
+	}
+	if decision := tracker.Decide(now); decision.Delay != policy.MaxBackoff {
+		t.Errorf("Expected the backoff to be capped to %s, got %s", policy.MaxBackoff, decision.Delay)
+	}
+}
+
+func TestMaxRestartsPerHour(t *testing.T) {
+	policy := testPolicy()
+	policy.BreakerThreshold = 100
+	tracker := NewRestartTracker(policy)
+
+	start := time.Now()
+	tracker.RecordRestart(start)
+	tracker.RecordRestart(start.Add(10 * time.Minute))
+	tracker.RecordRestart(start.Add(20 * time.Minute))
+
+	now := start.Add(30 * time.Minute)
+	decision := tracker.Decide(now)
+	if decision.Action != ActionRestart {
+		t.Fatalf("Expected a restart, got %s", decision.Action)
+	}
+	if decision.Delay != 30*time.Minute {
+		t.Errorf("Expected to wait until the first restart is an hour old (30m), got %s", decision.Delay)
+	}
+
+	if restarts := tracker.Status(start.Add(65 * time.Minute)).RestartsLastHour; restarts != 2 {
+		t.Errorf("Expected restarts older than an hour to be forgotten, got %d restarts", restarts)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	tracker := NewRestartTracker(testPolicy())
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if decision := tracker.Decide(now); decision.Action != ActionRestart {
+			t.Fatalf("Failure %d: expected a restart, got %s", i+1, decision.Action)
+		}
+		tracker.RecordRestart(now)
+		now = now.Add(time.Hour)
+	}
+
+	if decision := tracker.Decide(now); decision.Action != ActionDisable {
+		t.Fatalf("Expected the breaker to open after 4 consecutive failures, got %s", decision.Action)
+	}
+	if !tracker.Status(now).Disabled {
+		t.Errorf("Expected the supervisor to be disabled")
+	}
+
+	// The breaker stays open even once the supervisor would be considered stable
+	if decision := tracker.Decide(now.Add(24 * time.Hour)); decision.Action != ActionDisable {
+		t.Errorf("Expected the breaker to stay open, got %s", decision.Action)
+	}
+
+	tracker.Reset()
+	if decision := tracker.Decide(now); decision.Action != ActionRestart || decision.Delay != 10*time.Second {
+		t.Errorf("Expected a restart with the initial backoff after a reset, got %s after %s", decision.Action, decision.Delay)
+	}
+}
+
+func TestWindowRespondingGrace(t *testing.T) {
+	hung := true
+	probe := &WindowResponding{IsHung: func() bool { return hung }, Grace: time.Minute}
+
+	now := time.Now()
+	if healthy, _ := probe.Check(now); !healthy {
+		t.Errorf("Expected a hung window to be tolerated during the grace period")
+	}
+	if healthy, _ := probe.Check(now.Add(2 * time.Minute)); healthy {
+		t.Errorf("Expected a window hung for longer than the grace period to fail")
+	}
+
+	hung = false
+	probe.Check(now.Add(3 * time.Minute))
+	hung = true
+	if healthy, _ := probe.Check(now.Add(4 * time.Minute)); !healthy {
+		t.Errorf("Expected the grace period to start again after the window responded")
+	}
+}
+
+func TestDataFreshnessOnlyWhenExpected(t *testing.T) {
+	now := time.Now()
+	expected := false
+	probe := &DataFreshness{LastRefresh: func() (time.Time, bool) { return now.Add(-time.Hour), expected }, MaxAge: time.Minute}
+
+	if healthy, _ := probe.Check(now); !healthy {
+		t.Errorf("Expected stale data to be ignored while not expected to be refreshed")
+	}
+
+	expected = true
+	if healthy, _ := probe.Check(now); healthy {
+		t.Errorf("Expected stale data to fail")
+	}
+}